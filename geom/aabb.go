@@ -0,0 +1,230 @@
+package geom
+
+import "math"
+
+// AABB is an axis-aligned bounding box. It contains all points which are
+// between Min and Max in each of the three dimensions. A box with any Min
+// coordinate greater than the corresponding Max coordinate is empty.
+type AABB struct {
+	Min, Max Vector
+}
+
+// Bounded represents an object in the 3D space which can report its extent.
+type Bounded interface {
+
+	// Bounds returns an AABB which contains the whole object.
+	Bounds() AABB
+}
+
+// NewAABB returns the smallest AABB which contains all `points`. Without any
+// points it returns an empty box.
+func NewAABB(points ...Vector) AABB {
+	b := EmptyAABB()
+	for _, p := range points {
+		b = b.Expand(p)
+	}
+	return b
+}
+
+// EmptyAABB returns an AABB which contains nothing. It is the identity
+// element for Union and Expand.
+func EmptyAABB() AABB {
+	inf := math.Inf(1)
+	return AABB{
+		Min: NewVector(inf, inf, inf),
+		Max: NewVector(-inf, -inf, -inf),
+	}
+}
+
+// InfiniteAABB returns an AABB which contains the whole space. It is used as
+// the bounds of objects with infinite or unknown extent.
+func InfiniteAABB() AABB {
+	inf := math.Inf(1)
+	return AABB{
+		Min: NewVector(-inf, -inf, -inf),
+		Max: NewVector(inf, inf, inf),
+	}
+}
+
+// IsEmpty returns true when `b` contains no points.
+func (b AABB) IsEmpty() bool {
+	return b.Min.X > b.Max.X || b.Min.Y > b.Max.Y || b.Min.Z > b.Max.Z
+}
+
+// IsFinite returns true when `b` is not empty and all of its coordinates are
+// finite.
+func (b AABB) IsFinite() bool {
+	if b.IsEmpty() {
+		return false
+	}
+	for _, c := range [...]float64{b.Min.X, b.Min.Y, b.Min.Z, b.Max.X, b.Max.Y, b.Max.Z} {
+		if math.IsInf(c, 0) || math.IsNaN(c) {
+			return false
+		}
+	}
+	return true
+}
+
+// Union returns the smallest AABB which contains both `b` and `other`.
+func (b AABB) Union(other AABB) AABB {
+	return AABB{
+		Min: NewVector(
+			math.Min(b.Min.X, other.Min.X),
+			math.Min(b.Min.Y, other.Min.Y),
+			math.Min(b.Min.Z, other.Min.Z),
+		),
+		Max: NewVector(
+			math.Max(b.Max.X, other.Max.X),
+			math.Max(b.Max.Y, other.Max.Y),
+			math.Max(b.Max.Z, other.Max.Z),
+		),
+	}
+}
+
+// Expand returns the smallest AABB which contains both `b` and the point `p`.
+func (b AABB) Expand(p Vector) AABB {
+	return b.Union(AABB{Min: p, Max: p})
+}
+
+// Contains returns true when the point `p` is inside `b` or on its boundary.
+func (b AABB) Contains(p Vector) bool {
+	return p.X >= b.Min.X && p.X <= b.Max.X &&
+		p.Y >= b.Min.Y && p.Y <= b.Max.Y &&
+		p.Z >= b.Min.Z && p.Z <= b.Max.Z
+}
+
+// Size returns the extent of `b` along each of the axes.
+func (b AABB) Size() Vector {
+	if b.IsEmpty() {
+		return Vector{}
+	}
+	return Sub(b.Max, b.Min)
+}
+
+// Center returns the center point of `b`.
+func (b AABB) Center() Vector {
+	return Mul(Add(b.Min, b.Max), 0.5)
+}
+
+// SurfaceArea returns the total area of the six faces of `b`. It is zero for
+// empty boxes.
+func (b AABB) SurfaceArea() float64 {
+	s := b.Size()
+	return 2 * (s.X*s.Y + s.Y*s.Z + s.Z*s.X)
+}
+
+// LongestAxis returns 0, 1 or 2 for the X, Y or Z axis, whichever `b` is
+// longest along.
+func (b AABB) LongestAxis() int {
+	s := b.Size()
+	switch {
+	case s.X >= s.Y && s.X >= s.Z:
+		return 0
+	case s.Y >= s.Z:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// Corners returns the eight corner points of `b`.
+func (b AABB) Corners() [8]Vector {
+	var corners [8]Vector
+	for i := range corners {
+		corners[i] = b.Min
+		if i&1 != 0 {
+			corners[i].X = b.Max.X
+		}
+		if i&2 != 0 {
+			corners[i].Y = b.Max.Y
+		}
+		if i&4 != 0 {
+			corners[i].Z = b.Max.Z
+		}
+	}
+	return corners
+}
+
+// Transform returns an AABB which contains `b` transformed by `m`. Boxes
+// which are not finite become infinite.
+func (b AABB) Transform(m Matrix) AABB {
+	if b.IsEmpty() {
+		return b
+	}
+	if !b.IsFinite() {
+		return InfiniteAABB()
+	}
+
+	result := EmptyAABB()
+	for _, c := range b.Corners() {
+		result = result.Expand(m.Point(c))
+	}
+	return result
+}
+
+// Intersect implements the Intersectable interface. It returns true when
+// `ray` passes through the box, including its boundary.
+func (b AABB) Intersect(ray Ray) bool {
+	_, _, ok := b.IntersectRay(ray, 0, math.Inf(1))
+	return ok
+}
+
+// IntersectRay returns the ray parameters at which `ray` enters and exits `b`,
+// clipped to the interval [`tMin`, `tMax`] and the limits of the ray. Its last
+// return value is false when the ray misses the box in this interval.
+//
+// Direction components which are zero are handled explicitly, so rays parallel
+// to and lying on a face of the box count as hits. Infinite boxes and rays with
+// infinite inverse directions are supported as well.
+func (b AABB) IntersectRay(ray Ray, tMin, tMax float64) (float64, float64, bool) {
+	tMin, tMax = ray.Clip(tMin, tMax)
+	if tMin > tMax {
+		return 0, 0, false
+	}
+
+	origin := [3]float64{ray.Origin.X, ray.Origin.Y, ray.Origin.Z}
+	dir := [3]float64{ray.Direction.X, ray.Direction.Y, ray.Direction.Z}
+	min := [3]float64{b.Min.X, b.Min.Y, b.Min.Z}
+	max := [3]float64{b.Max.X, b.Max.Y, b.Max.Z}
+
+	for axis := 0; axis < 3; axis++ {
+		if dir[axis] == 0 {
+			if origin[axis] < min[axis] || origin[axis] > max[axis] {
+				return 0, 0, false
+			}
+			continue
+		}
+
+		inv := 1 / dir[axis]
+		tNear := (min[axis] - origin[axis]) * inv
+		tFar := (max[axis] - origin[axis]) * inv
+		if tNear > tFar {
+			tNear, tFar = tFar, tNear
+		}
+
+		// Make the test conservative in respect to the rounding errors
+		// of the computations above.
+		tFar *= 1 + 2*gamma3
+
+		// The comparisons are written so that NaNs leave the interval
+		// unchanged.
+		if tNear > tMin {
+			tMin = tNear
+		}
+		if tFar < tMax {
+			tMax = tFar
+		}
+		if tMin > tMax {
+			return 0, 0, false
+		}
+	}
+
+	return tMin, tMax, true
+}
+
+// gamma3 is the bound of the relative rounding error of three consecutive
+// floating point operations.
+const gamma3 = 3 * machineEpsilon / (1 - 3*machineEpsilon)
+
+// machineEpsilon is half of the distance between 1.0 and the next float64.
+const machineEpsilon = 1.1102230246251565e-16
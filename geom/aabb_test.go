@@ -0,0 +1,144 @@
+package geom
+
+import (
+	"math"
+	"testing"
+)
+
+func TestAABBIntersectRay(t *testing.T) {
+	box := NewAABB(NewVector(-1, -1, -1), NewVector(1, 1, 1))
+	inf := math.Inf(1)
+
+	tests := []struct {
+		description string
+		box         AABB
+		ray         Ray
+		intersected bool
+		tNear, tFar float64
+	}{
+		{
+			description: "simple intersection",
+			box:         box,
+			ray:         NewRay(NewVector(0, 0, -5), NewVector(0, 0, 1)),
+			intersected: true,
+			tNear:       4,
+			tFar:        6,
+		},
+		{
+			description: "origin inside the box",
+			box:         box,
+			ray:         NewRay(NewVector(0, 0, 0), NewVector(0, 0, 2)),
+			intersected: true,
+			tNear:       0,
+			tFar:        0.5,
+		},
+		{
+			description: "opposite direction",
+			box:         box,
+			ray:         NewRay(NewVector(0, 0, -5), NewVector(0, 0, -1)),
+			intersected: false,
+		},
+		{
+			description: "diagonal miss",
+			box:         box,
+			ray:         NewRay(NewVector(-3, 0, 0), NewVector(1, 2, 0)),
+			intersected: false,
+		},
+		{
+			description: "zero direction components inside the slabs",
+			box:         box,
+			ray:         NewRay(NewVector(0.5, 0.5, -5), NewVector(0, 0, 1)),
+			intersected: true,
+			tNear:       4,
+			tFar:        6,
+		},
+		{
+			description: "zero direction components outside the slabs",
+			box:         box,
+			ray:         NewRay(NewVector(1.5, 0.5, -5), NewVector(0, 0, 1)),
+			intersected: false,
+		},
+		{
+			description: "ray along a face",
+			box:         box,
+			ray:         NewRay(NewVector(1, 1, -5), NewVector(0, 0, 1)),
+			intersected: true,
+			tNear:       4,
+			tFar:        6,
+		},
+		{
+			description: "flat box",
+			box:         NewAABB(NewVector(-1, -1, 0), NewVector(1, 1, 0)),
+			ray:         NewRay(NewVector(0, 0, 1), NewVector(0, 0, -1)),
+			intersected: true,
+			tNear:       1,
+			tFar:        1,
+		},
+		{
+			description: "infinite box",
+			box:         InfiniteAABB(),
+			ray:         NewRay(NewVector(1, 2, 3), NewVector(1, -1, 0)),
+			intersected: true,
+			tNear:       0,
+			tFar:        inf,
+		},
+		{
+			description: "half space",
+			box:         AABB{Min: NewVector(-inf, -inf, -inf), Max: NewVector(inf, inf, 0)},
+			ray:         NewRay(NewVector(0, 0, 5), NewVector(0, 0, -1)),
+			intersected: true,
+			tNear:       5,
+			tFar:        inf,
+		},
+		{
+			description: "empty box",
+			box:         EmptyAABB(),
+			ray:         NewRay(NewVector(0, 0, -5), NewVector(0, 0, 1)),
+			intersected: false,
+		},
+	}
+
+	for _, test := range tests {
+		tNear, tFar, ok := test.box.IntersectRay(test.ray, 0, inf)
+		if ok != test.intersected {
+			t.Errorf("%s: expected intersection to be %t but it was %t", test.description, test.intersected, ok)
+			continue
+		}
+		if !ok {
+			continue
+		}
+		if math.Abs(tNear-test.tNear) > 1e-9 || (tFar != test.tFar && math.Abs(tFar-test.tFar) > 1e-9) {
+			t.Errorf("%s: expected interval [%g, %g] but got [%g, %g]",
+				test.description, test.tNear, test.tFar, tNear, tFar)
+		}
+	}
+}
+
+func TestAABBOperations(t *testing.T) {
+	box := NewAABB(NewVector(0, 0, 0), NewVector(1, 2, 3))
+
+	if area := box.SurfaceArea(); area != 22 {
+		t.Errorf("Expected surface area 22 but got %g", area)
+	}
+	if area := EmptyAABB().SurfaceArea(); area != 0 {
+		t.Errorf("Expected empty box surface area 0 but got %g", area)
+	}
+
+	union := box.Union(NewAABB(NewVector(-1, 1, 1)))
+	if union.Min != NewVector(-1, 0, 0) || union.Max != NewVector(1, 2, 3) {
+		t.Errorf("Unexpected union %v", union)
+	}
+	if EmptyAABB().Union(box) != box {
+		t.Errorf("Expected the empty box to be the identity for unions")
+	}
+
+	expanded := box.Expand(NewVector(5, -1, 1))
+	if expanded.Min != NewVector(0, -1, 0) || expanded.Max != NewVector(5, 2, 3) {
+		t.Errorf("Unexpected expanded box %v", expanded)
+	}
+
+	moved := box.Transform(Translate(NewVector(1, 1, 1)))
+	if moved.Min != NewVector(1, 1, 1) || moved.Max != NewVector(2, 3, 4) {
+		t.Errorf("Unexpected transformed box %v", moved)
+	}
+}
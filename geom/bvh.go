@@ -0,0 +1,434 @@
+package geom
+
+import (
+	"math"
+	"runtime"
+	"sync"
+)
+
+// BVH is a bounding volume hierarchy. It is an Intersectable which groups
+// many objects in a tree of nested bounding boxes, so that a ray only has to
+// be tested against the few objects whose boxes it passes through.
+//
+// Objects which are not Bounded, or whose bounds are not finite, can not be
+// put in the tree. They are kept aside and tested against every ray.
+type BVH struct {
+	tree bvhTree
+
+	// objects are the bounded objects, ordered so that every leaf covers
+	// a contiguous range of them.
+	objects []Intersectable
+
+	unbounded []Intersectable
+	bounds    AABB
+}
+
+// bvhTree is the tree of a bounding volume hierarchy. It knows nothing about
+// the primitives it contains besides their bounds and refers to them by their
+// indices. It is shared by the BVH and the Mesh types.
+type bvhTree struct {
+	// nodes is the tree flattened in depth-first order. The first child
+	// of an interior node always follows it immediately.
+	nodes []bvhNode
+
+	// order lists the indices of the primitives so that every leaf covers
+	// a contiguous range of it.
+	order []int
+}
+
+// bvhNode is a node of the flattened tree. For leaves `count` is the number of
+// primitives in the leaf and `offset` is the position of the first one in the
+// order of the tree. For interior
+// nodes `count` is zero and `offset` is the index of the second child.
+type bvhNode struct {
+	bounds AABB
+	offset int
+	count  int
+	axis   int
+}
+
+// bvhBuildNode is a node of the tree while it is being built.
+type bvhBuildNode struct {
+	bounds      AABB
+	left, right *bvhBuildNode
+	start, end  int
+	axis        int
+}
+
+// bvhPrimitive holds the information needed for building the tree for a
+// single primitive.
+type bvhPrimitive struct {
+	index    int
+	bounds   AABB
+	centroid Vector
+}
+
+const (
+	// bvhBins is the number of bins along an axis used for estimating
+	// the surface area heuristic of the possible splits.
+	bvhBins = 16
+
+	// bvhMaxLeafSize is the number of objects above which a leaf is
+	// split even if the surface area heuristic prefers not to.
+	bvhMaxLeafSize = 8
+
+	// bvhTraversalCost is the cost of visiting a node relative to the
+	// cost of intersecting a single object.
+	bvhTraversalCost = 0.125
+
+	// bvhParallelSize is the number of objects in a subtree above which
+	// its children are built concurrently.
+	bvhParallelSize = 4096
+)
+
+// NewBVH returns a BVH which contains all `objects`. The tree is built using
+// the surface area heuristic, evaluated over a fixed number of bins along each
+// axis. Large subtrees are built concurrently on all available CPUs.
+func NewBVH(objects []Intersectable) *BVH {
+	bvh := &BVH{bounds: EmptyAABB()}
+
+	var bounded []Intersectable
+	var bounds []AABB
+	for _, obj := range objects {
+		b, ok := obj.(Bounded)
+		if !ok {
+			bvh.unbounded = append(bvh.unbounded, obj)
+			continue
+		}
+
+		box := b.Bounds()
+		if box.IsEmpty() {
+			continue
+		}
+		if !box.IsFinite() {
+			bvh.unbounded = append(bvh.unbounded, obj)
+			continue
+		}
+
+		bounded = append(bounded, obj)
+		bounds = append(bounds, box)
+	}
+
+	if len(bvh.unbounded) > 0 {
+		bvh.bounds = InfiniteAABB()
+	}
+
+	bvh.tree = newBVHTree(bounds)
+	bvh.bounds = bvh.bounds.Union(bvh.tree.bounds())
+	bvh.objects = make([]Intersectable, len(bounded))
+	for i, index := range bvh.tree.order {
+		bvh.objects[i] = bounded[index]
+	}
+
+	return bvh
+}
+
+// newBVHTree returns the tree for primitives with finite `bounds`.
+func newBVHTree(bounds []AABB) bvhTree {
+	if len(bounds) == 0 {
+		return bvhTree{}
+	}
+
+	prims := make([]bvhPrimitive, len(bounds))
+	for i, b := range bounds {
+		prims[i] = bvhPrimitive{index: i, bounds: b, centroid: b.Center()}
+	}
+
+	b := &bvhBuilder{
+		prims:   prims,
+		workers: make(chan struct{}, runtime.GOMAXPROCS(0)),
+	}
+	root := b.build(0, len(prims))
+	b.wg.Wait()
+
+	tree := bvhTree{order: make([]int, len(prims))}
+	for i, p := range prims {
+		tree.order[i] = p.index
+	}
+	tree.flatten(root)
+
+	return tree
+}
+
+// bvhBuilder builds the tree of a BVH. Subtrees are built in separate
+// goroutines as long as there are free workers.
+type bvhBuilder struct {
+	prims   []bvhPrimitive
+	workers chan struct{}
+	wg      sync.WaitGroup
+}
+
+// build returns the root of a subtree for the primitives in [start, end). It
+// reorders the primitives in this range.
+func (b *bvhBuilder) build(start, end int) *bvhBuildNode {
+	node := &bvhBuildNode{start: start, end: end, bounds: EmptyAABB()}
+	centroids := EmptyAABB()
+	for _, p := range b.prims[start:end] {
+		node.bounds = node.bounds.Union(p.bounds)
+		centroids = centroids.Expand(p.centroid)
+	}
+
+	count := end - start
+	if count == 1 {
+		return node
+	}
+
+	axis, mid := b.split(node.bounds, centroids, start, end)
+	if mid == start || mid == end {
+		return node
+	}
+
+	node.axis = axis
+	if count < bvhParallelSize {
+		node.left = b.build(start, mid)
+		node.right = b.build(mid, end)
+		return node
+	}
+
+	select {
+	case b.workers <- struct{}{}:
+		b.wg.Add(1)
+		go func() {
+			defer func() {
+				<-b.workers
+				b.wg.Done()
+			}()
+			node.left = b.build(start, mid)
+		}()
+	default:
+		node.left = b.build(start, mid)
+	}
+	node.right = b.build(mid, end)
+
+	return node
+}
+
+// split chooses how to divide the primitives in [start, end) in two groups
+// and partitions them accordingly. It returns the split axis and the index
+// of the first primitive in the second group. When it is cheaper to keep the
+// primitives in a leaf the returned index is `end`.
+func (b *bvhBuilder) split(bounds, centroids AABB, start, end int) (int, int) {
+	count := end - start
+	axis := centroids.LongestAxis()
+	min, max := axisOf(centroids.Min, axis), axisOf(centroids.Max, axis)
+
+	if min == max {
+		// All centroids are at the same place. There is no sensible split
+		// but big leaves are still divided in two halves.
+		if count <= bvhMaxLeafSize {
+			return axis, end
+		}
+		return axis, start + count/2
+	}
+
+	var bins [bvhBins]struct {
+		bounds AABB
+		count  int
+	}
+	for i := range bins {
+		bins[i].bounds = EmptyAABB()
+	}
+
+	binOf := func(p bvhPrimitive) int {
+		i := int(bvhBins * (axisOf(p.centroid, axis) - min) / (max - min))
+		if i >= bvhBins {
+			i = bvhBins - 1
+		}
+		return i
+	}
+
+	for _, p := range b.prims[start:end] {
+		i := binOf(p)
+		bins[i].count++
+		bins[i].bounds = bins[i].bounds.Union(p.bounds)
+	}
+
+	// Sweep from the right to find the area and count of every suffix and
+	// then from the left evaluating the cost of each split.
+	var rightArea [bvhBins]float64
+	var rightCount [bvhBins]int
+	acc, n := EmptyAABB(), 0
+	for i := bvhBins - 1; i > 0; i-- {
+		acc = acc.Union(bins[i].bounds)
+		n += bins[i].count
+		rightArea[i] = acc.SurfaceArea()
+		rightCount[i] = n
+	}
+
+	bestBin, bestCost := 0, math.Inf(1)
+	acc, n = EmptyAABB(), 0
+	for i := 0; i < bvhBins-1; i++ {
+		acc = acc.Union(bins[i].bounds)
+		n += bins[i].count
+		cost := float64(n)*acc.SurfaceArea() + float64(rightCount[i+1])*rightArea[i+1]
+		if cost < bestCost {
+			bestBin, bestCost = i, cost
+		}
+	}
+
+	leafCost := float64(count)
+	splitCost := bvhTraversalCost + bestCost/bounds.SurfaceArea()
+	if count <= bvhMaxLeafSize && leafCost <= splitCost {
+		return axis, end
+	}
+
+	mid := start
+	for i := start; i < end; i++ {
+		if binOf(b.prims[i]) <= bestBin {
+			b.prims[i], b.prims[mid] = b.prims[mid], b.prims[i]
+			mid++
+		}
+	}
+
+	return axis, mid
+}
+
+// flatten appends the subtree with root `node` to tree.nodes in depth-first
+// order.
+func (tree *bvhTree) flatten(node *bvhBuildNode) {
+	index := len(tree.nodes)
+	tree.nodes = append(tree.nodes, bvhNode{bounds: node.bounds, axis: node.axis})
+
+	if node.left == nil {
+		tree.nodes[index].offset = node.start
+		tree.nodes[index].count = node.end - node.start
+		return
+	}
+
+	tree.flatten(node.left)
+	tree.nodes[index].offset = len(tree.nodes)
+	tree.flatten(node.right)
+}
+
+// bounds returns the bounds of all primitives in the tree.
+func (tree *bvhTree) bounds() AABB {
+	if len(tree.nodes) == 0 {
+		return EmptyAABB()
+	}
+	return tree.nodes[0].bounds
+}
+
+// Bounds implements the Bounded interface. A BVH with unbounded objects has
+// infinite bounds.
+func (bvh *BVH) Bounds() AABB {
+	return bvh.bounds
+}
+
+// Intersect implements the Intersectable interface. It stops at the first
+// object found to intersect `ray`, which is not necessarily the closest one.
+func (bvh *BVH) Intersect(ray Ray) bool {
+	return bvh.Occluded(ray, math.Inf(1))
+}
+
+// IntersectHit implements the Intersector interface. It returns the closest
+// hit among all objects. Objects which are not Intersectors are ignored.
+func (bvh *BVH) IntersectHit(ray Ray) (Hit, bool) {
+	return bvh.ClosestHit(ray, 0, math.Inf(1))
+}
+
+// Occluded implements the Occluder interface. It stops at the first object
+// found to intersect `ray` before `tMax`.
+func (bvh *BVH) Occluded(ray Ray, tMax float64) bool {
+	tMin, tMax := ray.Clip(0, tMax)
+	if tMin > tMax {
+		return false
+	}
+
+	for _, obj := range bvh.unbounded {
+		if Occluded(obj, ray, tMax) {
+			return true
+		}
+	}
+
+	found := false
+	bvh.tree.traverse(ray, tMin, &tMax, func(i int) bool {
+		found = Occluded(bvh.objects[i], ray, tMax)
+		return found
+	})
+	return found
+}
+
+// ClosestHit implements the ClosestHitter interface. Objects which are not
+// Intersectors are ignored.
+func (bvh *BVH) ClosestHit(ray Ray, tMin, tMax float64) (Hit, bool) {
+	tMin, tMax = ray.Clip(tMin, tMax)
+	if tMin > tMax {
+		return Hit{}, false
+	}
+
+	var closest Hit
+	found := false
+
+	try := func(obj Intersectable) {
+		if hit, ok := ClosestHit(obj, ray, tMin, tMax); ok {
+			closest, found, tMax = hit, true, hit.T
+		}
+	}
+
+	for _, obj := range bvh.unbounded {
+		try(obj)
+	}
+	bvh.tree.traverse(ray, tMin, &tMax, func(i int) bool {
+		try(bvh.objects[i])
+		return false
+	})
+
+	return closest, found
+}
+
+// traverse calls `visit` for the primitives in all leaves whose boxes `ray`
+// passes through with ray parameters in [`tMin`, `*tMax`]. The argument of
+// `visit` is the position of the primitive in the order of the tree. `visit`
+// may shrink `*tMax` to skip the nodes behind the hits it has already found.
+// Children are visited front to back. The traversal stops as soon as `visit`
+// returns true.
+func (tree *bvhTree) traverse(ray Ray, tMin float64, tMax *float64, visit func(int) bool) {
+	if len(tree.nodes) == 0 {
+		return
+	}
+
+	negative := [3]bool{ray.Direction.X < 0, ray.Direction.Y < 0, ray.Direction.Z < 0}
+
+	var buf [64]int
+	stack := buf[:0]
+	current := 0
+	for {
+		node := &tree.nodes[current]
+		if _, _, ok := node.bounds.IntersectRay(ray, tMin, *tMax); ok {
+			if node.count > 0 {
+				for i := node.offset; i < node.offset+node.count; i++ {
+					if visit(i) {
+						return
+					}
+				}
+			} else if negative[node.axis] {
+				stack = append(stack, current+1)
+				current = node.offset
+				continue
+			} else {
+				stack = append(stack, node.offset)
+				current++
+				continue
+			}
+		}
+
+		if len(stack) == 0 {
+			return
+		}
+		current = stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+	}
+}
+
+// axisOf returns the coordinate of `v` along `axis`, which is 0, 1 or 2 for
+// X, Y or Z.
+func axisOf(v Vector, axis int) float64 {
+	switch axis {
+	case 0:
+		return v.X
+	case 1:
+		return v.Y
+	default:
+		return v.Z
+	}
+}
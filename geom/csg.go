@@ -0,0 +1,388 @@
+package geom
+
+import (
+	"math"
+	"sort"
+)
+
+// Interval is a part of a ray which is inside a solid object. The ray enters
+// the object at In and leaves it at Out.
+type Interval struct {
+	In, Out Hit
+}
+
+// Solid is a ClosestHitter which encloses a volume. Rays alternately enter and
+// leave it, so its intersections with a ray form a list of intervals.
+type Solid interface {
+	ClosestHitter
+
+	// Intervals returns the parts of the whole line of `ray` which are
+	// inside this object, sorted by their ray parameters. The intervals do
+	// not overlap. The limits of `ray` are ignored. Intervals which extend
+	// to infinity have a hit with an infinite ray parameter at this end.
+	//
+	// The In hits have their FrontFace set and the Out hits do not. The
+	// normals of both point against the ray, as usual.
+	Intervals(ray Ray) []Interval
+}
+
+// CSGOperation is the way a CSG node combines its two operands.
+type CSGOperation int
+
+const (
+	// CSGUnion keeps the points which are in any of the operands.
+	CSGUnion CSGOperation = iota
+
+	// CSGIntersection keeps the points which are in both operands.
+	CSGIntersection
+
+	// CSGDifference keeps the points of the first operand which are not
+	// in the second.
+	CSGDifference
+)
+
+// String returns the name of the operation.
+func (op CSGOperation) String() string {
+	switch op {
+	case CSGUnion:
+		return "union"
+	case CSGIntersection:
+		return "intersection"
+	case CSGDifference:
+		return "difference"
+	default:
+		return "unknown"
+	}
+}
+
+// contains tells whether a point which is or is not in the operands, as told
+// by `inA` and `inB`, is in the result of the operation.
+func (op CSGOperation) contains(inA, inB bool) bool {
+	switch op {
+	case CSGUnion:
+		return inA || inB
+	case CSGIntersection:
+		return inA && inB
+	default:
+		return inA && !inB
+	}
+}
+
+// CSG is a Solid which combines two other solids using constructive solid
+// geometry. CSG nodes are solids themselves, so they can be nested for
+// building more complex objects.
+//
+// Surfaces of the operands which coincide, such as a face of a box cut out of
+// another box along one of its faces, do not leave slivers in the result. The
+// hit is reported from whichever operand makes the ray enter or leave it.
+type CSG struct {
+	op   CSGOperation
+	a, b Solid
+
+	tolerance Tolerance
+}
+
+// Union returns a CSG node which contains the points of both `a` and `b`.
+func Union(a, b Solid) *CSG {
+	return &CSG{op: CSGUnion, a: a, b: b, tolerance: DefaultTolerance}
+}
+
+// Intersection returns a CSG node which contains the points common to `a`
+// and `b`.
+func Intersection(a, b Solid) *CSG {
+	return &CSG{op: CSGIntersection, a: a, b: b, tolerance: DefaultTolerance}
+}
+
+// Difference returns a CSG node which contains the points of `a` which are not
+// in `b`. For example, a sphere with a cylinder drilled through it.
+func Difference(a, b Solid) *CSG {
+	return &CSG{op: CSGDifference, a: a, b: b, tolerance: DefaultTolerance}
+}
+
+// WithTolerance returns a copy of the node which tells whether points are on
+// the surfaces of its operands with `tolerance`. Union, Intersection and
+// Difference use DefaultTolerance.
+func (c *CSG) WithTolerance(tolerance Tolerance) *CSG {
+	node := *c
+	node.tolerance = tolerance
+	return &node
+}
+
+// Operation returns the operation of the node.
+func (c *CSG) Operation() CSGOperation {
+	return c.op
+}
+
+// Operands returns the two solids combined by the node.
+func (c *CSG) Operands() (Solid, Solid) {
+	return c.a, c.b
+}
+
+// Intersect implements the Intersectable interface.
+func (c *CSG) Intersect(ray Ray) bool {
+	return c.Occluded(ray, math.Inf(1))
+}
+
+// IntersectHit implements the Intersector interface.
+func (c *CSG) IntersectHit(ray Ray) (Hit, bool) {
+	return c.ClosestHit(ray, 0, math.Inf(1))
+}
+
+// Occluded implements the Occluder interface.
+func (c *CSG) Occluded(ray Ray, tMax float64) bool {
+	_, ok := c.ClosestHit(ray, 0, tMax)
+	return ok
+}
+
+// ClosestHit implements the ClosestHitter interface. It returns the first
+// boundary of the intervals of the node in [`tMin`, `tMax`]. For rays
+// starting inside the node this is where they leave it.
+func (c *CSG) ClosestHit(ray Ray, tMin, tMax float64) (Hit, bool) {
+	tMin, tMax = ray.Clip(tMin, tMax)
+	for _, in := range c.Intervals(ray) {
+		switch {
+		case in.In.T > tMax:
+			return Hit{}, false
+		case in.In.T >= tMin:
+			return in.In, true
+		case in.Out.T >= tMin && in.Out.T <= tMax:
+			return in.Out, true
+		}
+	}
+	return Hit{}, false
+}
+
+// Contains implements the Container interface. Operands which are not
+// Containers are tested with their intervals along lines through `p`.
+//
+// The surface of a difference which comes from its second operand is part of
+// it, so the points which are in the second operand, but within the tolerance
+// of the node from its surface, are not taken out of the first one.
+func (c *CSG) Contains(p Vector) bool {
+	inA := c.contains(c.a, p)
+	if !inA && c.op != CSGUnion {
+		return false
+	}
+	if c.op == CSGDifference {
+		return !c.interior(c.b, p)
+	}
+	return c.op.contains(inA, c.contains(c.b, p))
+}
+
+// contains tells whether `p` is in the operand `s` or within the tolerance of
+// the node from its surface.
+func (c *CSG) contains(s Solid, p Vector) bool {
+	if container, ok := s.(Container); ok {
+		return container.Contains(p)
+	}
+	return intervalsContain(s, p, solidEpsilon(c.tolerance, s, p))
+}
+
+// interior tells whether `p` is in the operand `s` and farther than the
+// tolerance of the node from its surface. Since the surface is within the
+// tolerance along some of the lines through points on it, such points must
+// be deep inside along all of them.
+func (c *CSG) interior(s Solid, p Vector) bool {
+	eps := solidEpsilon(c.tolerance, s, p)
+	for _, dir := range containsDirections {
+		deep := false
+		for _, in := range s.Intervals(NewRay(p, dir)) {
+			if in.In.T < -eps && in.Out.T > eps {
+				deep = true
+				break
+			}
+		}
+		if !deep {
+			return false
+		}
+	}
+	return true
+}
+
+// containsDirections are the directions of the lines through a point along
+// which the intervals of solids are checked. They are not in one plane, so at
+// least one of them crosses the surface of a solid at any of its points,
+// instead of touching it.
+var containsDirections = [3]Vector{{1, 0, 0}, {0, 1, 0}, {0, 0, 1}}
+
+// intervalsContain tells whether `p` is in `s` or within `eps` from its
+// surface, using the intervals of `s` along lines through `p`.
+func intervalsContain(s Solid, p Vector, eps float64) bool {
+	for _, dir := range containsDirections {
+		for _, in := range s.Intervals(NewRay(p, dir)) {
+			if in.In.T <= eps && in.Out.T >= -eps {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// solidEpsilon returns the distance from the surface of `s` within which `p`
+// is on it with `tol`. It is relative to the size of the bounds of `s`, or to
+// the distance of `p` from the origin for unbounded solids.
+func solidEpsilon(tol Tolerance, s Solid, p Vector) float64 {
+	size := Len(p)
+	if b, ok := s.(Bounded); ok {
+		if bounds := b.Bounds(); bounds.IsFinite() {
+			size = Len(bounds.Size())
+		}
+	}
+	return tol.Epsilon(size)
+}
+
+// Intervals implements the Solid interface. The intervals of the operands are
+// merged by sweeping along the ray and tracking whether it is inside each of
+// them.
+func (c *CSG) Intervals(ray Ray) []Interval {
+	a := c.a.Intervals(ray)
+	if len(a) == 0 && c.op != CSGUnion {
+		return nil
+	}
+	b := c.b.Intervals(ray)
+	return combineIntervals(c.op, a, b)
+}
+
+// Bounds implements the Bounded interface. Nodes with operands which are not
+// Bounded have infinite bounds.
+func (c *CSG) Bounds() AABB {
+	a, okA := c.a.(Bounded)
+	b, okB := c.b.(Bounded)
+
+	switch c.op {
+	case CSGUnion:
+		if !okA || !okB {
+			return InfiniteAABB()
+		}
+		return a.Bounds().Union(b.Bounds())
+	case CSGIntersection:
+		switch {
+		case okA && okB:
+			return intersectBounds(a.Bounds(), b.Bounds())
+		case okA:
+			return a.Bounds()
+		case okB:
+			return b.Bounds()
+		}
+		return InfiniteAABB()
+	default:
+		if !okA {
+			return InfiniteAABB()
+		}
+		return a.Bounds()
+	}
+}
+
+// intersectBounds returns the box common to `a` and `b`.
+func intersectBounds(a, b AABB) AABB {
+	common := AABB{
+		Min: NewVector(math.Max(a.Min.X, b.Min.X), math.Max(a.Min.Y, b.Min.Y), math.Max(a.Min.Z, b.Min.Z)),
+		Max: NewVector(math.Min(a.Max.X, b.Max.X), math.Min(a.Max.Y, b.Max.Y), math.Min(a.Max.Z, b.Max.Z)),
+	}
+	if common.IsEmpty() {
+		return EmptyAABB()
+	}
+	return common
+}
+
+// csgEvent is a place where a ray enters or leaves one of the operands of a
+// CSG node.
+type csgEvent struct {
+	hit    Hit
+	second bool
+	enter  bool
+}
+
+// combineIntervals returns the intervals of the result of `op` on solids with
+// intervals `a` and `b`.
+//
+// Events with the same ray parameter are handled together, so surfaces shared
+// by the operands change the result at most once. Within such a group the
+// entries are applied before the exits. When only this intermediate state is
+// inside the result, as for rays touching one of the operands, the result is
+// an interval of zero length.
+func combineIntervals(op CSGOperation, a, b []Interval) []Interval {
+	events := make([]csgEvent, 0, 2*(len(a)+len(b)))
+	for _, in := range a {
+		events = append(events, csgEvent{hit: in.In, enter: true}, csgEvent{hit: in.Out})
+	}
+	for _, in := range b {
+		events = append(events,
+			csgEvent{hit: in.In, second: true, enter: true},
+			csgEvent{hit: in.Out, second: true},
+		)
+	}
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].hit.T < events[j].hit.T
+	})
+
+	// depth counts the intervals of each operand the sweep is in.
+	var depth [2]int
+	inside := func() bool {
+		return op.contains(depth[0] > 0, depth[1] > 0)
+	}
+	apply := func(group []csgEvent, enter bool) {
+		for _, e := range group {
+			if e.enter != enter {
+				continue
+			}
+			i := 0
+			if e.second {
+				i = 1
+			}
+			if enter {
+				depth[i]++
+			} else {
+				depth[i]--
+			}
+		}
+	}
+
+	var result []Interval
+	var current Interval
+	for start := 0; start < len(events); {
+		end := start + 1
+		for end < len(events) && events[end].hit.T == events[start].hit.T {
+			end++
+		}
+		group := events[start:end]
+		start = end
+
+		before := inside()
+		apply(group, true)
+		middle := inside()
+		apply(group, false)
+		after := inside()
+
+		switch {
+		case !before && after:
+			current.In = boundaryHit(group, true)
+		case before && !after:
+			current.Out = boundaryHit(group, false)
+			result = append(result, current)
+		case !before && middle && !after:
+			hit := boundaryHit(group, true)
+			current.In = hit
+			current.Out = hit
+			current.Out.FrontFace = false
+			result = append(result, current)
+		}
+	}
+
+	return result
+}
+
+// boundaryHit returns the hit of the result at the events of `group`, where
+// the ray enters the result when `enter` is true or leaves it otherwise. It
+// prefers the events which enter or leave an operand in the same way.
+func boundaryHit(group []csgEvent, enter bool) Hit {
+	hit := group[0].hit
+	for _, e := range group {
+		if e.enter == enter {
+			hit = e.hit
+			break
+		}
+	}
+	hit.FrontFace = enter
+	return hit
+}
@@ -0,0 +1,189 @@
+package geom
+
+import (
+	"math"
+	"testing"
+)
+
+// span returns an interval along the X axis from `in` to `out`.
+func span(in, out float64) Interval {
+	return Interval{
+		In:  Hit{T: in, Normal: NewVector(-1, 0, 0), FrontFace: true},
+		Out: Hit{T: out, Normal: NewVector(-1, 0, 0)},
+	}
+}
+
+func TestCombineIntervals(t *testing.T) {
+	inf := math.Inf(1)
+
+	tests := []struct {
+		description string
+		op          CSGOperation
+		a, b        []Interval
+		expected    [][2]float64
+	}{
+		{"union of disjoint", CSGUnion, []Interval{span(0, 1)}, []Interval{span(2, 3)}, [][2]float64{{0, 1}, {2, 3}}},
+		{"union of overlapping", CSGUnion, []Interval{span(0, 2)}, []Interval{span(1, 3)}, [][2]float64{{0, 3}}},
+		{"union of touching", CSGUnion, []Interval{span(0, 1)}, []Interval{span(1, 3)}, [][2]float64{{0, 3}}},
+		{"union with nothing", CSGUnion, nil, []Interval{span(1, 3)}, [][2]float64{{1, 3}}},
+		{"union with infinite", CSGUnion, []Interval{span(-inf, 1)}, []Interval{span(0, 3)}, [][2]float64{{-inf, 3}}},
+		{"union keeps tangents", CSGUnion, []Interval{span(0, 1)}, []Interval{span(2, 2)}, [][2]float64{{0, 1}, {2, 2}}},
+		{"intersection of overlapping", CSGIntersection, []Interval{span(0, 2)}, []Interval{span(1, 3)}, [][2]float64{{1, 2}}},
+		{"intersection of disjoint", CSGIntersection, []Interval{span(0, 1)}, []Interval{span(2, 3)}, nil},
+		{"intersection of many", CSGIntersection, []Interval{span(0, 2), span(3, 5)}, []Interval{span(1, 4)}, [][2]float64{{1, 2}, {3, 4}}},
+		{"intersection of equal", CSGIntersection, []Interval{span(0, 2)}, []Interval{span(0, 2)}, [][2]float64{{0, 2}}},
+		{"difference in the middle", CSGDifference, []Interval{span(0, 3)}, []Interval{span(1, 2)}, [][2]float64{{0, 1}, {2, 3}}},
+		{"difference of the start", CSGDifference, []Interval{span(0, 3)}, []Interval{span(-1, 1)}, [][2]float64{{1, 3}}},
+		{"difference of everything", CSGDifference, []Interval{span(0, 3)}, []Interval{span(-1, 4)}, nil},
+		{"difference of shared end", CSGDifference, []Interval{span(0, 2)}, []Interval{span(1, 2)}, [][2]float64{{0, 1}}},
+		{"difference of shared start", CSGDifference, []Interval{span(0, 2)}, []Interval{span(0, 1)}, [][2]float64{{1, 2}}},
+		{"difference of tangent", CSGDifference, []Interval{span(0, 2)}, []Interval{span(1, 1)}, [][2]float64{{0, 2}}},
+	}
+
+	for _, test := range tests {
+		result := combineIntervals(test.op, test.a, test.b)
+		if len(result) != len(test.expected) {
+			t.Errorf("%s: expected %d intervals but got %d: %+v", test.description, len(test.expected), len(result), result)
+			continue
+		}
+		for i, in := range result {
+			if in.In.T != test.expected[i][0] || in.Out.T != test.expected[i][1] {
+				t.Errorf("%s: expected interval %d to be %v but it was [%g, %g]",
+					test.description, i, test.expected[i], in.In.T, in.Out.T)
+			}
+			if !in.In.FrontFace || in.Out.FrontFace {
+				t.Errorf("%s: expected interval %d to be entered at its front face and left at its back face",
+					test.description, i)
+			}
+		}
+	}
+}
+
+func TestCombineIntervalsHits(t *testing.T) {
+	a := []Interval{span(0, 3)}
+	b := []Interval{{
+		In:  Hit{T: 1, Normal: NewVector(-1, 0, 0), FrontFace: true, U: 0.25},
+		Out: Hit{T: 2, Normal: NewVector(-1, 0, 0), U: 0.75},
+	}}
+
+	result := combineIntervals(CSGDifference, a, b)
+	if len(result) != 2 {
+		t.Fatalf("Expected two intervals but got %+v", result)
+	}
+
+	// The ray leaves the difference where it enters the second operand and
+	// enters it again where it leaves the second operand.
+	if result[0].Out.U != 0.25 || result[1].In.U != 0.75 {
+		t.Errorf("Expected the inner boundaries to come from the second operand but got %+v", result)
+	}
+	if result[1].In.Normal != NewVector(-1, 0, 0) {
+		t.Errorf("Expected the normal to keep pointing against the ray but it was %+v", result[1].In.Normal)
+	}
+}
+
+// ball is a Solid sphere which is not a Container.
+type ball struct {
+	center Vector
+	radius float64
+}
+
+func (b ball) Intersect(ray Ray) bool {
+	return b.Occluded(ray, math.Inf(1))
+}
+
+func (b ball) IntersectHit(ray Ray) (Hit, bool) {
+	return b.ClosestHit(ray, 0, math.Inf(1))
+}
+
+func (b ball) Occluded(ray Ray, tMax float64) bool {
+	_, ok := b.ClosestHit(ray, 0, tMax)
+	return ok
+}
+
+func (b ball) ClosestHit(ray Ray, tMin, tMax float64) (Hit, bool) {
+	tMin, tMax = ray.Clip(tMin, tMax)
+	for _, in := range b.Intervals(ray) {
+		switch {
+		case in.In.T >= tMin && in.In.T <= tMax:
+			return in.In, true
+		case in.Out.T >= tMin && in.Out.T <= tMax:
+			return in.Out, true
+		}
+	}
+	return Hit{}, false
+}
+
+func (b ball) Intervals(ray Ray) []Interval {
+	oc := Sub(ray.Origin, b.center)
+	a := Dot(ray.Direction, ray.Direction)
+	half := Dot(oc, ray.Direction)
+	d := half*half - a*(Dot(oc, oc)-b.radius*b.radius)
+	if d < 0 {
+		return nil
+	}
+	in := Hit{T: (-half - math.Sqrt(d)) / a}
+	out := Hit{T: (-half + math.Sqrt(d)) / a}
+	in.Point, out.Point = ray.At(in.T), ray.At(out.T)
+	in.SetFaceNormal(ray, Sub(in.Point, b.center))
+	out.SetFaceNormal(ray, Sub(out.Point, b.center))
+	return []Interval{{In: in, Out: out}}
+}
+
+func (b ball) Bounds() AABB {
+	r := NewVector(b.radius, b.radius, b.radius)
+	return AABB{Min: Sub(b.center, r), Max: Add(b.center, r)}
+}
+
+func TestCSGContains(t *testing.T) {
+	big := ball{center: NewVector(0, 0, 0), radius: 2}
+	small := ball{center: NewVector(1, 0, 0), radius: 1}
+
+	tests := []struct {
+		description string
+		solid       Container
+		p           Vector
+		inside      bool
+	}{
+		{"union of the first", Union(big, small), NewVector(-1.5, 0, 0), true},
+		{"union outside", Union(big, small), NewVector(0, 2.5, 0), false},
+		{"intersection of both", Intersection(big, small), NewVector(1.5, 0, 0), true},
+		{"intersection of one", Intersection(big, small), NewVector(-1.5, 0, 0), false},
+		{"difference inside", Difference(big, small), NewVector(-1.5, 0, 0), true},
+		{"difference of the second", Difference(big, small), NewVector(1, 0, 0), false},
+		{"difference on the first", Difference(big, small), NewVector(0, -2, 0), true},
+		{"difference on the second", Difference(big, small), NewVector(0, 0, 0), true},
+		{"difference on the second off axis", Difference(big, small), NewVector(1.6, 0.8, 0), true},
+		{"difference just in the second", Difference(big, small), NewVector(1e-6, 0, 0), false},
+		{"difference outside", Difference(big, small), NewVector(-2.5, 0, 0), false},
+		{"difference with a coarse tolerance", Difference(big, small).WithTolerance(Tolerance{Absolute: 1e-3}),
+			NewVector(1e-4, 0, 0), true},
+	}
+
+	for _, test := range tests {
+		if inside := test.solid.Contains(test.p); inside != test.inside {
+			t.Errorf("%s: expected containment to be %t but it was %t", test.description, test.inside, inside)
+		}
+	}
+
+	moved, err := Transformed(small, Translate(NewVector(0, 5, 0)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !moved.Contains(NewVector(1, 5.5, 0)) || moved.Contains(NewVector(1, 0, 0)) {
+		t.Errorf("Expected a transformed solid to contain the transformed points only")
+	}
+}
+
+func TestIntersectBounds(t *testing.T) {
+	first := AABB{Min: NewVector(0, 0, 0), Max: NewVector(2, 2, 2)}
+	second := AABB{Min: NewVector(1, 1, 1), Max: NewVector(3, 3, 3)}
+	common := intersectBounds(first, second)
+	if common.Min != NewVector(1, 1, 1) || common.Max != NewVector(2, 2, 2) {
+		t.Errorf("Expected the common box to be [1, 2]³ but it was %+v", common)
+	}
+
+	far := AABB{Min: NewVector(5, 5, 5), Max: NewVector(6, 6, 6)}
+	if !intersectBounds(first, far).IsEmpty() {
+		t.Errorf("Expected disjoint boxes to have nothing in common")
+	}
+}
@@ -0,0 +1,74 @@
+package geom
+
+// Distancer is an object which can find its point closest to a given point.
+type Distancer interface {
+
+	// ClosestPoint returns the point of this object which is closest to
+	// `p`, together with the part of the object it is on.
+	ClosestPoint(p Vector) Closest
+
+	// Distance returns the distance from `p` to this object. It is the
+	// same as the distance to the point returned by ClosestPoint.
+	Distance(p Vector) float64
+}
+
+// Feature is a part of the boundary of a polygon, such as a triangle.
+type Feature int
+
+const (
+	// FeatureFace is the inside of a polygon or a smooth surface.
+	FeatureFace Feature = iota
+
+	// FeatureEdge is an edge of a polygon, excluding its end points.
+	FeatureEdge
+
+	// FeatureVertex is a vertex of a polygon.
+	FeatureVertex
+)
+
+// String returns the name of the feature.
+func (f Feature) String() string {
+	switch f {
+	case FeatureFace:
+		return "face"
+	case FeatureEdge:
+		return "edge"
+	case FeatureVertex:
+		return "vertex"
+	default:
+		return "unknown"
+	}
+}
+
+// Closest describes the point of an object which is closest to another
+// point, as returned by a Distancer.
+type Closest struct {
+	// Point is the closest point of the object.
+	Point Vector
+
+	// Distance is the distance from the query point to Point.
+	Distance float64
+
+	// Feature is the part of the object Point is on. For edges and
+	// vertices Index is the index of the edge or the vertex. Edge i of a
+	// polygon goes from vertex i to the next one.
+	Feature Feature
+	Index   int
+
+	// U and V are the surface coordinates of Point. Their exact meaning
+	// depends on the object, but it is the same as for its hits.
+	U, V float64
+}
+
+// newClosest returns the Closest for the query point `p` and the closest
+// point `q`.
+func newClosest(p, q Vector, feature Feature, index int, u, v float64) Closest {
+	return Closest{
+		Point:    q,
+		Distance: Len(Sub(q, p)),
+		Feature:  feature,
+		Index:    index,
+		U:        u,
+		V:        v,
+	}
+}
@@ -0,0 +1,87 @@
+package geom
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestClosestPointOnTriangle(t *testing.T) {
+	a, b, c := NewVector(0, 0, 0), NewVector(2, 0, 0), NewVector(0, 2, 0)
+
+	tests := []struct {
+		description string
+		p           Vector
+		point       Vector
+		feature     Feature
+		index       int
+		u, v        float64
+	}{
+		{"above the face", NewVector(0.5, 0.5, 3), NewVector(0.5, 0.5, 0), FeatureFace, 0, 0.25, 0.25},
+		{"below the face", NewVector(0.2, 1, -1), NewVector(0.2, 1, 0), FeatureFace, 0, 0.1, 0.5},
+		{"in the face", NewVector(1, 0.5, 0), NewVector(1, 0.5, 0), FeatureFace, 0, 0.5, 0.25},
+		{"first vertex", NewVector(-1, -1, 1), a, FeatureVertex, 0, 0, 0},
+		{"second vertex", NewVector(3, -0.5, 0), b, FeatureVertex, 1, 1, 0},
+		{"third vertex", NewVector(-0.5, 4, 2), c, FeatureVertex, 2, 0, 1},
+		{"first edge", NewVector(1.5, -2, 1), NewVector(1.5, 0, 0), FeatureEdge, 0, 0.75, 0},
+		{"second edge", NewVector(2, 2, 0), NewVector(1, 1, 0), FeatureEdge, 1, 0.5, 0.5},
+		{"third edge", NewVector(-3, 0.5, -1), NewVector(0, 0.5, 0), FeatureEdge, 2, 0, 0.25},
+		{"on an edge", NewVector(1, 0, 5), NewVector(1, 0, 0), FeatureEdge, 0, 0.5, 0},
+	}
+
+	for _, test := range tests {
+		closest := ClosestPointOnTriangle(test.p, a, b, c)
+		if Len(Sub(closest.Point, test.point)) > 1e-12 {
+			t.Errorf("%s: expected the closest point to be %v but it was %v", test.description, test.point, closest.Point)
+		}
+		if d := Len(Sub(test.p, test.point)); math.Abs(closest.Distance-d) > 1e-12 {
+			t.Errorf("%s: expected the distance to be %g but it was %g", test.description, d, closest.Distance)
+		}
+		if closest.Feature != test.feature || closest.Index != test.index {
+			t.Errorf("%s: expected the closest point on %s %d but it was on %s %d",
+				test.description, test.feature, test.index, closest.Feature, closest.Index)
+		}
+		if math.Abs(closest.U-test.u) > 1e-12 || math.Abs(closest.V-test.v) > 1e-12 {
+			t.Errorf("%s: expected coordinates (%g, %g) but they were (%g, %g)",
+				test.description, test.u, test.v, closest.U, closest.V)
+		}
+	}
+}
+
+func TestClosestPointOnTriangleRandom(t *testing.T) {
+	rnd := rand.New(rand.NewSource(5))
+	random := func() Vector {
+		return NewVector(rnd.Float64()*4-2, rnd.Float64()*4-2, rnd.Float64()*4-2)
+	}
+
+	for i := 0; i < 1000; i++ {
+		a, b, c := random(), random(), random()
+		p := random()
+		closest := ClosestPointOnTriangle(p, a, b, c)
+
+		point := Add(a, Add(Mul(Sub(b, a), closest.U), Mul(Sub(c, a), closest.V)))
+		if Len(Sub(point, closest.Point)) > 1e-9 {
+			t.Fatalf("The barycentric coordinates of %+v do not match its point", closest)
+		}
+
+		// No point of the triangle is closer.
+		for j := 0; j < 100; j++ {
+			u, v := rnd.Float64(), rnd.Float64()
+			if u+v > 1 {
+				u, v = 1-u, 1-v
+			}
+			q := Add(a, Add(Mul(Sub(b, a), u), Mul(Sub(c, a), v)))
+			if d := Len(Sub(q, p)); d < closest.Distance-1e-9 {
+				t.Fatalf("Point %v of the triangle is closer to %v than %+v", q, p, closest)
+			}
+		}
+	}
+}
+
+func TestClosestPointOnDegenerateTriangle(t *testing.T) {
+	a, b, c := NewVector(0, 0, 0), NewVector(2, 0, 0), NewVector(1, 0, 0)
+	closest := ClosestPointOnTriangle(NewVector(0.5, 1, 0), a, b, c)
+	if Len(Sub(closest.Point, NewVector(0.5, 0, 0))) > 1e-12 || closest.Distance != 1 {
+		t.Errorf("Expected the closest point of a flat triangle to be on its base but it was %+v", closest)
+	}
+}
@@ -0,0 +1,36 @@
+package geom
+
+// Hit describes the place where a ray struck an object.
+type Hit struct {
+	// T is the ray parameter of the intersection. The hit point is
+	// Origin + T*Direction of the ray which produced this hit.
+	T float64
+
+	// Point is the intersection point in the 3D space.
+	Point Vector
+
+	// Normal is the unit surface normal at Point. It always points against
+	// the incoming ray, regardless of which side of the surface was hit.
+	Normal Vector
+
+	// FrontFace is true when the ray struck the side of the surface which
+	// the geometric (outward) normal points to.
+	FrontFace bool
+
+	// U and V are the surface coordinates of Point. Their exact meaning
+	// depends on the object which was hit.
+	U, V float64
+}
+
+// SetFaceNormal sets h.Normal and h.FrontFace from the `outward` geometric
+// normal of the surface and the `ray` which hit it. `outward` does not have to
+// be of unit length.
+func (h *Hit) SetFaceNormal(ray Ray, outward Vector) {
+	outward = Normalize(outward)
+	h.FrontFace = Dot(ray.Direction, outward) < 0
+	if h.FrontFace {
+		h.Normal = outward
+	} else {
+		h.Normal = Neg(outward)
+	}
+}
@@ -0,0 +1,127 @@
+package geom
+
+import (
+	"errors"
+	"math"
+)
+
+// ErrSingularMatrix is returned when an object is transformed by a matrix which
+// is not invertible.
+var ErrSingularMatrix = errors.New("geom: singular transformation matrix")
+
+// Instance is an Intersectable which places another object in the scene
+// using an affine transformation. A single object may be shared by many
+// instances.
+type Instance struct {
+	object Intersectable
+
+	// toWorld transforms from the object space to the world space and
+	// toObject is its inverse. normal transforms object space normals to
+	// world space normals.
+	toWorld, toObject, normal Matrix
+}
+
+// Transformed returns an Instance of `obj` transformed by `m`. For example a
+// sphere scaled along one of the axes becomes an ellipsoid. It returns
+// ErrSingularMatrix when `m` is not invertible, such as a scale by zero along
+// one of the axes.
+func Transformed(obj Intersectable, m Matrix) (*Instance, error) {
+	inv, ok := m.Inverse()
+	if !ok {
+		return nil, ErrSingularMatrix
+	}
+
+	return &Instance{
+		object:   obj,
+		toWorld:  m,
+		toObject: inv,
+		normal:   inv.Transpose(),
+	}, nil
+}
+
+// Object returns the object which is transformed by this instance.
+func (in *Instance) Object() Intersectable {
+	return in.object
+}
+
+// Matrix returns the transformation from object to world space.
+func (in *Instance) Matrix() Matrix {
+	return in.toWorld
+}
+
+// Intersect implements the Intersectable interface. The ray is transformed in
+// the object space and tested there.
+func (in *Instance) Intersect(ray Ray) bool {
+	return in.object.Intersect(in.toObject.Ray(ray))
+}
+
+// IntersectHit implements the Intersector interface. It never reports a hit
+// when the transformed object is not an Intersector itself.
+func (in *Instance) IntersectHit(ray Ray) (Hit, bool) {
+	return in.ClosestHit(ray, 0, math.Inf(1))
+}
+
+// Occluded implements the Occluder interface.
+func (in *Instance) Occluded(ray Ray, tMax float64) bool {
+	return Occluded(in.object, in.toObject.Ray(ray), tMax)
+}
+
+// ClosestHit implements the ClosestHitter interface.
+func (in *Instance) ClosestHit(ray Ray, tMin, tMax float64) (Hit, bool) {
+	hit, ok := ClosestHit(in.object, in.toObject.Ray(ray), tMin, tMax)
+	if !ok {
+		return Hit{}, false
+	}
+	return in.hitToWorld(ray, hit), true
+}
+
+// Contains implements the Container interface. Transformed objects which are
+// Solids, but not Containers, are tested with their intervals along lines
+// through the point, with DefaultTolerance. It returns false for other
+// objects.
+func (in *Instance) Contains(p Vector) bool {
+	local := in.toObject.Point(p)
+	switch obj := in.object.(type) {
+	case Container:
+		return obj.Contains(local)
+	case Solid:
+		return intervalsContain(obj, local, solidEpsilon(DefaultTolerance, obj, local))
+	default:
+		return false
+	}
+}
+
+// Intervals implements the Solid interface. It returns no intervals when the
+// transformed object is not a Solid itself.
+func (in *Instance) Intervals(ray Ray) []Interval {
+	obj, ok := in.object.(Solid)
+	if !ok {
+		return nil
+	}
+
+	intervals := obj.Intervals(in.toObject.Ray(ray))
+	for i := range intervals {
+		intervals[i].In = in.hitToWorld(ray, intervals[i].In)
+		intervals[i].Out = in.hitToWorld(ray, intervals[i].Out)
+	}
+	return intervals
+}
+
+// hitToWorld converts `hit` of the object space ray to a hit of `ray` in the
+// world space. The ray parameter stays the same since ray directions are not
+// normalized during the transformation.
+func (in *Instance) hitToWorld(ray Ray, hit Hit) Hit {
+	hit.Point = ray.At(hit.T)
+	hit.Normal = Normalize(in.normal.Direction(hit.Normal))
+	return hit
+}
+
+// Bounds implements the Bounded interface. Instances of objects which are not
+// Bounded have infinite bounds.
+func (in *Instance) Bounds() AABB {
+	obj, ok := in.object.(Bounded)
+	if !ok {
+		return InfiniteAABB()
+	}
+	return obj.Bounds().Transform(in.toWorld)
+}
@@ -2,8 +2,25 @@ package geom
 
 // Intersectable represents an object in the 3D space which can be tested for
 // intersections with rays.
+//
+// Queries must not modify the object, so Intersectables are safe for
+// concurrent use by multiple goroutines once they are built. All objects in
+// this package follow this rule, and renderers rely on it for tracing rays in
+// parallel. Objects which wrap other objects are safe as long as the wrapped
+// ones are.
 type Intersectable interface {
 
 	// Intersect returns true when `ray` intersects this object.
 	Intersect(ray Ray) bool
 }
+
+// Intersector is an Intersectable which can also describe where exactly a ray
+// strikes it.
+type Intersector interface {
+	Intersectable
+
+	// IntersectHit returns the closest intersection of `ray` with this
+	// object which is not behind the ray origin. Its second return value is
+	// false when there is no such intersection.
+	IntersectHit(ray Ray) (Hit, bool)
+}
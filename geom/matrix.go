@@ -0,0 +1,191 @@
+package geom
+
+import "math"
+
+// Matrix is a 4x4 matrix which represents an affine transformation of the 3D
+// space. It is stored in row-major order and is applied to column vectors, so
+// the translation part of the transformation is in the last column.
+type Matrix [4][4]float64
+
+// Identity returns the identity Matrix, the transformation which changes nothing.
+func Identity() Matrix {
+	return Matrix{
+		{1, 0, 0, 0},
+		{0, 1, 0, 0},
+		{0, 0, 1, 0},
+		{0, 0, 0, 1},
+	}
+}
+
+// Translate returns a Matrix which moves points by the vector `v`.
+func Translate(v Vector) Matrix {
+	return Matrix{
+		{1, 0, 0, v.X},
+		{0, 1, 0, v.Y},
+		{0, 0, 1, v.Z},
+		{0, 0, 0, 1},
+	}
+}
+
+// Scale returns a Matrix which scales the space by `v.X`, `v.Y` and `v.Z`
+// along each of the axes.
+func Scale(v Vector) Matrix {
+	return Matrix{
+		{v.X, 0, 0, 0},
+		{0, v.Y, 0, 0},
+		{0, 0, v.Z, 0},
+		{0, 0, 0, 1},
+	}
+}
+
+// RotateX returns a Matrix which rotates the space `angle` radians around the
+// X axis.
+func RotateX(angle float64) Matrix {
+	return Rotate(NewVector(1, 0, 0), angle)
+}
+
+// RotateY returns a Matrix which rotates the space `angle` radians around the
+// Y axis.
+func RotateY(angle float64) Matrix {
+	return Rotate(NewVector(0, 1, 0), angle)
+}
+
+// RotateZ returns a Matrix which rotates the space `angle` radians around the
+// Z axis.
+func RotateZ(angle float64) Matrix {
+	return Rotate(NewVector(0, 0, 1), angle)
+}
+
+// Rotate returns a Matrix which rotates the space `angle` radians around
+// `axis`, which passes through the origin. Positive angles rotate counter
+// clockwise when looking from the tip of `axis` towards the origin.
+func Rotate(axis Vector, angle float64) Matrix {
+	a := Normalize(axis)
+	sin, cos := math.Sincos(angle)
+	t := 1 - cos
+
+	return Matrix{
+		{t*a.X*a.X + cos, t*a.X*a.Y - sin*a.Z, t*a.X*a.Z + sin*a.Y, 0},
+		{t*a.X*a.Y + sin*a.Z, t*a.Y*a.Y + cos, t*a.Y*a.Z - sin*a.X, 0},
+		{t*a.X*a.Z - sin*a.Y, t*a.Y*a.Z + sin*a.X, t*a.Z*a.Z + cos, 0},
+		{0, 0, 0, 1},
+	}
+}
+
+// LookAt returns a Matrix which places an object at `eye` and turns it so
+// that its local Z axis points towards `target` and its local Y axis is as
+// close to `up` as possible. `up` must not be parallel to `target - eye`.
+func LookAt(eye, target, up Vector) Matrix {
+	z := Normalize(Sub(target, eye))
+	x := Normalize(Cross(up, z))
+	y := Cross(z, x)
+
+	return Matrix{
+		{x.X, y.X, z.X, eye.X},
+		{x.Y, y.Y, z.Y, eye.Y},
+		{x.Z, y.Z, z.Z, eye.Z},
+		{0, 0, 0, 1},
+	}
+}
+
+// Mul returns the composition of `m` and `n`. The result applies `n` first
+// and `m` after it.
+func (m Matrix) Mul(n Matrix) (result Matrix) {
+	for i := 0; i < 4; i++ {
+		for j := 0; j < 4; j++ {
+			for k := 0; k < 4; k++ {
+				result[i][j] += m[i][k] * n[k][j]
+			}
+		}
+	}
+	return
+}
+
+// Transpose returns the transposed `m`.
+func (m Matrix) Transpose() (result Matrix) {
+	for i := 0; i < 4; i++ {
+		for j := 0; j < 4; j++ {
+			result[i][j] = m[j][i]
+		}
+	}
+	return
+}
+
+// Inverse returns the inverse of `m`. Its second return value is false when
+// `m` is singular and has no inverse.
+func (m Matrix) Inverse() (Matrix, bool) {
+	inv := Identity()
+
+	// Gauss-Jordan elimination with partial pivoting.
+	for col := 0; col < 4; col++ {
+		pivot := col
+		for row := col + 1; row < 4; row++ {
+			if math.Abs(m[row][col]) > math.Abs(m[pivot][col]) {
+				pivot = row
+			}
+		}
+		if m[pivot][col] == 0 {
+			return Matrix{}, false
+		}
+		m[col], m[pivot] = m[pivot], m[col]
+		inv[col], inv[pivot] = inv[pivot], inv[col]
+
+		div := 1 / m[col][col]
+		for j := 0; j < 4; j++ {
+			m[col][j] *= div
+			inv[col][j] *= div
+		}
+
+		for row := 0; row < 4; row++ {
+			if row == col {
+				continue
+			}
+			f := m[row][col]
+			for j := 0; j < 4; j++ {
+				m[row][j] -= f * m[col][j]
+				inv[row][j] -= f * inv[col][j]
+			}
+		}
+	}
+
+	return inv, true
+}
+
+// Point returns the point `p` transformed by `m`.
+func (m Matrix) Point(p Vector) (v Vector) {
+	v.X = m[0][0]*p.X + m[0][1]*p.Y + m[0][2]*p.Z + m[0][3]
+	v.Y = m[1][0]*p.X + m[1][1]*p.Y + m[1][2]*p.Z + m[1][3]
+	v.Z = m[2][0]*p.X + m[2][1]*p.Y + m[2][2]*p.Z + m[2][3]
+	return
+}
+
+// Direction returns the direction vector `d` transformed by `m`. Unlike
+// points, directions are not affected by translations.
+func (m Matrix) Direction(d Vector) (v Vector) {
+	v.X = m[0][0]*d.X + m[0][1]*d.Y + m[0][2]*d.Z
+	v.Y = m[1][0]*d.X + m[1][1]*d.Y + m[1][2]*d.Z
+	v.Z = m[2][0]*d.X + m[2][1]*d.Y + m[2][2]*d.Z
+	return
+}
+
+// Normal returns the surface normal `n` transformed by `m` and normalized.
+// Normals are transformed by the inverse transpose of `m` so that they stay
+// perpendicular to the transformed surface. This requires inverting `m` on
+// every call, so when transforming many normals with the same matrix prefer
+// calling Direction with the precomputed inverse transpose.
+func (m Matrix) Normal(n Vector) Vector {
+	inv, ok := m.Inverse()
+	if !ok {
+		return Vector{}
+	}
+	return Normalize(inv.Transpose().Direction(n))
+}
+
+// Ray returns `ray` with its origin and direction transformed by `m`. The
+// direction is not normalized, so ray parameters along the result match the
+// ones along `ray`.
+func (m Matrix) Ray(ray Ray) Ray {
+	ray.Origin = m.Point(ray.Origin)
+	ray.Direction = m.Direction(ray.Direction)
+	return ray
+}
@@ -0,0 +1,130 @@
+package geom
+
+import (
+	"math"
+	"testing"
+)
+
+func TestMatrixTransformations(t *testing.T) {
+	tests := []struct {
+		description string
+		m           Matrix
+		point       Vector
+		expected    Vector
+	}{
+		{
+			description: "identity",
+			m:           Identity(),
+			point:       NewVector(1, 2, 3),
+			expected:    NewVector(1, 2, 3),
+		},
+		{
+			description: "translate",
+			m:           Translate(NewVector(1, -1, 2)),
+			point:       NewVector(1, 2, 3),
+			expected:    NewVector(2, 1, 5),
+		},
+		{
+			description: "scale",
+			m:           Scale(NewVector(2, 3, -1)),
+			point:       NewVector(1, 2, 3),
+			expected:    NewVector(2, 6, -3),
+		},
+		{
+			description: "rotate around x",
+			m:           RotateX(math.Pi / 2),
+			point:       NewVector(0, 1, 0),
+			expected:    NewVector(0, 0, 1),
+		},
+		{
+			description: "rotate around y",
+			m:           RotateY(math.Pi / 2),
+			point:       NewVector(0, 0, 1),
+			expected:    NewVector(1, 0, 0),
+		},
+		{
+			description: "rotate around z",
+			m:           RotateZ(math.Pi / 2),
+			point:       NewVector(1, 0, 0),
+			expected:    NewVector(0, 1, 0),
+		},
+		{
+			description: "composition applies the right matrix first",
+			m:           Translate(NewVector(1, 0, 0)).Mul(Scale(NewVector(2, 2, 2))),
+			point:       NewVector(1, 1, 1),
+			expected:    NewVector(3, 2, 2),
+		},
+		{
+			description: "look at",
+			m:           LookAt(NewVector(1, 1, 1), NewVector(1, 1, 5), NewVector(0, 1, 0)),
+			point:       NewVector(0, 0, 2),
+			expected:    NewVector(1, 1, 3),
+		},
+	}
+
+	for _, test := range tests {
+		actual := test.m.Point(test.point)
+		if !vectorsClose(actual, test.expected) {
+			t.Errorf("%s: expected %v but got %v", test.description, test.expected, actual)
+		}
+	}
+}
+
+func TestMatrixInverse(t *testing.T) {
+	m := Translate(NewVector(1, 2, 3)).
+		Mul(Rotate(NewVector(1, 1, 0), 0.7)).
+		Mul(Scale(NewVector(2, 0.5, 3)))
+
+	inv, ok := m.Inverse()
+	if !ok {
+		t.Fatalf("Expected %v to be invertible", m)
+	}
+
+	id := Identity()
+	product := m.Mul(inv)
+	for i := 0; i < 4; i++ {
+		for j := 0; j < 4; j++ {
+			if math.Abs(product[i][j]-id[i][j]) > 1e-9 {
+				t.Fatalf("Expected m * m^-1 to be the identity but it was %v", product)
+			}
+		}
+	}
+
+	if _, ok := Scale(NewVector(1, 0, 1)).Inverse(); ok {
+		t.Errorf("Expected a scale by zero to have no inverse")
+	}
+}
+
+func TestMatrixTranspose(t *testing.T) {
+	m := Translate(NewVector(1, 2, 3))
+	tr := m.Transpose()
+	if tr[3][0] != 1 || tr[3][1] != 2 || tr[3][2] != 3 || tr[0][3] != 0 {
+		t.Errorf("Unexpected transpose %v of %v", tr, m)
+	}
+	if tr.Transpose() != m {
+		t.Errorf("Expected transposing twice to return the same matrix")
+	}
+}
+
+func TestMatrixDirectionsAndNormals(t *testing.T) {
+	m := Translate(NewVector(5, 5, 5)).Mul(Scale(NewVector(2, 1, 1)))
+
+	d := m.Direction(NewVector(1, -1, 0))
+	if !vectorsClose(d, NewVector(2, -1, 0)) {
+		t.Errorf("Expected directions to ignore translation but got %v", d)
+	}
+
+	// The normal of the plane x + y = 0 stretched twice along X.
+	n := m.Normal(NewVector(1, 1, 0))
+	expected := Normalize(NewVector(0.5, 1, 0))
+	if !vectorsClose(n, expected) {
+		t.Errorf("Expected normal %v but got %v", expected, n)
+	}
+	if math.Abs(Dot(n, d)) > 1e-9 {
+		t.Errorf("Expected the normal %v to stay perpendicular to %v", n, d)
+	}
+}
+
+func vectorsClose(a, b Vector) bool {
+	return Len(Sub(a, b)) < 1e-9
+}
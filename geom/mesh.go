@@ -0,0 +1,253 @@
+package geom
+
+import (
+	"fmt"
+	"math"
+)
+
+// UV is a point in the two dimensional texture space of a surface.
+type UV struct {
+	U, V float64
+}
+
+// Mesh is an Intersectable which represents a surface made of triangles. The
+// triangles share a single buffer of vertices and refer to them by their
+// indices, so a vertex used by many triangles is stored only once.
+//
+// Each vertex has a position and optionally a normal and a UV. When normals
+// are available they are interpolated over the triangles, which makes the
+// mesh look smooth. The faces of the mesh are indexed in the order of their
+// vertex indices.
+type Mesh struct {
+	positions []Vector
+	normals   []Vector
+	uvs       []UV
+	indices   []int
+
+	tree      bvhTree
+	algorithm TriangleAlgorithm
+	tolerance Tolerance
+}
+
+// NewMesh returns a new Mesh with vertex positions `positions`. Every three
+// consecutive `indices` refer to the vertices of one triangle, which are
+// ordered counter clockwise when looking at its front side. `normals` and
+// `uvs` may be nil. Otherwise they must have the same length as `positions`.
+//
+// The slices are used directly by the mesh and must not be modified after
+// calling NewMesh. It returns an error when the lengths of the slices do not
+// match or an index is out of range.
+func NewMesh(positions, normals []Vector, uvs []UV, indices []int) (*Mesh, error) {
+	if len(indices)%3 != 0 {
+		return nil, fmt.Errorf("geom: mesh index count %d is not a multiple of 3", len(indices))
+	}
+	if normals != nil && len(normals) != len(positions) {
+		return nil, fmt.Errorf("geom: mesh has %d normals for %d vertices", len(normals), len(positions))
+	}
+	if uvs != nil && len(uvs) != len(positions) {
+		return nil, fmt.Errorf("geom: mesh has %d UVs for %d vertices", len(uvs), len(positions))
+	}
+	for i, index := range indices {
+		if index < 0 || index >= len(positions) {
+			return nil, fmt.Errorf("geom: mesh index %d of face %d is out of range [0, %d)",
+				index, i/3, len(positions))
+		}
+	}
+
+	m := &Mesh{
+		positions: positions,
+		normals:   normals,
+		uvs:       uvs,
+		indices:   indices,
+		tolerance: DefaultTolerance,
+	}
+
+	bounds := make([]AABB, m.NumFaces())
+	for i := range bounds {
+		a, b, c := m.facePositions(i)
+		bounds[i] = NewAABB(a, b, c)
+	}
+	m.tree = newBVHTree(bounds)
+
+	return m, nil
+}
+
+// Positions returns the positions of the vertices of the mesh.
+func (m *Mesh) Positions() []Vector {
+	return m.positions
+}
+
+// Normals returns the normals of the vertices of the mesh or nil when the
+// mesh has no normals.
+func (m *Mesh) Normals() []Vector {
+	return m.normals
+}
+
+// UVs returns the texture coordinates of the vertices of the mesh or nil when
+// the mesh has none.
+func (m *Mesh) UVs() []UV {
+	return m.uvs
+}
+
+// Indices returns the vertex indices of all faces of the mesh.
+func (m *Mesh) Indices() []int {
+	return m.indices
+}
+
+// NumFaces returns the number of triangles in the mesh.
+func (m *Mesh) NumFaces() int {
+	return len(m.indices) / 3
+}
+
+// Face returns the indices of the three vertices of face `i`.
+func (m *Mesh) Face(i int) [3]int {
+	return [3]int{m.indices[3*i], m.indices[3*i+1], m.indices[3*i+2]}
+}
+
+// facePositions returns the positions of the three vertices of face `i`.
+func (m *Mesh) facePositions(i int) (Vector, Vector, Vector) {
+	return m.positions[m.indices[3*i]], m.positions[m.indices[3*i+1]], m.positions[m.indices[3*i+2]]
+}
+
+// WithAlgorithm returns a copy of the mesh which intersects its faces using
+// `algorithm`. The copy shares the vertex buffers and the hierarchy of the
+// mesh, so it is cheap to make. NewMesh uses MollerTrumbore.
+func (m *Mesh) WithAlgorithm(algorithm TriangleAlgorithm) *Mesh {
+	mesh := *m
+	mesh.algorithm = algorithm
+	return &mesh
+}
+
+// WithTolerance returns a copy of the mesh whose faces are intersected with
+// `tolerance`. Like WithAlgorithm, it is cheap to make. NewMesh uses
+// DefaultTolerance.
+func (m *Mesh) WithTolerance(tolerance Tolerance) *Mesh {
+	mesh := *m
+	mesh.tolerance = tolerance
+	return &mesh
+}
+
+// Bounds implements the Bounded interface.
+func (m *Mesh) Bounds() AABB {
+	return m.tree.bounds()
+}
+
+// Intersect implements the Intersectable interface.
+func (m *Mesh) Intersect(ray Ray) bool {
+	return m.Occluded(ray, math.Inf(1))
+}
+
+// IntersectHit implements the Intersector interface.
+func (m *Mesh) IntersectHit(ray Ray) (Hit, bool) {
+	return m.ClosestHit(ray, 0, math.Inf(1))
+}
+
+// Occluded implements the Occluder interface. It stops at the first face
+// found to intersect `ray`.
+func (m *Mesh) Occluded(ray Ray, tMax float64) bool {
+	tMin, tMax := ray.Clip(0, tMax)
+	if tMin > tMax {
+		return false
+	}
+
+	found := false
+	m.tree.traverse(ray, tMin, &tMax, func(i int) bool {
+		a, b, c := m.facePositions(m.tree.order[i])
+		_, _, _, found = IntersectTriangle(m.algorithm, m.tolerance, ray, a, b, c, tMin, tMax)
+		return found
+	})
+	return found
+}
+
+// ClosestHit implements the ClosestHitter interface.
+func (m *Mesh) ClosestHit(ray Ray, tMin, tMax float64) (Hit, bool) {
+	hit, _, ok := m.ClosestFace(ray, tMin, tMax)
+	return hit, ok
+}
+
+// ClosestFace is like ClosestHit but also returns the index of the face
+// which was hit. The normal of the returned hit is interpolated from the
+// vertex normals when the mesh has them. Whether the hit is on the front face
+// is always decided by the winding order of the triangle.
+//
+// The U and V of the returned hit are interpolated from the vertex UVs. When
+// the mesh has no UVs they are the barycentric coordinates of the hit point
+// in respect to the second and the third vertex of the face.
+func (m *Mesh) ClosestFace(ray Ray, tMin, tMax float64) (Hit, int, bool) {
+	tMin, tMax = ray.Clip(tMin, tMax)
+	if tMin > tMax {
+		return Hit{}, 0, false
+	}
+
+	face := -1
+	var u, v float64
+	m.tree.traverse(ray, tMin, &tMax, func(i int) bool {
+		f := m.tree.order[i]
+		a, b, c := m.facePositions(f)
+		if t, fu, fv, ok := IntersectTriangle(m.algorithm, m.tolerance, ray, a, b, c, tMin, tMax); ok {
+			face, tMax, u, v = f, t, fu, fv
+		}
+		return false
+	})
+
+	if face < 0 {
+		return Hit{}, 0, false
+	}
+
+	return m.faceHit(ray, face, tMax, u, v), face, true
+}
+
+// Contains implements the Container interface. It computes the generalized
+// winding number of the mesh around `p`, the sum of the solid angles under
+// which its faces are seen from `p` divided by 4π. The winding number is one
+// for points inside a closed mesh and zero for points outside it, regardless
+// of the orientation of the faces. Meshes with holes give values in between
+// and points for which it is at least one half are considered inside.
+//
+// Points within the tolerance of a face, relative to its longest edge, are
+// on the surface of the mesh and are always inside. The query visits every
+// face of the mesh.
+func (m *Mesh) Contains(p Vector) bool {
+	var total float64
+	for i := 0; i < m.NumFaces(); i++ {
+		a, b, c := m.facePositions(i)
+		size := math.Max(Len(Sub(b, a)), math.Max(Len(Sub(c, b)), Len(Sub(a, c))))
+		if ClosestPointOnTriangle(p, a, b, c).Distance <= m.tolerance.Epsilon(size) {
+			return true
+		}
+		total += solidAngle(p, a, b, c)
+	}
+	return math.Abs(total) >= 2*math.Pi
+}
+
+// faceHit returns the hit of `ray` with face `face` at ray parameter `t` and
+// barycentric coordinates `u` and `v`.
+func (m *Mesh) faceHit(ray Ray, face int, t, u, v float64) Hit {
+	i := m.Face(face)
+	w := 1 - u - v
+
+	a, b, c := m.facePositions(face)
+	hit := Hit{T: t, Point: ray.At(t), U: u, V: v}
+	hit.SetFaceNormal(ray, Cross(Sub(b, a), Sub(c, a)))
+
+	if m.normals != nil {
+		shading := Add(Add(
+			Mul(m.normals[i[0]], w),
+			Mul(m.normals[i[1]], u)),
+			Mul(m.normals[i[2]], v))
+		if !hit.FrontFace {
+			shading = Neg(shading)
+		}
+		if shading = Normalize(shading); shading != (Vector{}) {
+			hit.Normal = shading
+		}
+	}
+
+	if m.uvs != nil {
+		uv0, uv1, uv2 := m.uvs[i[0]], m.uvs[i[1]], m.uvs[i[2]]
+		hit.U = w*uv0.U + u*uv1.U + v*uv2.U
+		hit.V = w*uv0.V + u*uv1.V + v*uv2.V
+	}
+
+	return hit
+}
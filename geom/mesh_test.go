@@ -0,0 +1,221 @@
+package geom
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// cubeMesh returns a mesh of the cube [-1, 1]³ with outward facing triangles.
+func cubeMesh(t *testing.T) *Mesh {
+	positions := []Vector{
+		NewVector(-1, -1, -1), NewVector(1, -1, -1), NewVector(1, 1, -1), NewVector(-1, 1, -1),
+		NewVector(-1, -1, 1), NewVector(1, -1, 1), NewVector(1, 1, 1), NewVector(-1, 1, 1),
+	}
+	indices := []int{
+		0, 2, 1, 0, 3, 2, // back, z = -1
+		4, 5, 6, 4, 6, 7, // front, z = 1
+		0, 1, 5, 0, 5, 4, // bottom, y = -1
+		3, 7, 6, 3, 6, 2, // top, y = 1
+		0, 4, 7, 0, 7, 3, // left, x = -1
+		1, 2, 6, 1, 6, 5, // right, x = 1
+	}
+
+	m, err := NewMesh(positions, nil, nil, indices)
+	if err != nil {
+		t.Fatalf("Unexpected error creating the cube mesh: %s", err)
+	}
+	return m
+}
+
+func TestMeshFaces(t *testing.T) {
+	cube := cubeMesh(t)
+
+	tests := []struct {
+		description string
+		ray         Ray
+		intersected bool
+		t           float64
+		faces       []int
+		front       bool
+	}{
+		{"front face", NewRay(NewVector(0.5, 0.2, 5), NewVector(0, 0, -1)), true, 4, []int{2, 3}, true},
+		{"back face from inside", NewRay(NewVector(0.5, 0.2, 0), NewVector(0, 0, -1)), true, 1, []int{0, 1}, false},
+		{"right face", NewRay(NewVector(5, 0.5, 0.2), NewVector(-2, 0, 0)), true, 2, []int{10, 11}, true},
+		{"shared edge", NewRay(NewVector(0, 0, 5), NewVector(0, 0, -1)), true, 4, []int{2, 3}, true},
+		{"miss", NewRay(NewVector(1.5, 0, 5), NewVector(0, 0, -1)), false, 0, nil, false},
+		{"opposite direction", NewRay(NewVector(0, 0, 5), NewVector(0, 0, 1)), false, 0, nil, false},
+	}
+
+	for _, test := range tests {
+		hit, face, ok := cube.ClosestFace(test.ray, 0, math.Inf(1))
+		if ok != test.intersected {
+			t.Errorf("%s: expected intersection to be %t but it was %t", test.description, test.intersected, ok)
+			continue
+		}
+		if cube.Intersect(test.ray) != ok {
+			t.Errorf("%s: Intersect does not agree with ClosestFace", test.description)
+		}
+		if !ok {
+			continue
+		}
+		if math.Abs(hit.T-test.t) > 1e-9 {
+			t.Errorf("%s: expected hit at %g but it was at %g", test.description, test.t, hit.T)
+		}
+		if face != test.faces[0] && face != test.faces[1] {
+			t.Errorf("%s: expected one of faces %v to be hit but it was %d", test.description, test.faces, face)
+		}
+		if hit.FrontFace != test.front {
+			t.Errorf("%s: expected front face to be %t but it was %t", test.description, test.front, hit.FrontFace)
+		}
+	}
+
+	if b := cube.Bounds(); b.Min != NewVector(-1, -1, -1) || b.Max != NewVector(1, 1, 1) {
+		t.Errorf("Unexpected cube bounds %v", b)
+	}
+}
+
+func TestMeshInterpolation(t *testing.T) {
+	positions := []Vector{
+		NewVector(0, 0, 0), NewVector(1, 0, 0), NewVector(1, 1, 0), NewVector(0, 1, 0),
+	}
+	normals := []Vector{
+		NewVector(-1, 0, 1), NewVector(1, 0, 1), NewVector(1, 0, 1), NewVector(-1, 0, 1),
+	}
+	uvs := []UV{{0, 0}, {1, 0}, {1, 1}, {0, 1}}
+	quad, err := NewMesh(positions, normals, uvs, []int{0, 1, 2, 0, 2, 3})
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	hit, ok := quad.IntersectHit(NewRay(NewVector(0.5, 0.25, 1), NewVector(0, 0, -1)))
+	if !ok {
+		t.Fatalf("Expected the ray to hit the quad")
+	}
+	if !vectorsClose(hit.Normal, NewVector(0, 0, 1)) {
+		t.Errorf("Expected interpolated normal (0, 0, 1) but got %v", hit.Normal)
+	}
+	if math.Abs(hit.U-0.5) > 1e-9 || math.Abs(hit.V-0.25) > 1e-9 {
+		t.Errorf("Expected interpolated UV (0.5, 0.25) but got (%g, %g)", hit.U, hit.V)
+	}
+
+	hit, ok = quad.IntersectHit(NewRay(NewVector(0.75, 0.5, -1), NewVector(0, 0, 1)))
+	if !ok {
+		t.Fatalf("Expected the ray to hit the back of the quad")
+	}
+	if hit.FrontFace || !vectorsClose(hit.Normal, Normalize(NewVector(-0.5, 0, -1))) {
+		t.Errorf("Expected back facing interpolated normal but got %v", hit.Normal)
+	}
+}
+
+func TestMeshValidation(t *testing.T) {
+	positions := []Vector{NewVector(0, 0, 0), NewVector(1, 0, 0), NewVector(0, 1, 0)}
+
+	tests := []struct {
+		description string
+		normals     []Vector
+		uvs         []UV
+		indices     []int
+	}{
+		{"incomplete face", nil, nil, []int{0, 1}},
+		{"index out of range", nil, nil, []int{0, 1, 3}},
+		{"negative index", nil, nil, []int{0, -1, 2}},
+		{"missing normals", positions[:2], nil, []int{0, 1, 2}},
+		{"missing UVs", nil, []UV{{0, 0}}, []int{0, 1, 2}},
+	}
+
+	for _, test := range tests {
+		if _, err := NewMesh(positions, test.normals, test.uvs, test.indices); err == nil {
+			t.Errorf("%s: expected an error", test.description)
+		}
+	}
+
+	empty, err := NewMesh(nil, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error for an empty mesh: %s", err)
+	}
+	if empty.Intersect(NewRay(NewVector(0, 0, 0), NewVector(1, 0, 0))) {
+		t.Errorf("Expected an empty mesh to intersect nothing")
+	}
+}
+
+func TestMeshWatertight(t *testing.T) {
+	cube := cubeMesh(t).WithAlgorithm(Watertight)
+	rnd := rand.New(rand.NewSource(1))
+
+	for i := 0; i < 10000; i++ {
+		// Aim from inside the cube at a random point on a random edge,
+		// including the diagonals shared by the two triangles of a side.
+		face := cube.Face(rnd.Intn(cube.NumFaces()))
+		k := rnd.Intn(3)
+		p, q := cube.Positions()[face[k]], cube.Positions()[face[(k+1)%3]]
+		s := rnd.Float64()
+		target := Add(Mul(p, 1-s), Mul(q, s))
+
+		origin := NewVector(rnd.Float64()-0.5, rnd.Float64()-0.5, rnd.Float64()-0.5)
+		ray := NewRay(origin, Sub(target, origin))
+
+		hit, ok := cube.IntersectHit(ray)
+		if !ok {
+			t.Fatalf("Ray %+v aimed at an edge leaked through the cube", ray)
+		}
+		if math.Abs(hit.T-1) > 1e-9 {
+			t.Fatalf("Expected ray %+v to hit the cube at t=1 but it was %g", ray, hit.T)
+		}
+	}
+}
+
+func TestMeshContains(t *testing.T) {
+	cube := cubeMesh(t)
+
+	// The same cube with all faces turned inside out.
+	indices := make([]int, len(cube.Indices()))
+	for i := 0; i < len(indices); i += 3 {
+		f := cube.Face(i / 3)
+		indices[i], indices[i+1], indices[i+2] = f[0], f[2], f[1]
+	}
+	inverted, err := NewMesh(cube.Positions(), nil, nil, indices)
+	if err != nil {
+		t.Fatalf("Unexpected error creating the inverted cube: %s", err)
+	}
+
+	// The cube without its front face.
+	open, err := NewMesh(cube.Positions(), nil, nil, append(append([]int{}, cube.Indices()[:6]...), cube.Indices()[12:]...))
+	if err != nil {
+		t.Fatalf("Unexpected error creating the open cube: %s", err)
+	}
+
+	tests := []struct {
+		description string
+		p           Vector
+		inside      bool
+	}{
+		{"center", NewVector(0, 0, 0), true},
+		{"near a corner", NewVector(0.99, -0.99, 0.99), true},
+		{"outside", NewVector(2, 0, 0), false},
+		{"far away", NewVector(100, -50, 30), false},
+		{"on a face", NewVector(1, 0.3, -0.2), true},
+		{"on an edge", NewVector(1, 1, 0.5), true},
+		{"on a diagonal", NewVector(0.5, 0.5, 1), true},
+		{"on a vertex", NewVector(-1, -1, -1), true},
+		{"just outside a face", NewVector(1+1e-6, 0.3, -0.2), false},
+		{"in the plane of a face", NewVector(3, 0, 1), false},
+	}
+
+	for _, test := range tests {
+		if inside := cube.Contains(test.p); inside != test.inside {
+			t.Errorf("%s: expected containment to be %t but it was %t", test.description, test.inside, inside)
+		}
+		if inside := inverted.Contains(test.p); inside != test.inside {
+			t.Errorf("%s: expected containment in the inverted cube to be %t but it was %t",
+				test.description, test.inside, inside)
+		}
+	}
+
+	if !open.Contains(NewVector(0, 0, -0.5)) {
+		t.Errorf("Expected the open cube to contain points far from its hole")
+	}
+	if open.Contains(NewVector(0, 0, 1.5)) {
+		t.Errorf("Expected the open cube not to contain points outside its hole")
+	}
+}
@@ -0,0 +1,373 @@
+/*
+Package obj reads and writes geometry in the Wavefront OBJ format.
+
+Only the geometry is supported: vertex positions, texture coordinates,
+normals, polygonal faces and groups. Faces with more than three vertices are
+split in triangles as fans around their first vertex, so they are expected to
+be convex. Materials, smoothing groups, curves and all other statements are
+ignored.
+*/
+package obj
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/fmi/go-homework/geom"
+)
+
+// Model is the geometry read from or written to an OBJ file.
+type Model struct {
+	// Mesh contains all faces of the model.
+	Mesh *geom.Mesh
+
+	// Groups are the named groups of faces in the model, in the order in
+	// which they appear in the file.
+	Groups []Group
+}
+
+// Group is a named range of consecutive faces of a mesh. Both `g` and `o`
+// statements start a new group, except for the ones without a name or with
+// the name "default", after which the faces belong to no group.
+type Group struct {
+	Name string
+
+	// Start is the index of the first face in the group and End is the
+	// index after the last one.
+	Start, End int
+}
+
+// ParseError is returned by Read for malformed input.
+type ParseError struct {
+	// Line is the number of the line which can not be parsed, starting
+	// from 1.
+	Line int
+	Err  error
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("obj: line %d: %s", e.Line, e.Err)
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// vertexKey identifies a unique combination of position, texture coordinates
+// and normal used by the faces. Each of them becomes one vertex of the mesh.
+// Missing texture coordinates and normals are -1.
+type vertexKey struct {
+	v, vt, vn int
+}
+
+// reader holds the state of Read.
+type reader struct {
+	positions []geom.Vector
+	texcoords []geom.UV
+	normals   []geom.Vector
+
+	vertices map[vertexKey]int
+	keys     []vertexKey
+	indices  []int
+	groups   []Group
+
+	// grouped is true when new faces belong to the last group.
+	grouped bool
+}
+
+// Read reads an OBJ file from `r` and returns its geometry as a single mesh.
+// Malformed input is reported with a *ParseError.
+func Read(r io.Reader) (*Model, error) {
+	rd := &reader{vertices: make(map[vertexKey]int)}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+
+	line, statement := 0, ""
+	for scanner.Scan() {
+		line++
+		text := scanner.Text()
+
+		// A backslash at the end of a line joins it with the next one.
+		if strings.HasSuffix(text, "\\") {
+			statement += text[:len(text)-1] + " "
+			continue
+		}
+		statement += text
+
+		if err := rd.statement(statement); err != nil {
+			return nil, &ParseError{Line: line, Err: err}
+		}
+		statement = ""
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return rd.model()
+}
+
+// statement parses a single statement of the file.
+func (rd *reader) statement(text string) error {
+	if i := strings.IndexByte(text, '#'); i >= 0 {
+		text = text[:i]
+	}
+	fields := strings.Fields(text)
+	if len(fields) == 0 {
+		return nil
+	}
+
+	switch args := fields[1:]; fields[0] {
+	case "v":
+		v, err := parseFloats(args, 3, 4)
+		if err != nil {
+			return err
+		}
+		rd.positions = append(rd.positions, geom.NewVector(v[0], v[1], v[2]))
+	case "vt":
+		v, err := parseFloats(args, 1, 3)
+		if err != nil {
+			return err
+		}
+		uv := geom.UV{U: v[0]}
+		if len(v) > 1 {
+			uv.V = v[1]
+		}
+		rd.texcoords = append(rd.texcoords, uv)
+	case "vn":
+		v, err := parseFloats(args, 3, 3)
+		if err != nil {
+			return err
+		}
+		rd.normals = append(rd.normals, geom.NewVector(v[0], v[1], v[2]))
+	case "f":
+		return rd.face(args)
+	case "g", "o":
+		rd.group(strings.Join(args, " "))
+	}
+
+	return nil
+}
+
+// face parses the vertices of a face and adds its triangles.
+func (rd *reader) face(args []string) error {
+	if len(args) < 3 {
+		return fmt.Errorf("face has %d vertices, at least 3 are needed", len(args))
+	}
+
+	vertices := make([]int, len(args))
+	for i, arg := range args {
+		key, err := rd.vertexKey(arg)
+		if err != nil {
+			return err
+		}
+
+		index, ok := rd.vertices[key]
+		if !ok {
+			index = len(rd.keys)
+			rd.vertices[key] = index
+			rd.keys = append(rd.keys, key)
+		}
+		vertices[i] = index
+	}
+
+	for i := 1; i+1 < len(vertices); i++ {
+		rd.indices = append(rd.indices, vertices[0], vertices[i], vertices[i+1])
+	}
+	if rd.grouped {
+		rd.groups[len(rd.groups)-1].End = len(rd.indices) / 3
+	}
+
+	return nil
+}
+
+// vertexKey parses a face vertex of the form v, v/vt, v//vn or v/vt/vn.
+func (rd *reader) vertexKey(arg string) (vertexKey, error) {
+	parts := strings.Split(arg, "/")
+	if len(parts) > 3 {
+		return vertexKey{}, fmt.Errorf("malformed face vertex %q", arg)
+	}
+
+	key := vertexKey{v: -1, vt: -1, vn: -1}
+	targets := []*int{&key.v, &key.vt, &key.vn}
+	counts := []int{len(rd.positions), len(rd.texcoords), len(rd.normals)}
+	names := []string{"vertex", "texture coordinate", "normal"}
+
+	for i, part := range parts {
+		if part == "" {
+			if i == 0 {
+				return vertexKey{}, fmt.Errorf("malformed face vertex %q", arg)
+			}
+			continue
+		}
+
+		n, err := strconv.Atoi(part)
+		if err != nil {
+			return vertexKey{}, fmt.Errorf("malformed face vertex %q", arg)
+		}
+
+		// Positive indices start from 1 and negative ones count back
+		// from the last element defined so far.
+		index := n - 1
+		if n < 0 {
+			index = counts[i] + n
+		}
+		if n == 0 || index < 0 || index >= counts[i] {
+			return vertexKey{}, fmt.Errorf("%s index %d is out of range, %d defined", names[i], n, counts[i])
+		}
+		*targets[i] = index
+	}
+
+	return key, nil
+}
+
+// group starts a new group of faces with name `name`. The faces after an
+// empty or default name belong to no group.
+func (rd *reader) group(name string) {
+	faces := len(rd.indices) / 3
+	if n := len(rd.groups); n > 0 && rd.groups[n-1].Start == rd.groups[n-1].End {
+		// The previous group has no faces.
+		rd.groups = rd.groups[:n-1]
+	}
+	rd.grouped = name != "" && name != defaultGroup
+	if rd.grouped {
+		rd.groups = append(rd.groups, Group{Name: name, Start: faces, End: faces})
+	}
+}
+
+// model returns the model built from all parsed statements.
+func (rd *reader) model() (*Model, error) {
+	if n := len(rd.groups); n > 0 && rd.groups[n-1].Start == rd.groups[n-1].End {
+		rd.groups = rd.groups[:n-1]
+	}
+
+	positions := make([]geom.Vector, len(rd.keys))
+	var normals []geom.Vector
+	var uvs []geom.UV
+
+	// Normals and texture coordinates are only kept when all vertices
+	// have them.
+	hasNormals, hasUVs := len(rd.keys) > 0, len(rd.keys) > 0
+	for _, key := range rd.keys {
+		hasNormals = hasNormals && key.vn >= 0
+		hasUVs = hasUVs && key.vt >= 0
+	}
+	if hasNormals {
+		normals = make([]geom.Vector, len(rd.keys))
+	}
+	if hasUVs {
+		uvs = make([]geom.UV, len(rd.keys))
+	}
+
+	for i, key := range rd.keys {
+		positions[i] = rd.positions[key.v]
+		if hasNormals {
+			normals[i] = rd.normals[key.vn]
+		}
+		if hasUVs {
+			uvs[i] = rd.texcoords[key.vt]
+		}
+	}
+
+	mesh, err := geom.NewMesh(positions, normals, uvs, rd.indices)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Model{Mesh: mesh, Groups: rd.groups}, nil
+}
+
+// parseFloats parses between `min` and `max` float arguments.
+func parseFloats(args []string, min, max int) ([]float64, error) {
+	if len(args) < min || len(args) > max {
+		if min == max {
+			return nil, fmt.Errorf("expected %d numbers but got %d", min, len(args))
+		}
+		return nil, fmt.Errorf("expected %d to %d numbers but got %d", min, max, len(args))
+	}
+
+	result := make([]float64, len(args))
+	for i, arg := range args {
+		f, err := strconv.ParseFloat(arg, 64)
+		if err != nil {
+			return nil, fmt.Errorf("malformed number %q", arg)
+		}
+		result[i] = f
+	}
+	return result, nil
+}
+
+// defaultGroup is the name of the group of the faces which belong to no
+// other group.
+const defaultGroup = "default"
+
+// Write writes `model` to `w` in the OBJ format. Every vertex of the mesh is
+// written with its position and, when the mesh has them, its texture
+// coordinates and normal. The faces after the end of a group which are not in
+// the next one are written in the default group.
+func Write(w io.Writer, model *Model) error {
+	if model == nil || model.Mesh == nil {
+		return errors.New("obj: no mesh to write")
+	}
+
+	bw := bufio.NewWriter(w)
+	mesh := model.Mesh
+
+	for _, p := range mesh.Positions() {
+		fmt.Fprintf(bw, "v %s %s %s\n", formatFloat(p.X), formatFloat(p.Y), formatFloat(p.Z))
+	}
+	for _, uv := range mesh.UVs() {
+		fmt.Fprintf(bw, "vt %s %s\n", formatFloat(uv.U), formatFloat(uv.V))
+	}
+	for _, n := range mesh.Normals() {
+		fmt.Fprintf(bw, "vn %s %s %s\n", formatFloat(n.X), formatFloat(n.Y), formatFloat(n.Z))
+	}
+
+	groups := model.Groups
+	grouped, end := false, 0
+	for face := 0; face < mesh.NumFaces(); face++ {
+		if grouped && face >= end && (len(groups) == 0 || groups[0].Start != face) {
+			fmt.Fprintf(bw, "g %s\n", defaultGroup)
+			grouped = false
+		}
+		for len(groups) > 0 && groups[0].Start == face {
+			fmt.Fprintf(bw, "g %s\n", groups[0].Name)
+			grouped, end = true, groups[0].End
+			groups = groups[1:]
+		}
+
+		bw.WriteString("f")
+		for _, i := range mesh.Face(face) {
+			bw.WriteString(" " + faceVertex(i+1, mesh.UVs() != nil, mesh.Normals() != nil))
+		}
+		bw.WriteString("\n")
+	}
+
+	return bw.Flush()
+}
+
+// faceVertex formats the face vertex with the same 1-based index for its
+// position, texture coordinates and normal.
+func faceVertex(i int, uv, normal bool) string {
+	s := strconv.Itoa(i)
+	switch {
+	case uv && normal:
+		return s + "/" + s + "/" + s
+	case uv:
+		return s + "/" + s
+	case normal:
+		return s + "//" + s
+	default:
+		return s
+	}
+}
+
+// formatFloat formats `f` with as many digits as needed for reading it back
+// exactly.
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
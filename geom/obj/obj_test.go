@@ -0,0 +1,220 @@
+package obj
+
+import (
+	"bytes"
+	"errors"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/fmi/go-homework/geom"
+)
+
+const cube = `# A unit cube with a square hole
+mtllib cube.mtl
+o cube
+v 0 0 0
+v 1 0 0
+v 1 1 0
+v 0 1 0
+v 0 0 1
+v 1 0 1
+v 1 1 1
+v 0 1 1
+vn 0 0 -1
+vn 0 0 1
+g bottom top
+usemtl red
+f 1//1 4//1 3//1 2//1
+f -4//2 -3//2 -2//2 -1//2
+g sides
+s 1
+f 1 2 6 5
+f 2 3 7 6
+f 3 4 \
+  8 7
+f 4 1 5 8
+g empty
+`
+
+func TestRead(t *testing.T) {
+	model, err := Read(strings.NewReader(cube))
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	mesh := model.Mesh
+	if n := mesh.NumFaces(); n != 12 {
+		t.Errorf("Expected 12 triangles but got %d", n)
+	}
+
+	// The bottom and top vertices have normals and the side ones do not,
+	// so the same positions are used in different vertices.
+	if n := len(mesh.Positions()); n != 16 {
+		t.Errorf("Expected 16 unique vertices but got %d", n)
+	}
+	if mesh.Normals() != nil {
+		t.Errorf("Expected normals to be dropped since not all vertices have them")
+	}
+
+	expected := []Group{{Name: "bottom top", Start: 0, End: 4}, {Name: "sides", Start: 4, End: 12}}
+	if len(model.Groups) != len(expected) {
+		t.Fatalf("Expected groups %v but got %v", expected, model.Groups)
+	}
+	for i := range expected {
+		if model.Groups[i] != expected[i] {
+			t.Errorf("Expected groups %v but got %v", expected, model.Groups)
+		}
+	}
+
+	ray := geom.NewRay(geom.NewVector(0.5, 0.5, 5), geom.NewVector(0, 0, -1))
+	hit, face, ok := mesh.ClosestFace(ray, 0, math.Inf(1))
+	if !ok {
+		t.Fatalf("Expected the ray to hit the cube")
+	}
+	if hit.T != 4 || (face != 2 && face != 3) {
+		t.Errorf("Expected the ray to hit the top at t=4 but it hit face %d at %g", face, hit.T)
+	}
+}
+
+func TestReadSmooth(t *testing.T) {
+	const quad = `
+v -1 -1 0
+v 1 -1 0
+v 1 1 0
+v -1 1 0
+vt 0 0
+vt 1 0
+vt 1 1
+vt 0 1
+vn -1 0 1
+vn 1 0 1
+f 1/1/1 2/2/2 3/3/2 4/4/1
+`
+	model, err := Read(strings.NewReader(quad))
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if model.Mesh.Normals() == nil || model.Mesh.UVs() == nil {
+		t.Fatalf("Expected the mesh to have normals and UVs")
+	}
+
+	hit, ok := model.Mesh.IntersectHit(geom.NewRay(geom.NewVector(0, 0.5, 1), geom.NewVector(0, 0, -1)))
+	if !ok {
+		t.Fatalf("Expected the ray to hit the quad")
+	}
+	if geom.Len(geom.Sub(hit.Normal, geom.NewVector(0, 0, 1))) > 1e-9 {
+		t.Errorf("Expected interpolated normal (0, 0, 1) but got %v", hit.Normal)
+	}
+	if math.Abs(hit.U-0.5) > 1e-9 || math.Abs(hit.V-0.75) > 1e-9 {
+		t.Errorf("Expected UV (0.5, 0.75) but got (%g, %g)", hit.U, hit.V)
+	}
+}
+
+func TestReadErrors(t *testing.T) {
+	tests := []struct {
+		description string
+		input       string
+		line        int
+	}{
+		{"malformed number", "v 0 0 0\nv 1 x 0\n", 2},
+		{"too few coordinates", "v 0 0\n", 1},
+		{"too few face vertices", "v 0 0 0\nv 1 0 0\nf 1 2\n", 3},
+		{"zero index", "v 0 0 0\nv 1 0 0\nv 0 1 0\nf 0 1 2\n", 4},
+		{"index out of range", "v 0 0 0\nv 1 0 0\nv 0 1 0\n\nf 1 2 4\n", 5},
+		{"negative index out of range", "v 0 0 0\nv 1 0 0\nv 0 1 0\nf -1 -2 -4\n", 4},
+		{"missing normal", "v 0 0 0\nv 1 0 0\nv 0 1 0\nf 1//1 2//1 3//1\n", 4},
+		{"malformed face vertex", "v 0 0 0\nv 1 0 0\nv 0 1 0\nf 1/2/3/4 2 3\n", 4},
+		{"continued line", "v 0 0 \\\n0 1 2\n", 2},
+	}
+
+	for _, test := range tests {
+		_, err := Read(strings.NewReader(test.input))
+		var perr *ParseError
+		if !errors.As(err, &perr) {
+			t.Errorf("%s: expected a parse error but got %v", test.description, err)
+			continue
+		}
+		if perr.Line != test.line {
+			t.Errorf("%s: expected an error on line %d but got %q", test.description, test.line, err)
+		}
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	positions := []geom.Vector{
+		geom.NewVector(0, 0, 0), geom.NewVector(1, 0, 0),
+		geom.NewVector(0, 1, 0), geom.NewVector(0.1, 0.2, 1.0/3),
+	}
+	normals := []geom.Vector{
+		geom.NewVector(0, 0, 1), geom.NewVector(0, 0, 1),
+		geom.NewVector(0, 0, 1), geom.NewVector(1, 0, 0),
+	}
+	uvs := []geom.UV{{U: 0, V: 0}, {U: 1, V: 0}, {U: 0, V: 1}, {U: 0.5, V: 0.5}}
+	mesh, err := geom.NewMesh(positions, normals, uvs, []int{0, 1, 2, 1, 3, 2})
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	groups := []Group{{Name: "first", Start: 0, End: 1}, {Name: "second", Start: 1, End: 2}}
+
+	var buf bytes.Buffer
+	if err := Write(&buf, &Model{Mesh: mesh, Groups: groups}); err != nil {
+		t.Fatalf("Unexpected error writing: %s", err)
+	}
+
+	model, err := Read(&buf)
+	if err != nil {
+		t.Fatalf("Unexpected error reading: %s", err)
+	}
+
+	read := model.Mesh
+	for i := range positions {
+		if read.Positions()[i] != positions[i] || read.Normals()[i] != normals[i] || read.UVs()[i] != uvs[i] {
+			t.Errorf("Vertex %d did not survive the round trip", i)
+		}
+	}
+	for i, index := range mesh.Indices() {
+		if read.Indices()[i] != index {
+			t.Errorf("Expected indices %v but got %v", mesh.Indices(), read.Indices())
+			break
+		}
+	}
+	for i := range groups {
+		if model.Groups[i] != groups[i] {
+			t.Errorf("Expected groups %v but got %v", groups, model.Groups)
+		}
+	}
+}
+
+func TestRoundTripUngroupedFaces(t *testing.T) {
+	positions := []geom.Vector{
+		geom.NewVector(0, 0, 0), geom.NewVector(1, 0, 0),
+		geom.NewVector(0, 1, 0), geom.NewVector(1, 1, 0),
+	}
+	indices := []int{0, 1, 2, 1, 3, 2, 0, 3, 2, 0, 1, 3, 2, 3, 0}
+	mesh, err := geom.NewMesh(positions, nil, nil, indices)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	// The first and the third faces and the last one are in no group.
+	groups := []Group{{Name: "first", Start: 1, End: 2}, {Name: "second", Start: 3, End: 4}}
+
+	var buf bytes.Buffer
+	if err := Write(&buf, &Model{Mesh: mesh, Groups: groups}); err != nil {
+		t.Fatalf("Unexpected error writing: %s", err)
+	}
+	model, err := Read(&buf)
+	if err != nil {
+		t.Fatalf("Unexpected error reading: %s", err)
+	}
+
+	if len(model.Groups) != len(groups) {
+		t.Fatalf("Expected groups %v but got %v", groups, model.Groups)
+	}
+	for i := range groups {
+		if model.Groups[i] != groups[i] {
+			t.Errorf("Expected groups %v but got %v", groups, model.Groups)
+		}
+	}
+}
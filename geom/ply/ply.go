@@ -0,0 +1,588 @@
+/*
+Package ply reads and writes triangle meshes in the PLY (Polygon File) format.
+
+The ASCII, binary little endian and binary big endian variants are supported.
+Vertex positions, normals (nx, ny, nz) and texture coordinates (u and v, s and
+t or texture_u and texture_v) are read from the "vertex" element and the
+polygons from the vertex_indices or vertex_index list of the "face" element.
+Polygons with more than three vertices are split in triangles as fans around
+their first vertex. All other elements and properties are skipped.
+
+The body of the file is parsed as a stream, so only the resulting mesh is
+kept in memory.
+*/
+package ply
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/fmi/go-homework/geom"
+)
+
+// Format is the encoding of the body of a PLY file.
+type Format int
+
+const (
+	// ASCII stores every element on a separate line of text.
+	ASCII Format = iota
+	// BinaryLittleEndian stores the properties in little endian binary.
+	BinaryLittleEndian
+	// BinaryBigEndian stores the properties in big endian binary.
+	BinaryBigEndian
+)
+
+var formatNames = map[string]Format{
+	"ascii":                ASCII,
+	"binary_little_endian": BinaryLittleEndian,
+	"binary_big_endian":    BinaryBigEndian,
+}
+
+func (f Format) String() string {
+	for name, format := range formatNames {
+		if format == f {
+			return name
+		}
+	}
+	return "Format(" + strconv.Itoa(int(f)) + ")"
+}
+
+// ParseError is returned by Read for malformed input.
+type ParseError struct {
+	// Line is the number of the line which can not be parsed, starting
+	// from 1. It is zero for errors in the binary body of a file.
+	Line int
+	Err  error
+}
+
+func (e *ParseError) Error() string {
+	if e.Line == 0 {
+		return "ply: " + e.Err.Error()
+	}
+	return fmt.Sprintf("ply: line %d: %s", e.Line, e.Err)
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// scalar is the type of a property value.
+type scalar int
+
+const (
+	int8Type scalar = iota
+	uint8Type
+	int16Type
+	uint16Type
+	int32Type
+	uint32Type
+	float32Type
+	float64Type
+)
+
+var scalarNames = map[string]scalar{
+	"char": int8Type, "int8": int8Type,
+	"uchar": uint8Type, "uint8": uint8Type,
+	"short": int16Type, "int16": int16Type,
+	"ushort": uint16Type, "uint16": uint16Type,
+	"int": int32Type, "int32": int32Type,
+	"uint": uint32Type, "uint32": uint32Type,
+	"float": float32Type, "float32": float32Type,
+	"double": float64Type, "float64": float64Type,
+}
+
+var scalarSizes = [...]int{1, 1, 2, 2, 4, 4, 4, 8}
+
+type property struct {
+	name string
+	typ  scalar
+
+	// list tells whether the property is a list whose length has type
+	// count.
+	list  bool
+	count scalar
+}
+
+type element struct {
+	name       string
+	count      int
+	properties []property
+}
+
+// reader holds the state of Read.
+type reader struct {
+	r      *bufio.Reader
+	format Format
+	order  binary.ByteOrder
+	line   int
+
+	elements []element
+
+	positions []geom.Vector
+	normals   []geom.Vector
+	uvs       []geom.UV
+	indices   []int
+}
+
+// Read reads a PLY file from `r` and returns its faces as a mesh. Malformed
+// input is reported with a *ParseError.
+func Read(r io.Reader) (*geom.Mesh, error) {
+	rd := &reader{r: bufio.NewReaderSize(r, 64*1024)}
+	if err := rd.header(); err != nil {
+		return nil, err
+	}
+
+	for _, e := range rd.elements {
+		if err := rd.element(e); err != nil {
+			return nil, err
+		}
+	}
+
+	return geom.NewMesh(rd.positions, rd.normals, rd.uvs, rd.indices)
+}
+
+// header parses the header of the file up to and including "end_header".
+func (rd *reader) header() error {
+	fields, err := rd.nextLine()
+	if err != nil || len(fields) != 1 || fields[0] != "ply" {
+		return rd.errorf("not a PLY file")
+	}
+
+	hasFormat := false
+	for {
+		fields, err := rd.nextLine()
+		if err == io.EOF {
+			return rd.errorf("missing end_header")
+		}
+		if err != nil {
+			return err
+		}
+
+		switch fields[0] {
+		case "format":
+			format, ok := formatNames[field(fields, 1)]
+			if len(fields) != 3 || !ok {
+				return rd.errorf("unsupported format %q", strings.Join(fields[1:], " "))
+			}
+			rd.format, hasFormat = format, true
+			rd.order = binary.ByteOrder(binary.LittleEndian)
+			if format == BinaryBigEndian {
+				rd.order = binary.BigEndian
+			}
+		case "element":
+			if len(fields) != 3 {
+				return rd.errorf("malformed element")
+			}
+			count, err := strconv.Atoi(fields[2])
+			if err != nil || count < 0 {
+				return rd.errorf("malformed element count %q", fields[2])
+			}
+			rd.elements = append(rd.elements, element{name: fields[1], count: count})
+		case "property":
+			p, err := rd.property(fields[1:])
+			if err != nil {
+				return err
+			}
+			if len(rd.elements) == 0 {
+				return rd.errorf("property %q outside of an element", p.name)
+			}
+			e := &rd.elements[len(rd.elements)-1]
+			e.properties = append(e.properties, p)
+		case "comment", "obj_info":
+		case "end_header":
+			if !hasFormat {
+				return rd.errorf("missing format")
+			}
+			return nil
+		default:
+			return rd.errorf("unknown header keyword %q", fields[0])
+		}
+	}
+}
+
+// property parses the arguments of a property declaration.
+func (rd *reader) property(args []string) (property, error) {
+	if len(args) == 4 && args[0] == "list" {
+		count, ok1 := scalarNames[args[1]]
+		typ, ok2 := scalarNames[args[2]]
+		if !ok1 || !ok2 || count == float32Type || count == float64Type {
+			return property{}, rd.errorf("unsupported list types %q and %q", args[1], args[2])
+		}
+		return property{name: args[3], typ: typ, list: true, count: count}, nil
+	}
+	if len(args) != 2 {
+		return property{}, rd.errorf("malformed property")
+	}
+
+	typ, ok := scalarNames[args[0]]
+	if !ok {
+		return property{}, rd.errorf("unsupported property type %q", args[0])
+	}
+	return property{name: args[1], typ: typ}, nil
+}
+
+// vertexLayout maps the properties of the vertex element to the attributes
+// of the mesh. Each field is the index of the property or -1.
+type vertexLayout struct {
+	position [3]int
+	normal   [3]int
+	uv       [2]int
+}
+
+func newVertexLayout(e element) (vertexLayout, error) {
+	l := vertexLayout{position: [3]int{-1, -1, -1}, normal: [3]int{-1, -1, -1}, uv: [2]int{-1, -1}}
+	slots := map[string]*int{
+		"x": &l.position[0], "y": &l.position[1], "z": &l.position[2],
+		"nx": &l.normal[0], "ny": &l.normal[1], "nz": &l.normal[2],
+		"u": &l.uv[0], "v": &l.uv[1],
+		"s": &l.uv[0], "t": &l.uv[1],
+		"texture_u": &l.uv[0], "texture_v": &l.uv[1],
+	}
+
+	for i, p := range e.properties {
+		if slot, ok := slots[p.name]; ok && !p.list {
+			*slot = i
+		}
+	}
+	for _, i := range l.position {
+		if i < 0 {
+			return l, errors.New("vertex element is missing x, y or z")
+		}
+	}
+	return l, nil
+}
+
+func (l vertexLayout) hasNormals() bool {
+	return l.normal[0] >= 0 && l.normal[1] >= 0 && l.normal[2] >= 0
+}
+
+func (l vertexLayout) hasUVs() bool {
+	return l.uv[0] >= 0 && l.uv[1] >= 0
+}
+
+// element reads all instances of `e` from the body of the file.
+func (rd *reader) element(e element) error {
+	var layout vertexLayout
+	faceList := -1
+
+	switch e.name {
+	case "vertex":
+		var err error
+		if layout, err = newVertexLayout(e); err != nil {
+			return &ParseError{Err: err}
+		}
+		rd.positions = make([]geom.Vector, 0, e.count)
+		if layout.hasNormals() {
+			rd.normals = make([]geom.Vector, 0, e.count)
+		}
+		if layout.hasUVs() {
+			rd.uvs = make([]geom.UV, 0, e.count)
+		}
+	case "face":
+		for i, p := range e.properties {
+			if p.list && (p.name == "vertex_indices" || p.name == "vertex_index") {
+				faceList = i
+			}
+		}
+		if faceList < 0 {
+			return &ParseError{Err: errors.New("face element has no vertex_indices list")}
+		}
+	}
+
+	values := make([]float64, len(e.properties))
+	var list []float64
+
+	for i := 0; i < e.count; i++ {
+		if rd.format == ASCII {
+			fields, err := rd.nextLine()
+			if err == io.EOF {
+				return rd.errorf("unexpected end of file in %s %d", e.name, i)
+			}
+			if err != nil {
+				return err
+			}
+			if list, err = rd.parseASCII(e, fields, values, faceList); err != nil {
+				return err
+			}
+		} else {
+			var err error
+			if list, err = rd.readBinary(e, values, faceList, list[:0]); err != nil {
+				return &ParseError{Err: fmt.Errorf("reading %s %d: %w", e.name, i, err)}
+			}
+		}
+
+		switch e.name {
+		case "vertex":
+			rd.addVertex(layout, values)
+		case "face":
+			if err := rd.addFace(list); err != nil {
+				if rd.format == ASCII {
+					return rd.errorf("%s", err)
+				}
+				return &ParseError{Err: fmt.Errorf("face %d: %w", i, err)}
+			}
+		}
+	}
+
+	return nil
+}
+
+// parseASCII parses the properties of one element instance from `fields`
+// into `values`. The items of the list property at index `keep` are
+// returned.
+func (rd *reader) parseASCII(e element, fields []string, values []float64, keep int) ([]float64, error) {
+	var list []float64
+	next := func() (float64, error) {
+		if len(fields) == 0 {
+			return 0, rd.errorf("too few values for %s", e.name)
+		}
+		f, err := strconv.ParseFloat(fields[0], 64)
+		if err != nil {
+			return 0, rd.errorf("malformed number %q", fields[0])
+		}
+		fields = fields[1:]
+		return f, nil
+	}
+
+	for i, p := range e.properties {
+		if !p.list {
+			f, err := next()
+			if err != nil {
+				return nil, err
+			}
+			values[i] = f
+			continue
+		}
+
+		n, err := next()
+		if err != nil {
+			return nil, err
+		}
+		if n < 0 || n != math.Trunc(n) {
+			return nil, rd.errorf("malformed list length %g", n)
+		}
+		for j := 0; j < int(n); j++ {
+			f, err := next()
+			if err != nil {
+				return nil, err
+			}
+			if i == keep {
+				list = append(list, f)
+			}
+		}
+	}
+
+	if len(fields) > 0 {
+		return nil, rd.errorf("too many values for %s", e.name)
+	}
+	return list, nil
+}
+
+// readBinary reads the properties of one element instance into `values`.
+// The items of the list property at index `keep` are appended to `list`.
+func (rd *reader) readBinary(e element, values []float64, keep int, list []float64) ([]float64, error) {
+	var buf [8]byte
+	read := func(typ scalar) (float64, error) {
+		b := buf[:scalarSizes[typ]]
+		if _, err := io.ReadFull(rd.r, b); err != nil {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return 0, err
+		}
+		return decode(rd.order, typ, b), nil
+	}
+
+	for i, p := range e.properties {
+		if !p.list {
+			f, err := read(p.typ)
+			if err != nil {
+				return nil, err
+			}
+			values[i] = f
+			continue
+		}
+
+		n, err := read(p.count)
+		if err != nil {
+			return nil, err
+		}
+		if n < 0 {
+			return nil, fmt.Errorf("negative list length %g", n)
+		}
+		for j := 0; j < int(n); j++ {
+			f, err := read(p.typ)
+			if err != nil {
+				return nil, err
+			}
+			if i == keep {
+				list = append(list, f)
+			}
+		}
+	}
+
+	return list, nil
+}
+
+func (rd *reader) addVertex(l vertexLayout, values []float64) {
+	rd.positions = append(rd.positions,
+		geom.NewVector(values[l.position[0]], values[l.position[1]], values[l.position[2]]))
+	if rd.normals != nil {
+		rd.normals = append(rd.normals,
+			geom.NewVector(values[l.normal[0]], values[l.normal[1]], values[l.normal[2]]))
+	}
+	if rd.uvs != nil {
+		rd.uvs = append(rd.uvs, geom.UV{U: values[l.uv[0]], V: values[l.uv[1]]})
+	}
+}
+
+// addFace triangulates the polygon with vertex indices `list`.
+func (rd *reader) addFace(list []float64) error {
+	if len(list) < 3 {
+		return fmt.Errorf("face has %d vertices, at least 3 are needed", len(list))
+	}
+	for _, f := range list {
+		if f < 0 || f != math.Trunc(f) {
+			return fmt.Errorf("malformed vertex index %g", f)
+		}
+	}
+
+	for i := 1; i+1 < len(list); i++ {
+		rd.indices = append(rd.indices, int(list[0]), int(list[i]), int(list[i+1]))
+	}
+	return nil
+}
+
+// nextLine reads the fields of the next non-empty line.
+func (rd *reader) nextLine() ([]string, error) {
+	for {
+		text, err := rd.r.ReadString('\n')
+		if len(text) > 0 {
+			rd.line++
+		}
+		if fields := strings.Fields(text); len(fields) > 0 {
+			return fields, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (rd *reader) errorf(format string, args ...interface{}) error {
+	return &ParseError{Line: rd.line, Err: fmt.Errorf(format, args...)}
+}
+
+// field returns fields[i] or an empty string when it does not exist.
+func field(fields []string, i int) string {
+	if i < len(fields) {
+		return fields[i]
+	}
+	return ""
+}
+
+func decode(order binary.ByteOrder, typ scalar, b []byte) float64 {
+	switch typ {
+	case int8Type:
+		return float64(int8(b[0]))
+	case uint8Type:
+		return float64(b[0])
+	case int16Type:
+		return float64(int16(order.Uint16(b)))
+	case uint16Type:
+		return float64(order.Uint16(b))
+	case int32Type:
+		return float64(int32(order.Uint32(b)))
+	case uint32Type:
+		return float64(order.Uint32(b))
+	case float32Type:
+		return float64(math.Float32frombits(order.Uint32(b)))
+	default:
+		return math.Float64frombits(order.Uint64(b))
+	}
+}
+
+// Write writes `mesh` to `w` as a PLY file in format `format`. Coordinates
+// are written as doubles, so reading the file back gives the same mesh.
+func Write(w io.Writer, mesh *geom.Mesh, format Format) error {
+	if mesh == nil {
+		return errors.New("ply: no mesh to write")
+	}
+
+	var order binary.ByteOrder
+	switch format {
+	case ASCII:
+	case BinaryLittleEndian:
+		order = binary.LittleEndian
+	case BinaryBigEndian:
+		order = binary.BigEndian
+	default:
+		return fmt.Errorf("ply: unknown format %v", format)
+	}
+
+	positions, normals, uvs := mesh.Positions(), mesh.Normals(), mesh.UVs()
+
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "ply\nformat %s 1.0\n", format)
+	fmt.Fprintf(bw, "element vertex %d\n", len(positions))
+	bw.WriteString("property double x\nproperty double y\nproperty double z\n")
+	if normals != nil {
+		bw.WriteString("property double nx\nproperty double ny\nproperty double nz\n")
+	}
+	if uvs != nil {
+		bw.WriteString("property double u\nproperty double v\n")
+	}
+	fmt.Fprintf(bw, "element face %d\n", mesh.NumFaces())
+	bw.WriteString("property list uchar int vertex_indices\nend_header\n")
+
+	values := make([]float64, 0, 8)
+	for i, p := range positions {
+		values = append(values[:0], p.X, p.Y, p.Z)
+		if normals != nil {
+			values = append(values, normals[i].X, normals[i].Y, normals[i].Z)
+		}
+		if uvs != nil {
+			values = append(values, uvs[i].U, uvs[i].V)
+		}
+
+		if order == nil {
+			for j, v := range values {
+				if j > 0 {
+					bw.WriteByte(' ')
+				}
+				bw.WriteString(strconv.FormatFloat(v, 'g', -1, 64))
+			}
+			bw.WriteByte('\n')
+			continue
+		}
+
+		var buf [8]byte
+		for _, v := range values {
+			order.PutUint64(buf[:], math.Float64bits(v))
+			bw.Write(buf[:])
+		}
+	}
+
+	for i := 0; i < mesh.NumFaces(); i++ {
+		face := mesh.Face(i)
+		if order == nil {
+			fmt.Fprintf(bw, "3 %d %d %d\n", face[0], face[1], face[2])
+			continue
+		}
+
+		var buf [13]byte
+		buf[0] = 3
+		for j, index := range face {
+			order.PutUint32(buf[1+4*j:], uint32(index))
+		}
+		bw.Write(buf[:])
+	}
+
+	return bw.Flush()
+}
@@ -0,0 +1,162 @@
+package ply
+
+import (
+	"bytes"
+	"errors"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/fmi/go-homework/geom"
+)
+
+const square = `ply
+format ascii 1.0
+comment a unit square with an extra element
+element vertex 4
+property float x
+property float y
+property float z
+property uchar red
+property float s
+property float t
+element face 1
+property uchar flags
+property list uchar int vertex_indices
+element edge 1
+property int vertex1
+property int vertex2
+end_header
+0 0 0 255 0 0
+1 0 0 255 1 0
+1 1 0 255 1 1
+
+0 1 0 255 0 1
+7 4 0 1 2 3
+0 2
+`
+
+func TestReadASCII(t *testing.T) {
+	mesh, err := Read(strings.NewReader(square))
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	if n := mesh.NumFaces(); n != 2 {
+		t.Errorf("Expected the square to be split in 2 triangles but got %d", n)
+	}
+	if mesh.UVs() == nil || mesh.Normals() != nil {
+		t.Fatalf("Expected UVs and no normals")
+	}
+
+	hit, ok := mesh.IntersectHit(geom.NewRay(geom.NewVector(0.25, 0.75, 1), geom.NewVector(0, 0, -1)))
+	if !ok {
+		t.Fatalf("Expected the ray to hit the square")
+	}
+	if math.Abs(hit.U-0.25) > 1e-9 || math.Abs(hit.V-0.75) > 1e-9 {
+		t.Errorf("Expected UV (0.25, 0.75) but got (%g, %g)", hit.U, hit.V)
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	positions := []geom.Vector{
+		geom.NewVector(0, 0, 0), geom.NewVector(1, 0, 0),
+		geom.NewVector(0, 1, 0), geom.NewVector(0.1, 0.2, 1.0/3),
+	}
+	normals := []geom.Vector{
+		geom.NewVector(0, 0, 1), geom.NewVector(0, 0, 1),
+		geom.NewVector(0, 0, 1), geom.NewVector(-1, 0, 0),
+	}
+	uvs := []geom.UV{{U: 0, V: 0}, {U: 1, V: 0}, {U: 0, V: 1}, {U: 0.5, V: 0.5}}
+	mesh, err := geom.NewMesh(positions, normals, uvs, []int{0, 1, 2, 1, 3, 2})
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	for _, format := range []Format{ASCII, BinaryLittleEndian, BinaryBigEndian} {
+		var buf bytes.Buffer
+		if err := Write(&buf, mesh, format); err != nil {
+			t.Errorf("%s: unexpected error writing: %s", format, err)
+			continue
+		}
+
+		read, err := Read(&buf)
+		if err != nil {
+			t.Errorf("%s: unexpected error reading: %s", format, err)
+			continue
+		}
+
+		for i := range positions {
+			if read.Positions()[i] != positions[i] || read.Normals()[i] != normals[i] || read.UVs()[i] != uvs[i] {
+				t.Errorf("%s: vertex %d did not survive the round trip", format, i)
+			}
+		}
+		for i, index := range mesh.Indices() {
+			if read.Indices()[i] != index {
+				t.Errorf("%s: expected indices %v but got %v", format, mesh.Indices(), read.Indices())
+				break
+			}
+		}
+	}
+}
+
+func TestReadBinaryTypes(t *testing.T) {
+	header := "ply\nformat binary_big_endian 1.0\n" +
+		"element vertex 3\nproperty short x\nproperty uchar y\nproperty double z\n" +
+		"element face 1\nproperty list ushort uint vertex_index\nend_header\n"
+	body := []byte{
+		0xff, 0xff, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+		0, 2, 0, 0x40, 0, 0, 0, 0, 0, 0, 0,
+		0, 0, 3, 0xbf, 0xf0, 0, 0, 0, 0, 0, 0,
+		0, 3, 0, 0, 0, 0, 0, 0, 0, 1, 0, 0, 0, 2,
+	}
+
+	mesh, err := Read(bytes.NewReader(append([]byte(header), body...)))
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	expected := []geom.Vector{geom.NewVector(-1, 0, 0), geom.NewVector(2, 0, 2), geom.NewVector(0, 3, -1)}
+	for i, p := range mesh.Positions() {
+		if p != expected[i] {
+			t.Errorf("Expected vertex %d to be %v but got %v", i, expected[i], p)
+		}
+	}
+	if mesh.NumFaces() != 1 {
+		t.Errorf("Expected 1 face but got %d", mesh.NumFaces())
+	}
+}
+
+func TestReadErrors(t *testing.T) {
+	const vertices = "ply\nformat ascii 1.0\nelement vertex 3\nproperty float x\nproperty float y\nproperty float z\n"
+	tests := []struct {
+		description string
+		input       string
+		line        int
+	}{
+		{"not a PLY file", "solid x\n", 1},
+		{"unknown format", "ply\nformat utf8 1.0\n", 2},
+		{"unknown type", "ply\nformat ascii 1.0\nelement vertex 1\nproperty quad x\n", 4},
+		{"missing end_header", vertices, 6},
+		{"malformed number", vertices + "end_header\n0 0 0\n1 O 0\n", 9},
+		{"too many values", vertices + "end_header\n0 0 0 0\n", 8},
+		{"truncated body", vertices + "end_header\n0 0 0\n1 0 0\n", 9},
+		{
+			"short face",
+			vertices + "element face 1\nproperty list uchar int vertex_indices\nend_header\n0 0 0\n1 0 0\n0 1 0\n2 0 1\n",
+			13,
+		},
+	}
+
+	for _, test := range tests {
+		_, err := Read(strings.NewReader(test.input))
+		var perr *ParseError
+		if !errors.As(err, &perr) {
+			t.Errorf("%s: expected a parse error but got %v", test.description, err)
+			continue
+		}
+		if perr.Line != test.line {
+			t.Errorf("%s: expected an error on line %d but got %q", test.description, test.line, err)
+		}
+	}
+}
@@ -0,0 +1,274 @@
+/*
+Package poly finds the real roots of polynomials of degree up to four.
+
+The roots are found in closed form and then refined with a few iterations of
+Newton's method on the original polynomial, which removes most of the error
+accumulated by the closed form solutions. All functions return the distinct
+real roots in increasing order. A root of higher multiplicity is returned
+once. When the leading coefficients are zero the polynomial is solved as one
+of lower degree.
+*/
+package poly
+
+import (
+	"math"
+	"sort"
+)
+
+// epsilon is the threshold below which intermediate values of the closed
+// form solutions are considered zero. The polynomials are scaled so that
+// their roots are about one before solving them, which makes a fixed
+// threshold suit polynomials of any size.
+const epsilon = 1e-12
+
+// minDeflation is how many times the biggest root of a cubic has to be bigger
+// than the other ones for them to be found by dividing it out.
+const minDeflation = 1e3
+
+// polishIterations is the maximum number of Newton iterations used for
+// refining each root.
+const polishIterations = 8
+
+// Linear returns the root of a*x + b = 0. It returns no roots when `a` is zero.
+func Linear(a, b float64) []float64 {
+	if a == 0 {
+		return nil
+	}
+	return []float64{-b / a}
+}
+
+// Quadratic returns the real roots of a*x² + b*x + c = 0.
+func Quadratic(a, b, c float64) []float64 {
+	if a == 0 {
+		return Linear(b, c)
+	}
+
+	discrim := b*b - 4*a*c
+	switch {
+	case discrim < 0:
+		return nil
+	case discrim == 0:
+		return []float64{-b / (2 * a)}
+	}
+
+	// Avoid the cancellation in -b ± sqrt(discrim) by computing the root
+	// with the bigger magnitude first.
+	q := -0.5 * (b + math.Copysign(math.Sqrt(discrim), b))
+	if q == 0 {
+		// b and c are both zero.
+		return []float64{0}
+	}
+	return normalize([]float64{q / a, c / q})
+}
+
+// Cubic returns the real roots of a*x³ + b*x² + c*x + d = 0.
+func Cubic(a, b, c, d float64) []float64 {
+	if a == 0 {
+		return Quadratic(b, c, d)
+	}
+
+	roots := depressedCubic(b/a, c/a, d/a)
+	coeffs := []float64{a, b, c, d}
+	for i := range roots {
+		roots[i] = polish(coeffs, roots[i])
+	}
+
+	// The closed form solution is only accurate relative to the biggest
+	// root, so much smaller roots, as when the leading coefficient is
+	// close to zero, lose their precision or even merge. Find them from the
+	// quadratic which is left after dividing the biggest root out instead.
+	// Dividing from the constant term keeps the quotient accurate.
+	big := roots[0]
+	for _, r := range roots[1:] {
+		if math.Abs(r) > math.Abs(big) {
+			big = r
+		}
+	}
+	if big != 0 && math.Abs(a*big*big*big) >= math.Abs(d) {
+		q0 := -d / big
+		q1 := (q0 - c) / big
+		q2 := (q1 - b) / big
+		rest := Quadratic(q2, q1, q0)
+		if len(rest) > 0 && math.Abs(big) > minDeflation*scale(q1/q2, q0/q2) {
+			for i := range rest {
+				rest[i] = polish(coeffs, rest[i])
+			}
+			roots = append(rest, big)
+		}
+	}
+	return normalize(roots)
+}
+
+// Quartic returns the real roots of a*x⁴ + b*x³ + c*x² + d*x + e = 0. It uses
+// Ferrari's method, reducing the equation to a cubic and two quadratics.
+func Quartic(a, b, c, d, e float64) []float64 {
+	if a == 0 {
+		return Cubic(b, c, d, e)
+	}
+
+	// Solve for x/s, whose roots are about one.
+	A, B, C, D := b/a, c/a, d/a, e/a
+	s := scale(A, B, C, D)
+	A, B, C, D = A/s, B/(s*s), C/(s*s*s), D/(s*s*s*s)
+
+	// Substitute x/s = y - A/4 to eliminate the cubic term:
+	// y⁴ + p*y² + q*y + r = 0.
+	sqA := A * A
+	p := -3.0/8*sqA + B
+	q := 1.0/8*sqA*A - 1.0/2*A*B + C
+	r := -3.0/256*sqA*sqA + 1.0/16*sqA*B - 1.0/4*A*C + D
+
+	var roots []float64
+	if math.Abs(r) < epsilon {
+		// No absolute term: y(y³ + p*y + q) = 0.
+		roots = append(depressedCubic(0, p, q), 0)
+	} else {
+		// Take the biggest real root of the resolvent cubic...
+		resolvent := depressedCubic(-0.5*p, -r, 0.5*r*p-0.125*q*q)
+		z := resolvent[0]
+		for _, root := range resolvent[1:] {
+			z = math.Max(z, root)
+		}
+
+		// ...and use it to split the quartic in two quadratics:
+		// (y² + v*y + z - u)(y² - v*y + z + u), where u² = z² - r,
+		// v² = 2z - p and 2uv = q. Both squares are never negative for
+		// the biggest root, so negative values are rounding errors. The
+		// bigger one is the more accurate and gives the other from q.
+		u, v := 0.0, 0.0
+		if u2, v2 := z*z-r, 2*z-p; v2 >= u2 {
+			v = math.Sqrt(math.Max(0, v2))
+			if v > 0 {
+				u = q / (2 * v)
+			}
+		} else {
+			u = math.Sqrt(math.Max(0, u2))
+			if u > 0 {
+				v = q / (2 * u)
+			}
+		}
+
+		roots = append(Quadratic(1, v, z-u), Quadratic(1, -v, z+u)...)
+	}
+
+	coeffs := []float64{a, b, c, d, e}
+	for i := range roots {
+		roots[i] = polish(coeffs, (roots[i]-A/4)*s)
+	}
+	return normalize(roots)
+}
+
+// depressedCubic returns the real roots of x³ + a*x² + b*x + c = 0 using
+// Cardano's formula or its trigonometric form when there are three roots.
+// The roots are not sorted.
+func depressedCubic(a, b, c float64) []float64 {
+	// Solve for x/s, whose roots are about one.
+	s := scale(a, b, c)
+	a, b, c = a/s, b/(s*s), c/(s*s*s)
+
+	// Substitute x/s = y - a/3 to eliminate the quadratic term:
+	// y³ + 3p*y + 2q = 0.
+	sqA := a * a
+	p := 1.0 / 3 * (-1.0/3*sqA + b)
+	q := 1.0 / 2 * (2.0/27*a*sqA - 1.0/3*a*b + c)
+
+	cbP := p * p * p
+	discrim := q*q + cbP
+
+	var roots []float64
+	switch {
+	case math.Abs(discrim) < epsilon:
+		if math.Abs(q) < epsilon {
+			// One triple root.
+			roots = []float64{0}
+		} else {
+			// One single and one double root.
+			u := math.Cbrt(-q)
+			roots = []float64{2 * u, -u}
+		}
+	case discrim < 0:
+		// Three real roots.
+		phi := 1.0 / 3 * math.Acos(math.Max(-1, math.Min(1, -q/math.Sqrt(-cbP))))
+		t := 2 * math.Sqrt(-p)
+		roots = []float64{
+			t * math.Cos(phi),
+			-t * math.Cos(phi+math.Pi/3),
+			-t * math.Cos(phi-math.Pi/3),
+		}
+	default:
+		// One real root.
+		sqrtD := math.Sqrt(discrim)
+		roots = []float64{math.Cbrt(sqrtD-q) - math.Cbrt(sqrtD+q)}
+	}
+
+	for i := range roots {
+		roots[i] = (roots[i] - a/3) * s
+	}
+	return roots
+}
+
+// scale returns a power of two close to the biggest magnitude of the roots of
+// the monic polynomial with the other coefficients `coeffs`, ordered from
+// the highest degree. Dividing the roots by a power of two adds no rounding
+// errors to the coefficients. scale returns one for x^n.
+func scale(coeffs ...float64) float64 {
+	s := 0.0
+	for i, c := range coeffs {
+		s = math.Max(s, math.Pow(math.Abs(c), 1/float64(i+1)))
+	}
+	if s == 0 || math.IsInf(s, 0) || math.IsNaN(s) {
+		return 1
+	}
+	_, exp := math.Frexp(s)
+	return math.Ldexp(1, exp)
+}
+
+// polish refines the root `x` of the polynomial with coefficients `coeffs`,
+// ordered from the highest degree, with Newton's method. It returns `x`
+// unchanged when the iterations do not improve it.
+func polish(coeffs []float64, x float64) float64 {
+	best, bestErr := x, math.Abs(eval(coeffs, x))
+	for i := 0; i < polishIterations && bestErr > 0; i++ {
+		f, df := evalDerivative(coeffs, x)
+		if df == 0 {
+			break
+		}
+		x -= f / df
+		if err := math.Abs(eval(coeffs, x)); err < bestErr {
+			best, bestErr = x, err
+		} else {
+			break
+		}
+	}
+	return best
+}
+
+// eval returns the value of the polynomial with coefficients `coeffs` at `x`.
+func eval(coeffs []float64, x float64) float64 {
+	f, _ := evalDerivative(coeffs, x)
+	return f
+}
+
+// evalDerivative returns the values of the polynomial with coefficients
+// `coeffs` and of its derivative at `x`, using Horner's scheme.
+func evalDerivative(coeffs []float64, x float64) (float64, float64) {
+	var f, df float64
+	for _, c := range coeffs {
+		df = df*x + f
+		f = f*x + c
+	}
+	return f, df
+}
+
+// normalize sorts `roots` and removes the duplicates.
+func normalize(roots []float64) []float64 {
+	sort.Float64s(roots)
+	result := roots[:0]
+	for i, r := range roots {
+		if i > 0 && r-result[len(result)-1] <= epsilon*math.Max(1, math.Abs(r)) {
+			continue
+		}
+		result = append(result, r)
+	}
+	return result
+}
@@ -0,0 +1,104 @@
+package poly
+
+import (
+	"math"
+	"testing"
+)
+
+func TestQuadratic(t *testing.T) {
+	tests := []struct {
+		description string
+		a, b, c     float64
+		roots       []float64
+	}{
+		{"two roots", 1, -3, 2, []float64{1, 2}},
+		{"double root", 1, -2, 1, []float64{1}},
+		{"no roots", 1, 0, 1, nil},
+		{"linear", 0, 2, -4, []float64{2}},
+		{"constant", 0, 0, 1, nil},
+		{"zero roots", 1, 0, 0, []float64{0}},
+		{"cancellation", 1, -1e8, 1, []float64{1e-8, 1e8}},
+	}
+
+	for _, test := range tests {
+		checkRoots(t, test.description, Quadratic(test.a, test.b, test.c), test.roots)
+	}
+}
+
+func TestCubic(t *testing.T) {
+	tests := []struct {
+		description string
+		coeffs      [4]float64
+		roots       []float64
+	}{
+		{"three roots", [4]float64{1, -6, 11, -6}, []float64{1, 2, 3}},
+		{"one root", [4]float64{1, 0, 0, -8}, []float64{2}},
+		{"double root", [4]float64{1, -4, 5, -2}, []float64{1, 2}},
+		{"triple root", [4]float64{1, -3, 3, -1}, []float64{1}},
+		{"scaled", [4]float64{-2, 12, -22, 12}, []float64{1, 2, 3}},
+		{"quadratic", [4]float64{0, 1, -3, 2}, []float64{1, 2}},
+		{"tiny leading coefficient", [4]float64{1e-20, 1, -3, 2}, []float64{-1e20, 1, 2}},
+		{"small leading coefficient", [4]float64{1e-8, 1, -3, 2}, []float64{-1.00000003e8, 1.00000001, 1.99999992}},
+		{"three roots times 10", [4]float64{1, -60, 1100, -6000}, []float64{10, 20, 30}},
+		{"three roots times 1000", [4]float64{1, -6e3, 11e6, -6e9}, []float64{1000, 2000, 3000}},
+	}
+
+	for _, test := range tests {
+		c := test.coeffs
+		checkRoots(t, test.description, Cubic(c[0], c[1], c[2], c[3]), test.roots)
+	}
+}
+
+func TestQuartic(t *testing.T) {
+	tests := []struct {
+		description string
+		coeffs      [5]float64
+		roots       []float64
+	}{
+		{"four roots", fromRoots(1, 2, 3, 4), []float64{1, 2, 3, 4}},
+		{"symmetric roots", fromRoots(-2, -1, 1, 2), []float64{-2, -1, 1, 2}},
+		{"no roots", [5]float64{1, 0, 0, 0, 1}, nil},
+		{"two roots", [5]float64{1, 0, 0, 0, -16}, []float64{-2, 2}},
+		{"two double roots", fromRoots(-1, -1, 1, 1), []float64{-1, 1}},
+		{"no absolute term", fromRoots(0, 1, 2, 3), []float64{0, 1, 2, 3}},
+		{"close roots", fromRoots(1, 1.001, 5, 7), []float64{1, 1.001, 5, 7}},
+		{"far roots", fromRoots(0.01, 1, 100, 1000), []float64{0.01, 1, 100, 1000}},
+		{"cubic", [5]float64{0, 1, -6, 11, -6}, []float64{1, 2, 3}},
+		{"four roots times 10", fromRoots(10, 20, 30, 40), []float64{10, 20, 30, 40}},
+		{"four roots times 100", fromRoots(100, 200, 300, 400), []float64{100, 200, 300, 400}},
+		{"four roots times 1000", fromRoots(1000, 2000, 3000, 4000), []float64{1000, 2000, 3000, 4000}},
+		{"symmetric roots times 100", fromRoots(-200, -100, 100, 200), []float64{-200, -100, 100, 200}},
+		{"two double roots times 1000", fromRoots(-1000, -1000, 1000, 1000), []float64{-1000, 1000}},
+		{"tiny roots", fromRoots(1e-4, 2e-4, 3e-4, 4e-4), []float64{1e-4, 2e-4, 3e-4, 4e-4}},
+	}
+
+	for _, test := range tests {
+		c := test.coeffs
+		checkRoots(t, test.description, Quartic(c[0], c[1], c[2], c[3], c[4]), test.roots)
+	}
+}
+
+// fromRoots returns the coefficients of the monic polynomial with roots `r`.
+func fromRoots(r ...float64) [5]float64 {
+	return [5]float64{
+		1,
+		-(r[0] + r[1] + r[2] + r[3]),
+		r[0]*r[1] + r[0]*r[2] + r[0]*r[3] + r[1]*r[2] + r[1]*r[3] + r[2]*r[3],
+		-(r[0]*r[1]*r[2] + r[0]*r[1]*r[3] + r[0]*r[2]*r[3] + r[1]*r[2]*r[3]),
+		r[0] * r[1] * r[2] * r[3],
+	}
+}
+
+func checkRoots(t *testing.T, description string, actual, expected []float64) {
+	t.Helper()
+	if len(actual) != len(expected) {
+		t.Errorf("%s: expected roots %v but got %v", description, expected, actual)
+		return
+	}
+	for i := range actual {
+		if math.Abs(actual[i]-expected[i]) > 1e-6*math.Max(1, math.Abs(expected[i])) {
+			t.Errorf("%s: expected roots %v but got %v", description, expected, actual)
+			return
+		}
+	}
+}
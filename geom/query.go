@@ -0,0 +1,85 @@
+package geom
+
+import "math"
+
+// Occluder is an Intersectable which can answer occlusion (any-hit) queries.
+// Such queries only ask whether something blocks a ray, so the object is
+// free to stop at the first intersection it finds.
+type Occluder interface {
+	Intersectable
+
+	// Occluded returns true when `ray` intersects this object with a ray
+	// parameter in [0, `tMax`] which is also within the limits of `ray`.
+	Occluded(ray Ray, tMax float64) bool
+}
+
+// ClosestHitter is an Intersector which can find the closest intersection in
+// a given interval of the ray.
+type ClosestHitter interface {
+	Intersector
+
+	// ClosestHit returns the intersection of `ray` with this object which
+	// has the smallest ray parameter in [`tMin`, `tMax`] and within the
+	// limits of `ray`. Its second return value is false when there is no
+	// intersection in this interval.
+	ClosestHit(ray Ray, tMin, tMax float64) (Hit, bool)
+}
+
+// Container is an object which encloses a volume and can tell whether points
+// are inside it.
+type Container interface {
+
+	// Contains returns true when `p` is inside this object. Points on its
+	// surface, within the tolerance of the object, are inside too.
+	Contains(p Vector) bool
+}
+
+// Occluded returns true when `ray` intersects `obj` with a ray parameter in
+// [0, `tMax`]. It uses the most specific query `obj` supports. Objects which
+// can only report whether they intersect a ray at all are considered to
+// occlude it regardless of `tMax`.
+func Occluded(obj Intersectable, ray Ray, tMax float64) bool {
+	switch o := obj.(type) {
+	case Occluder:
+		return o.Occluded(ray, tMax)
+	case Intersector:
+		if math.IsInf(tMax, 1) {
+			return o.Intersect(ray)
+		}
+		_, ok := ClosestHit(o, ray, 0, tMax)
+		return ok
+	default:
+		return obj.Intersect(ray)
+	}
+}
+
+// ClosestHit returns the intersection of `ray` with `obj` which has the
+// smallest ray parameter in [`tMin`, `tMax`]. It uses the most specific query
+// `obj` supports. Objects which are not Intersectors never report hits.
+func ClosestHit(obj Intersectable, ray Ray, tMin, tMax float64) (Hit, bool) {
+	switch o := obj.(type) {
+	case ClosestHitter:
+		return o.ClosestHit(ray, tMin, tMax)
+	case Intersector:
+		tMin, tMax = ray.Clip(tMin, tMax)
+		if tMin > tMax {
+			return Hit{}, false
+		}
+
+		// Move the ray origin to tMin so that IntersectHit skips
+		// everything before it.
+		start := NewRay(ray.At(tMin), ray.Direction)
+		hit, ok := o.IntersectHit(start)
+		if !ok {
+			return Hit{}, false
+		}
+		hit.T += tMin
+		if hit.T > tMax {
+			return Hit{}, false
+		}
+		hit.Point = ray.At(hit.T)
+		return hit, true
+	default:
+		return Hit{}, false
+	}
+}
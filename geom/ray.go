@@ -1,9 +1,20 @@
 package geom
 
+import "math"
+
 // Ray represents a geometric ray defined by an origin point and direction vector.
+// The points of the ray are Origin + t*Direction for every t >= 0, or for every
+// t in [TMin, TMax] when the ray is Bounded.
 type Ray struct {
 	Origin    Vector
 	Direction Vector
+
+	// TMin and TMax limit the ray to a segment when Bounded is true and are
+	// ignored otherwise, so rays which only set their origin and direction
+	// have no limits. TMin may be equal to TMax, which leaves a single
+	// point of the ray. Negative values of TMin are treated as zero.
+	TMin, TMax float64
+	Bounded    bool
 }
 
 // NewRay returns a ray defined by its origin point `origin` and direction `dir`.
@@ -13,3 +24,45 @@ func NewRay(origin, dir Vector) Ray {
 		Direction: dir,
 	}
 }
+
+// Limit returns a copy of the ray which is limited to the ray parameters in
+// [`tMin`, `tMax`]. An infinite `tMax` leaves it without an upper limit.
+func (r Ray) Limit(tMin, tMax float64) Ray {
+	r.TMin, r.TMax, r.Bounded = tMin, tMax, true
+	return r
+}
+
+// Between returns a Ray which starts at `p` and ends at `q`. Its direction is
+// `q - p`, so the ray parameter of `p` is 0 and the one of `q` is 1. Both end
+// points are part of the segment.
+func Between(p, q Vector) Ray {
+	return NewRay(p, Sub(q, p)).Limit(0, 1)
+}
+
+// At returns the point of the ray with parameter `t`.
+func (r Ray) At(t float64) Vector {
+	return Add(r.Origin, Mul(r.Direction, t))
+}
+
+// Interval returns the range of ray parameters which belong to the ray.
+func (r Ray) Interval() (float64, float64) {
+	return r.Clip(0, math.Inf(1))
+}
+
+// Clip returns the part of the interval [`tMin`, `tMax`] which belongs to the
+// ray. The result is an empty interval, with its start after its end, when the
+// two do not overlap.
+func (r Ray) Clip(tMin, tMax float64) (float64, float64) {
+	if r.Bounded {
+		if r.TMin > tMin {
+			tMin = r.TMin
+		}
+		if r.TMax < tMax {
+			tMax = r.TMax
+		}
+	}
+	if tMin < 0 {
+		tMin = 0
+	}
+	return tMin, tMax
+}
@@ -0,0 +1,74 @@
+package geom
+
+import (
+	"math"
+	"testing"
+)
+
+func TestRayInterval(t *testing.T) {
+	inf := math.Inf(1)
+
+	tests := []struct {
+		description string
+		ray         Ray
+		tMin, tMax  float64
+	}{
+		{"new ray", NewRay(NewVector(0, 0, 0), NewVector(1, 0, 0)), 0, inf},
+		{"zero value", Ray{Direction: NewVector(1, 0, 0)}, 0, inf},
+		{"limits without Bounded", Ray{Direction: NewVector(1, 0, 0), TMin: 1, TMax: 2}, 0, inf},
+		{"segment", Between(NewVector(1, 0, 0), NewVector(3, 0, 0)), 0, 1},
+		{"negative start", Ray{Direction: NewVector(1, 0, 0), TMin: -1, TMax: 2, Bounded: true}, 0, 2},
+		{"limited start", NewRay(NewVector(0, 0, 0), NewVector(1, 0, 0)).Limit(1, inf), 1, inf},
+		{"single point", NewRay(NewVector(0, 0, 0), NewVector(1, 0, 0)).Limit(2, 2), 2, 2},
+		{"limited to the origin", NewRay(NewVector(0, 0, 0), NewVector(1, 0, 0)).Limit(0, 0), 0, 0},
+	}
+
+	for _, test := range tests {
+		tMin, tMax := test.ray.Interval()
+		if tMin != test.tMin || tMax != test.tMax {
+			t.Errorf("%s: expected interval [%g, %g] but got [%g, %g]",
+				test.description, test.tMin, test.tMax, tMin, tMax)
+		}
+	}
+
+	tMin, tMax := Between(NewVector(0, 0, 0), NewVector(1, 0, 0)).Clip(0.5, 3)
+	if tMin != 0.5 || tMax != 1 {
+		t.Errorf("Expected clipped interval [0.5, 1] but got [%g, %g]", tMin, tMax)
+	}
+}
+
+func TestRayPoints(t *testing.T) {
+	p, q := NewVector(1, 2, 3), NewVector(3, 2, -1)
+	segment := Between(p, q)
+
+	if at := segment.At(0); at != p {
+		t.Errorf("Expected the segment to start at %v but it starts at %v", p, at)
+	}
+	if at := segment.At(1); at != q {
+		t.Errorf("Expected the segment to end at %v but it ends at %v", q, at)
+	}
+	if at := segment.At(0.5); at != NewVector(2, 2, 1) {
+		t.Errorf("Expected the middle of the segment to be (2, 2, 1) but it was %v", at)
+	}
+}
+
+func TestAABBRespectsRayLimits(t *testing.T) {
+	box := NewAABB(NewVector(-1, -1, -1), NewVector(1, 1, 1))
+
+	if box.Intersect(Between(NewVector(0, 0, -5), NewVector(0, 0, -2))) {
+		t.Errorf("Expected a segment which ends before the box to miss it")
+	}
+	if !box.Intersect(Between(NewVector(0, 0, -5), NewVector(0, 0, -1))) {
+		t.Errorf("Expected a segment which ends on the box to hit it")
+	}
+	up := NewRay(NewVector(0, 0, -5), NewVector(0, 0, 1))
+	if box.Intersect(up.Limit(7, math.Inf(1))) {
+		t.Errorf("Expected a ray which starts after the box to miss it")
+	}
+	if !box.Intersect(up.Limit(4, 4)) {
+		t.Errorf("Expected a single point on the box to hit it")
+	}
+	if !box.Intersect(Ray{Origin: NewVector(0, 0, -5), Direction: NewVector(0, 0, 1)}) {
+		t.Errorf("Expected a ray literal without limits to be unbounded")
+	}
+}
@@ -0,0 +1,227 @@
+/*
+Package sdf describes shapes with signed distance functions and intersects rays
+with them using sphere tracing.
+
+A signed distance function returns the distance from a point to the surface of
+a shape. It is negative inside the shape and positive outside of it. Exact
+distances are not required: the functions of this package only rely on the
+value being no larger than the distance to the surface, so that a sphere with
+this radius around the point does not cross it. Some of the combinators, such
+as SmoothUnion and Twist, only keep this bound approximately. Shapes built with
+them may need a step scale below one, see Shape.WithStepScale.
+
+The primitives are centered at the origin. Use Translate and Transform for
+placing them elsewhere.
+*/
+package sdf
+
+import (
+	"math"
+
+	"github.com/fmi/go-homework/geom"
+)
+
+// Func is a signed distance function.
+type Func func(p geom.Vector) float64
+
+// Sphere returns the distance function of a sphere with radius `r`.
+func Sphere(r float64) Func {
+	return func(p geom.Vector) float64 {
+		return geom.Len(p) - r
+	}
+}
+
+// Box returns the distance function of a box with half sizes `half.X`,
+// `half.Y` and `half.Z` along the coordinate axes.
+func Box(half geom.Vector) Func {
+	return func(p geom.Vector) float64 {
+		q := geom.NewVector(math.Abs(p.X)-half.X, math.Abs(p.Y)-half.Y, math.Abs(p.Z)-half.Z)
+		outside := geom.Len(geom.NewVector(math.Max(q.X, 0), math.Max(q.Y, 0), math.Max(q.Z, 0)))
+		inside := math.Min(math.Max(q.X, math.Max(q.Y, q.Z)), 0)
+		return outside + inside
+	}
+}
+
+// Torus returns the distance function of a torus symmetric around the Z axis.
+// `R` is the distance from the axis to the center of its tube and `r` is the
+// radius of the tube.
+func Torus(R, r float64) Func {
+	return func(p geom.Vector) float64 {
+		return math.Hypot(math.Hypot(p.X, p.Y)-R, p.Z) - r
+	}
+}
+
+// Plane returns the distance function of the half-space below the plane with
+// normal `normal` which is at distance `offset` from the origin along the
+// normal. The normal points outside.
+func Plane(normal geom.Vector, offset float64) Func {
+	n := geom.Normalize(normal)
+	return func(p geom.Vector) float64 {
+		return geom.Dot(p, n) - offset
+	}
+}
+
+// Capsule returns the distance function of the points within distance `r` of
+// the segment from `a` to `b`.
+func Capsule(a, b geom.Vector, r float64) Func {
+	ab := geom.Sub(b, a)
+	l := geom.Dot(ab, ab)
+	return func(p geom.Vector) float64 {
+		ap := geom.Sub(p, a)
+		s := 0.0
+		if l > 0 {
+			s = math.Max(0, math.Min(1, geom.Dot(ap, ab)/l))
+		}
+		return geom.Len(geom.Sub(ap, geom.Mul(ab, s))) - r
+	}
+}
+
+// Union returns the distance function of the points in any of `shapes`.
+func Union(shapes ...Func) Func {
+	return func(p geom.Vector) float64 {
+		d := math.Inf(1)
+		for _, f := range shapes {
+			d = math.Min(d, f(p))
+		}
+		return d
+	}
+}
+
+// Intersection returns the distance function of the points in all `shapes`.
+func Intersection(shapes ...Func) Func {
+	return func(p geom.Vector) float64 {
+		d := math.Inf(-1)
+		for _, f := range shapes {
+			d = math.Max(d, f(p))
+		}
+		return d
+	}
+}
+
+// Difference returns the distance function of the points in `a` which are not
+// in `b`.
+func Difference(a, b Func) Func {
+	return func(p geom.Vector) float64 {
+		return math.Max(a(p), -b(p))
+	}
+}
+
+// SmoothUnion is like Union for two shapes, but blends them where they are
+// closer than `k` to each other, as if they were made of clay. It uses the
+// polynomial smooth minimum of Inigo Quilez.
+func SmoothUnion(a, b Func, k float64) Func {
+	return func(p geom.Vector) float64 {
+		return smoothMin(a(p), b(p), k)
+	}
+}
+
+// SmoothIntersection is like Intersection for two shapes, but rounds the edges
+// where their surfaces meet over a distance of about `k`.
+func SmoothIntersection(a, b Func, k float64) Func {
+	return func(p geom.Vector) float64 {
+		return -smoothMin(-a(p), -b(p), k)
+	}
+}
+
+// SmoothDifference is like Difference, but rounds the edges where the surfaces
+// of `a` and `b` meet over a distance of about `k`.
+func SmoothDifference(a, b Func, k float64) Func {
+	return func(p geom.Vector) float64 {
+		return -smoothMin(-a(p), b(p), k)
+	}
+}
+
+// smoothMin returns the minimum of `a` and `b`, smoothed when they are closer
+// than `k` to each other.
+func smoothMin(a, b, k float64) float64 {
+	if k <= 0 {
+		return math.Min(a, b)
+	}
+	h := math.Max(k-math.Abs(a-b), 0) / k
+	return math.Min(a, b) - h*h*k/4
+}
+
+// Round returns the distance function of the points within distance `r` of
+// `f`. It makes the shape bigger and rounds its edges and corners.
+func Round(f Func, r float64) Func {
+	return func(p geom.Vector) float64 {
+		return f(p) - r
+	}
+}
+
+// Onion returns the distance function of a shell with thickness `thickness`
+// around the surface of `f`.
+func Onion(f Func, thickness float64) Func {
+	return func(p geom.Vector) float64 {
+		return math.Abs(f(p)) - thickness/2
+	}
+}
+
+// Twist returns the distance function of `f` twisted around the Z axis by `k`
+// radians per unit of height. The result is not an exact distance. Its error
+// grows with `k` and the distance from the axis.
+func Twist(f Func, k float64) Func {
+	return func(p geom.Vector) float64 {
+		s, c := math.Sincos(-k * p.Z)
+		return f(geom.NewVector(c*p.X-s*p.Y, s*p.X+c*p.Y, p.Z))
+	}
+}
+
+// Repeat returns the distance function of copies of `f` placed at every point
+// of the grid with spacing `period`. Components of `period` which are zero do
+// not repeat the shape along their axis. The copies must fit in their cells
+// for the result to be a correct distance bound.
+func Repeat(f Func, period geom.Vector) Func {
+	return func(p geom.Vector) float64 {
+		return f(geom.NewVector(repeat(p.X, period.X), repeat(p.Y, period.Y), repeat(p.Z, period.Z)))
+	}
+}
+
+// repeat returns `x` moved to the cell with size `period` around zero.
+func repeat(x, period float64) float64 {
+	if period == 0 {
+		return x
+	}
+	return x - period*math.Floor(x/period+0.5)
+}
+
+// Translate returns the distance function of `f` moved by `v`.
+func Translate(f Func, v geom.Vector) Func {
+	return func(p geom.Vector) float64 {
+		return f(geom.Sub(p, v))
+	}
+}
+
+// Scale returns the distance function of `f` scaled uniformly by `s`.
+func Scale(f Func, s float64) Func {
+	return func(p geom.Vector) float64 {
+		return f(geom.Mul(p, 1/s)) * s
+	}
+}
+
+// Transform returns the distance function of `f` transformed by `m`. The
+// distances stay correct only for rigid transformations, which are made of
+// rotations and translations. Transform panics when `m` is not invertible.
+func Transform(f Func, m geom.Matrix) Func {
+	inv, ok := m.Inverse()
+	if !ok {
+		panic("sdf: Transform called with a singular matrix")
+	}
+	return func(p geom.Vector) float64 {
+		return f(inv.Point(p))
+	}
+}
+
+// Gradient returns the gradient of `f` at `p`, estimated with central
+// differences with step `h`. For distance functions it points outside and its
+// length is close to one near the surface.
+func Gradient(f Func, p geom.Vector, h float64) geom.Vector {
+	dx := geom.NewVector(h, 0, 0)
+	dy := geom.NewVector(0, h, 0)
+	dz := geom.NewVector(0, 0, h)
+	return geom.Mul(geom.NewVector(
+		f(geom.Add(p, dx))-f(geom.Sub(p, dx)),
+		f(geom.Add(p, dy))-f(geom.Sub(p, dy)),
+		f(geom.Add(p, dz))-f(geom.Sub(p, dz)),
+	), 1/(2*h))
+}
@@ -0,0 +1,83 @@
+package sdf
+
+import (
+	"math"
+	"testing"
+
+	"github.com/fmi/go-homework/geom"
+)
+
+func TestDistances(t *testing.T) {
+	sphere := Sphere(1)
+	box := Box(geom.NewVector(1, 2, 3))
+
+	tests := []struct {
+		description string
+		f           Func
+		p           geom.Vector
+		distance    float64
+	}{
+		{"sphere center", sphere, geom.NewVector(0, 0, 0), -1},
+		{"sphere outside", sphere, geom.NewVector(0, 3, 0), 2},
+		{"box face", box, geom.NewVector(1, 0, 0), 0},
+		{"box inside", box, geom.NewVector(0.5, 0, 0), -0.5},
+		{"box outside a face", box, geom.NewVector(0, 0, 5), 2},
+		{"box outside a corner", box, geom.NewVector(2, 3, 4), math.Sqrt(3)},
+		{"torus tube", Torus(2, 0.5), geom.NewVector(0, 2, 0), -0.5},
+		{"torus hole", Torus(2, 0.5), geom.NewVector(0, 0, 0), 1.5},
+		{"plane", Plane(geom.NewVector(0, 0, 2), 1), geom.NewVector(5, 5, 3), 2},
+		{"capsule side", Capsule(geom.NewVector(0, 0, 0), geom.NewVector(0, 0, 2), 0.5), geom.NewVector(1, 0, 1), 0.5},
+		{"capsule end", Capsule(geom.NewVector(0, 0, 0), geom.NewVector(0, 0, 2), 0.5), geom.NewVector(0, 0, 3), 0.5},
+		{"union", Union(sphere, Translate(sphere, geom.NewVector(3, 0, 0))), geom.NewVector(2.5, 0, 0), -0.5},
+		{"intersection", Intersection(sphere, Translate(sphere, geom.NewVector(1, 0, 0))), geom.NewVector(0, 0, 0), 0},
+		{"difference", Difference(box, sphere), geom.NewVector(0, 0, 0), 1},
+		{"smooth union far", SmoothUnion(sphere, Translate(sphere, geom.NewVector(5, 0, 0)), 0.5), geom.NewVector(-2, 0, 0), 1},
+		{"smooth union blend", SmoothUnion(sphere, Translate(sphere, geom.NewVector(3, 0, 0)), 1), geom.NewVector(1.5, 0, 0), 0.25},
+		{"smooth intersection", SmoothIntersection(sphere, sphere, 1), geom.NewVector(0, 0, 0), -0.75},
+		{"smooth difference", SmoothDifference(box, Translate(sphere, geom.NewVector(5, 0, 0)), 1), geom.NewVector(0, 0, 0), -1},
+		{"round", Round(box, 0.5), geom.NewVector(2, 3, 4), math.Sqrt(3) - 0.5},
+		{"onion inside", Onion(sphere, 0.2), geom.NewVector(0, 0, 0.95), -0.05},
+		{"onion hollow", Onion(sphere, 0.2), geom.NewVector(0, 0, 0), 0.9},
+		{"twist", Twist(Box(geom.NewVector(1, 0.1, 10)), math.Pi/2), geom.NewVector(0, 0.5, 1), -0.1},
+		{"repeat", Repeat(sphere, geom.NewVector(4, 0, 4)), geom.NewVector(8, 0, -4.5), -0.5},
+		{"repeat along one axis", Repeat(sphere, geom.NewVector(4, 0, 0)), geom.NewVector(4, 3, 0), 2},
+		{"scale", Scale(sphere, 2), geom.NewVector(0, 3, 0), 1},
+		{"transform", Transform(box, geom.RotateZ(math.Pi/2)), geom.NewVector(2, 0, 0), 0},
+	}
+
+	for _, test := range tests {
+		if d := test.f(test.p); math.Abs(d-test.distance) > 1e-9 {
+			t.Errorf("%s: expected the distance to be %g but it was %g", test.description, test.distance, d)
+		}
+	}
+}
+
+func TestGradient(t *testing.T) {
+	tests := []struct {
+		description string
+		f           Func
+		p           geom.Vector
+		gradient    geom.Vector
+	}{
+		{"sphere", Sphere(1), geom.NewVector(0, 2, 0), geom.NewVector(0, 1, 0)},
+		{"sphere diagonal", Sphere(1), geom.NewVector(1, 1, 1), geom.Normalize(geom.NewVector(1, 1, 1))},
+		{"box face", Box(geom.NewVector(1, 1, 1)), geom.NewVector(0.2, -1, 0.3), geom.NewVector(0, -1, 0)},
+		{"plane", Plane(geom.NewVector(1, 1, 0), 0), geom.NewVector(3, 1, 2), geom.Normalize(geom.NewVector(1, 1, 0))},
+	}
+
+	for _, test := range tests {
+		g := Gradient(test.f, test.p, 1e-6)
+		if geom.Len(geom.Sub(g, test.gradient)) > 1e-6 {
+			t.Errorf("%s: expected the gradient to be %v but it was %v", test.description, test.gradient, g)
+		}
+	}
+}
+
+func TestTransformPanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Errorf("Expected Transform to panic for a singular matrix")
+		}
+	}()
+	Transform(Sphere(1), geom.Scale(geom.NewVector(1, 0, 1)))
+}
@@ -0,0 +1,133 @@
+package sdf
+
+import (
+	"math"
+
+	"github.com/fmi/go-homework/geom"
+)
+
+// DefaultTolerance is the tolerance used by new shapes. Sphere tracing
+// approaches the surface slowly, especially for rays which graze it, so it is
+// coarser than geom.DefaultTolerance.
+var DefaultTolerance = geom.Tolerance{Absolute: 1e-6, Relative: 1e-6}
+
+// DefaultMaxSteps is the number of steps after which new shapes give up
+// tracing a ray.
+const DefaultMaxSteps = 256
+
+// Shape is a geom.Intersectable which represents the surface of a signed
+// distance function. Rays are intersected with it using sphere tracing: the
+// ray advances by the distance to the surface, which can never make it cross
+// the surface, until it is close enough to it. Rays which do not get close
+// enough within the maximum number of steps miss the shape.
+//
+// Shapes are safe for concurrent use as long as their distance functions are.
+type Shape struct {
+	f      Func
+	bounds geom.AABB
+
+	maxSteps  int
+	tolerance geom.Tolerance
+	stepScale float64
+}
+
+// NewShape returns a Shape with distance function `f`, which lies entirely in
+// `bounds`. Rays are traced only within the bounds, so tighter bounds make
+// tracing faster. Use geom.InfiniteAABB for shapes which are not bounded.
+func NewShape(f Func, bounds geom.AABB) *Shape {
+	return &Shape{
+		f:         f,
+		bounds:    bounds,
+		maxSteps:  DefaultMaxSteps,
+		tolerance: DefaultTolerance,
+		stepScale: 1,
+	}
+}
+
+// WithMaxSteps returns a copy of the shape which gives up tracing a ray after
+// `steps` steps.
+func (s *Shape) WithMaxSteps(steps int) *Shape {
+	shape := *s
+	shape.maxSteps = steps
+	return &shape
+}
+
+// WithTolerance returns a copy of the shape for which rays hit the surface
+// when they are within `tolerance` of it. The size used for the relative part
+// of the tolerance is the distance from the origin of the ray, so that far
+// away parts of the shape are traced with less precision.
+func (s *Shape) WithTolerance(tolerance geom.Tolerance) *Shape {
+	shape := *s
+	shape.tolerance = tolerance
+	return &shape
+}
+
+// WithStepScale returns a copy of the shape which advances rays by `scale`
+// times the distance to the surface. Scales below one make tracing slower,
+// but are needed for distance functions which overestimate the distance, such
+// as the ones made with Twist.
+func (s *Shape) WithStepScale(scale float64) *Shape {
+	shape := *s
+	shape.stepScale = scale
+	return &shape
+}
+
+// Func returns the distance function of the shape.
+func (s *Shape) Func() Func {
+	return s.f
+}
+
+// Intersect implements the geom.Intersectable interface.
+func (s *Shape) Intersect(ray geom.Ray) bool {
+	return s.Occluded(ray, math.Inf(1))
+}
+
+// IntersectHit implements the geom.Intersector interface.
+func (s *Shape) IntersectHit(ray geom.Ray) (geom.Hit, bool) {
+	return s.ClosestHit(ray, 0, math.Inf(1))
+}
+
+// Occluded implements the geom.Occluder interface.
+func (s *Shape) Occluded(ray geom.Ray, tMax float64) bool {
+	_, ok := s.ClosestHit(ray, 0, tMax)
+	return ok
+}
+
+// ClosestHit implements the geom.ClosestHitter interface. Rays starting inside
+// the shape are traced to where they leave it.
+//
+// The normal of the returned hit is the gradient of the distance function,
+// estimated with Gradient. Its U and V are always zero.
+func (s *Shape) ClosestHit(ray geom.Ray, tMin, tMax float64) (geom.Hit, bool) {
+	tMin, tMax, ok := s.bounds.IntersectRay(ray, tMin, tMax)
+	dl := geom.Len(ray.Direction)
+	if !ok || dl == 0 {
+		return geom.Hit{}, false
+	}
+
+	t := tMin
+	for i := 0; i < s.maxSteps && t <= tMax; i++ {
+		p := ray.At(t)
+		d := math.Abs(s.f(p))
+		eps := s.tolerance.Epsilon(t * dl)
+		if d <= eps {
+			hit := geom.Hit{T: t, Point: p}
+			hit.SetFaceNormal(ray, Gradient(s.f, p, eps))
+			return hit, true
+		}
+		t += s.stepScale * d / dl
+	}
+
+	return geom.Hit{}, false
+}
+
+// Contains implements the geom.Container interface. Points within the
+// absolute part of the tolerance of the shape from its surface are inside.
+func (s *Shape) Contains(p geom.Vector) bool {
+	return s.f(p) <= s.tolerance.Absolute
+}
+
+// Bounds implements the geom.Bounded interface.
+func (s *Shape) Bounds() geom.AABB {
+	return s.bounds
+}
@@ -0,0 +1,166 @@
+package sdf
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/fmi/go-homework/geom"
+)
+
+// cube returns the bounds of the cube with center at the origin and half
+// size `half`.
+func cube(half float64) geom.AABB {
+	return geom.NewAABB(geom.NewVector(-half, -half, -half), geom.NewVector(half, half, half))
+}
+
+func TestShapeHits(t *testing.T) {
+	sphere := NewShape(Translate(Sphere(1), geom.NewVector(0, 0, 1)), geom.InfiniteAABB())
+	rounded := NewShape(Round(Box(geom.NewVector(1, 1, 1)), 0.5), cube(2))
+	twisted := NewShape(Twist(Box(geom.NewVector(2, 0.5, 2)), 0.5), cube(3)).WithStepScale(0.5)
+	grid := NewShape(Repeat(Sphere(0.5), geom.NewVector(2, 2, 0)), geom.InfiniteAABB())
+
+	tests := []struct {
+		description string
+		shape       *Shape
+		ray         geom.Ray
+		intersected bool
+		t           float64
+		normal      geom.Vector
+		front       bool
+	}{
+		{
+			"sphere", sphere,
+			geom.NewRay(geom.NewVector(0, 0, 5), geom.NewVector(0, 0, -1)),
+			true, 3, geom.NewVector(0, 0, 1), true,
+		},
+		{
+			"sphere with a long direction", sphere,
+			geom.NewRay(geom.NewVector(0, 0, 5), geom.NewVector(0, 0, -4)),
+			true, 0.75, geom.NewVector(0, 0, 1), true,
+		},
+		{
+			"sphere from inside", sphere,
+			geom.NewRay(geom.NewVector(0, 0, 1), geom.NewVector(1, 0, 0)),
+			true, 1, geom.NewVector(-1, 0, 0), false,
+		},
+		{
+			"sphere miss", sphere,
+			geom.NewRay(geom.NewVector(1.01, 0, 5), geom.NewVector(0, 0, -1)),
+			false, 0, geom.Vector{}, false,
+		},
+		{
+			"sphere behind", sphere,
+			geom.NewRay(geom.NewVector(0, 0, 5), geom.NewVector(0, 0, 1)),
+			false, 0, geom.Vector{}, false,
+		},
+		{
+			"rounded box face", rounded,
+			geom.NewRay(geom.NewVector(0.5, 0.2, -5), geom.NewVector(0, 0, 1)),
+			true, 3.5, geom.NewVector(0, 0, -1), true,
+		},
+		{
+			"rounded box edge", rounded,
+			geom.NewRay(geom.NewVector(5, 5, 0), geom.NewVector(-1, -1, 0)),
+			true, 4 - 0.5/math.Sqrt2, geom.Normalize(geom.NewVector(1, 1, 0)), true,
+		},
+		{
+			"twisted box bottom", twisted,
+			geom.NewRay(geom.NewVector(0.2, 0, -5), geom.NewVector(0, 0, 1)),
+			true, 3, geom.NewVector(0, 0, -1), true,
+		},
+		{
+			"repeated spheres", grid,
+			geom.NewRay(geom.NewVector(4, -6, 5), geom.NewVector(0, 0, -1)),
+			true, 4.5, geom.NewVector(0, 0, 1), true,
+		},
+		{
+			"between repeated spheres", grid,
+			geom.NewRay(geom.NewVector(1, 1, 5), geom.NewVector(0, 0, -1)),
+			false, 0, geom.Vector{}, false,
+		},
+	}
+
+	for _, test := range tests {
+		hit, ok := test.shape.IntersectHit(test.ray)
+		if ok != test.intersected {
+			t.Errorf("%s: expected intersection to be %t but it was %t", test.description, test.intersected, ok)
+			continue
+		}
+		if test.shape.Intersect(test.ray) != ok {
+			t.Errorf("%s: Intersect disagrees with IntersectHit", test.description)
+		}
+		if !ok {
+			continue
+		}
+
+		if math.Abs(hit.T-test.t) > 1e-5 {
+			t.Errorf("%s: expected t to be %g but it was %g", test.description, test.t, hit.T)
+		}
+		if geom.Len(geom.Sub(hit.Point, test.ray.At(hit.T))) > 1e-12 {
+			t.Errorf("%s: expected the hit point to be on the ray", test.description)
+		}
+		if geom.Len(geom.Sub(hit.Normal, test.normal)) > 1e-4 || hit.FrontFace != test.front {
+			t.Errorf("%s: expected normal %v (front %t) but it was %v (front %t)",
+				test.description, test.normal, test.front, hit.Normal, hit.FrontFace)
+		}
+		if test.shape.Occluded(test.ray, hit.T*0.9) {
+			t.Errorf("%s: expected no occlusion before the hit", test.description)
+		}
+	}
+}
+
+func TestShapeConfiguration(t *testing.T) {
+	shape := NewShape(Sphere(1), cube(1))
+	ray := geom.NewRay(geom.NewVector(0.9, 0.9, 5), geom.NewVector(-0.3, -0.3, -1))
+
+	// Rays grazing the sphere need many steps.
+	hit, ok := shape.IntersectHit(ray)
+	if !ok {
+		t.Fatalf("Expected the ray to hit the sphere")
+	}
+	if shape.WithMaxSteps(3).Intersect(ray) {
+		t.Errorf("Expected the ray to run out of steps")
+	}
+
+	coarse, ok := shape.WithTolerance(geom.Tolerance{Absolute: 0.1}).IntersectHit(ray)
+	if !ok || coarse.T >= hit.T || hit.T-coarse.T > 0.2 {
+		t.Errorf("Expected a coarse tolerance to stop the ray a bit earlier, at %g instead of %g", coarse.T, hit.T)
+	}
+
+	// The twisted box overestimates distances and full steps jump over
+	// its corners.
+	twisted := NewShape(Twist(Box(geom.NewVector(2, 0.2, 2)), 1.5), cube(3))
+	rnd := rand.New(rand.NewSource(3))
+	misses, scaledMisses := 0, 0
+	for i := 0; i < 1000; i++ {
+		origin := geom.NewVector(rnd.Float64()*8-4, -5, rnd.Float64()*8-4)
+		ray := geom.NewRay(origin, geom.NewVector(0, 1, 0))
+		inside := false
+		for y := -3.0; y <= 3; y += 0.01 {
+			if twisted.Contains(geom.NewVector(origin.X, y, origin.Z)) {
+				inside = true
+				break
+			}
+		}
+		if !inside {
+			continue
+		}
+		if !twisted.Intersect(ray) {
+			misses++
+		}
+		if !twisted.WithStepScale(0.5).Intersect(ray) {
+			scaledMisses++
+		}
+	}
+	if scaledMisses > 0 {
+		t.Errorf("Expected smaller steps to find all hits, but %d rays missed (%d with full steps)", scaledMisses, misses)
+	}
+
+	if !shape.Contains(geom.NewVector(0, 1, 0)) || shape.Contains(geom.NewVector(0, 1.001, 0)) {
+		t.Errorf("Expected the surface to be inside the shape and nothing else")
+	}
+	if shape.Bounds() != cube(1) {
+		t.Errorf("Expected the shape to keep its bounds")
+	}
+}
@@ -0,0 +1,494 @@
+/*
+Package stl reads and writes triangle meshes in the STL format.
+
+Both the binary and the ASCII variants of the format can be read. The
+triangles are parsed one at a time, so large files can be processed with a
+Reader without keeping the whole file in memory.
+*/
+package stl
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/fmi/go-homework/geom"
+)
+
+const (
+	headerSize   = 80
+	triangleSize = 50
+)
+
+// ErrTrailingData is returned for binary files which have more data after the
+// number of triangles given in their header.
+var ErrTrailingData = errors.New("stl: data after the last triangle of a binary file")
+
+// Triangle is a single facet of an STL file.
+type Triangle struct {
+	Normal   geom.Vector
+	Vertices [3]geom.Vector
+}
+
+// ParseError is returned for malformed ASCII input.
+type ParseError struct {
+	// Line is the number of the line which can not be parsed, starting
+	// from 1.
+	Line int
+	Err  error
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("stl: line %d: %s", e.Line, e.Err)
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// Reader reads the triangles of an STL file one by one.
+type Reader struct {
+	r      *bufio.Reader
+	binary bool
+	name   string
+
+	// remaining is the number of triangles left in a binary file.
+	remaining uint32
+	read      int
+
+	// line and fields hold the position in an ASCII file.
+	line   int
+	fields []string
+}
+
+// NewReader returns a Reader for the STL file in `r`. It reads the header
+// of the file and detects whether it is binary or ASCII.
+//
+// ASCII files start with "solid", but so do some binary ones. A file is
+// treated as ASCII only when "solid" is followed by a "facet" or an
+// "endsolid" keyword on the next lines, or when its first line is text which
+// is too long for finding them.
+//
+// The number of triangles in the header of a binary file is checked against
+// the size of the file when `r` is an io.Seeker. Otherwise Next reports files
+// which are longer than the header says with ErrTrailingData after the last
+// triangle.
+func NewReader(r io.Reader) (*Reader, error) {
+	size, err := remainingSize(r)
+	if err != nil {
+		return nil, err
+	}
+	rd := &Reader{r: bufio.NewReaderSize(r, 64*1024)}
+
+	start, full, err := rd.peekStart()
+	if err != nil {
+		return nil, err
+	}
+
+	if isASCII(start, full) {
+		if err := rd.readSolid(); err != nil {
+			return nil, err
+		}
+		return rd, nil
+	}
+
+	var header [headerSize + 4]byte
+	if _, err := io.ReadFull(rd.r, header[:]); err != nil {
+		return nil, fmt.Errorf("stl: reading binary header: %w", noEOF(err))
+	}
+	rd.binary = true
+	rd.name = strings.TrimRight(string(header[:headerSize]), "\x00 ")
+	rd.remaining = binary.LittleEndian.Uint32(header[headerSize:])
+
+	if body, expected := size-(headerSize+4), int64(rd.remaining)*triangleSize; size >= 0 && body != expected {
+		cause := ErrTrailingData
+		if body < expected {
+			cause = io.ErrUnexpectedEOF
+		}
+		return nil, fmt.Errorf("stl: header counts %d triangles, but the file has room for %d: %w",
+			rd.remaining, body/triangleSize, cause)
+	}
+
+	return rd, nil
+}
+
+// remainingSize returns the number of bytes from the current position of `r`
+// to its end, or -1 when `r` is not an io.Seeker.
+func remainingSize(r io.Reader) (int64, error) {
+	seeker, ok := r.(io.Seeker)
+	if !ok {
+		return -1, nil
+	}
+	current, err := seeker.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return -1, nil
+	}
+	end, err := seeker.Seek(0, io.SeekEnd)
+	if err != nil {
+		return 0, err
+	}
+	if _, err := seeker.Seek(current, io.SeekStart); err != nil {
+		return 0, err
+	}
+	return end - current, nil
+}
+
+// peekStart returns the start of the file without consuming it. It peeks
+// further until the start holds the first line and the word after it, the
+// file ends or the buffer is full, which is reported by `full`.
+func (rd *Reader) peekStart() (start []byte, full bool, err error) {
+	for n := 512; ; n *= 2 {
+		if n > rd.r.Size() {
+			n = rd.r.Size()
+		}
+		start, err = rd.r.Peek(n)
+		if err == io.EOF {
+			return start, false, nil
+		}
+		if err != nil {
+			return nil, false, err
+		}
+		if hasKeyword(start) {
+			return start, false, nil
+		}
+		if n == rd.r.Size() {
+			return start, true, nil
+		}
+	}
+}
+
+// hasKeyword tells whether `start` holds the whole first line of a file and
+// the whole word after it.
+func hasKeyword(start []byte) bool {
+	newline := bytes.IndexByte(start, '\n')
+	if newline < 0 {
+		return false
+	}
+	rest := bytes.TrimLeftFunc(start[newline+1:], unicode.IsSpace)
+	return bytes.IndexFunc(rest, unicode.IsSpace) >= 0
+}
+
+// isASCII tells whether a file starting with `start` is an ASCII STL file.
+// `full` is true when `start` is only a part of a longer first line.
+func isASCII(start []byte, full bool) bool {
+	fields := bytes.Fields(start)
+	if len(fields) == 0 || string(fields[0]) != "solid" {
+		return false
+	}
+
+	// The name after "solid" is optional and may contain spaces, so the
+	// first keyword is searched for after the end of the first line.
+	newline := bytes.IndexByte(start, '\n')
+	if newline < 0 {
+		// Binary files have floating point data after a header of 80
+		// bytes, which is almost never all printable.
+		return full && bytes.IndexFunc(start, func(r rune) bool {
+			return r == unicode.ReplacementChar || !unicode.IsPrint(r) && !unicode.IsSpace(r)
+		}) < 0
+	}
+	rest := bytes.Fields(start[newline+1:])
+	return len(rest) > 0 && (string(rest[0]) == "facet" || string(rest[0]) == "endsolid")
+}
+
+// Binary tells whether the file is in the binary format.
+func (rd *Reader) Binary() bool {
+	return rd.binary
+}
+
+// Name returns the name of the solid in an ASCII file or the header of a
+// binary file without its trailing padding.
+func (rd *Reader) Name() string {
+	return rd.name
+}
+
+// Next returns the next triangle of the file. It returns io.EOF when there
+// are no more triangles, or ErrTrailingData when a binary file goes on after
+// the triangles given in its header.
+func (rd *Reader) Next() (Triangle, error) {
+	if rd.binary {
+		return rd.nextBinary()
+	}
+	return rd.nextASCII()
+}
+
+func (rd *Reader) nextBinary() (Triangle, error) {
+	if rd.remaining == 0 {
+		if _, err := rd.r.Peek(1); err == nil {
+			return Triangle{}, fmt.Errorf("stl: after %d triangles: %w", rd.read, ErrTrailingData)
+		}
+		return Triangle{}, io.EOF
+	}
+
+	var buf [triangleSize]byte
+	if _, err := io.ReadFull(rd.r, buf[:]); err != nil {
+		return Triangle{}, fmt.Errorf("stl: reading triangle %d: %w", rd.read, noEOF(err))
+	}
+	rd.remaining--
+	rd.read++
+
+	vector := func(offset int) geom.Vector {
+		return geom.NewVector(
+			float64(math.Float32frombits(binary.LittleEndian.Uint32(buf[offset:]))),
+			float64(math.Float32frombits(binary.LittleEndian.Uint32(buf[offset+4:]))),
+			float64(math.Float32frombits(binary.LittleEndian.Uint32(buf[offset+8:]))),
+		)
+	}
+
+	return Triangle{
+		Normal:   vector(0),
+		Vertices: [3]geom.Vector{vector(12), vector(24), vector(36)},
+	}, nil
+}
+
+// readSolid reads the "solid" line of an ASCII file.
+func (rd *Reader) readSolid() error {
+	if err := rd.nextLine(); err != nil {
+		return err
+	}
+	rd.name = strings.Join(rd.fields[1:], " ")
+	return nil
+}
+
+func (rd *Reader) nextASCII() (Triangle, error) {
+	var t Triangle
+
+	if err := rd.nextLine(); err == io.EOF {
+		return t, rd.errorf("missing endsolid")
+	} else if err != nil {
+		return t, err
+	}
+	if rd.fields[0] == "endsolid" {
+		return t, io.EOF
+	}
+
+	normal, err := rd.check(5, "facet", "normal")
+	if err != nil {
+		return t, err
+	}
+	if t.Normal, err = rd.vector(normal); err != nil {
+		return t, err
+	}
+
+	if err := rd.expectLine(0, "outer", "loop"); err != nil {
+		return t, err
+	}
+	for i := range t.Vertices {
+		vertex, err := rd.expect(4, "vertex")
+		if err != nil {
+			return t, err
+		}
+		if t.Vertices[i], err = rd.vector(vertex); err != nil {
+			return t, err
+		}
+	}
+	if err := rd.expectLine(0, "endloop"); err != nil {
+		return t, err
+	}
+	if err := rd.expectLine(0, "endfacet"); err != nil {
+		return t, err
+	}
+
+	rd.read++
+	return t, nil
+}
+
+// expectLine reads the next line and checks that it starts with `keywords`
+// and has `fields` fields in total. A zero `fields` means the keywords only.
+func (rd *Reader) expectLine(fields int, keywords ...string) error {
+	_, err := rd.expect(fields, keywords...)
+	return err
+}
+
+// expect reads the next line, checks it like expectLine and returns the
+// fields after the keywords.
+func (rd *Reader) expect(fields int, keywords ...string) ([]string, error) {
+	if err := rd.nextLine(); err == io.EOF {
+		return nil, rd.errorf("unexpected end of file, expected %q", strings.Join(keywords, " "))
+	} else if err != nil {
+		return nil, err
+	}
+	return rd.check(fields, keywords...)
+}
+
+// check checks the current line like expectLine and returns the fields
+// after the keywords.
+func (rd *Reader) check(fields int, keywords ...string) ([]string, error) {
+	want := strings.Join(keywords, " ")
+	if fields == 0 {
+		fields = len(keywords)
+	}
+	if len(rd.fields) < len(keywords) || strings.Join(rd.fields[:len(keywords)], " ") != want {
+		return nil, rd.errorf("expected %q", want)
+	}
+	if len(rd.fields) != fields {
+		return nil, rd.errorf("expected %d fields after %q but got %d",
+			fields-len(keywords), want, len(rd.fields)-len(keywords))
+	}
+	return rd.fields[len(keywords):], nil
+}
+
+// vector parses the three coordinates in `fields`.
+func (rd *Reader) vector(fields []string) (geom.Vector, error) {
+	var v [3]float64
+	for i, field := range fields {
+		f, err := strconv.ParseFloat(field, 64)
+		if err != nil {
+			return geom.Vector{}, rd.errorf("malformed number %q", field)
+		}
+		v[i] = f
+	}
+	return geom.NewVector(v[0], v[1], v[2]), nil
+}
+
+// nextLine reads the next non-empty line of an ASCII file in rd.fields.
+func (rd *Reader) nextLine() error {
+	for {
+		text, err := rd.r.ReadString('\n')
+		if len(text) > 0 {
+			rd.line++
+		}
+		if fields := strings.Fields(text); len(fields) > 0 {
+			rd.fields = fields
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (rd *Reader) errorf(format string, args ...interface{}) error {
+	return &ParseError{Line: rd.line, Err: fmt.Errorf(format, args...)}
+}
+
+// noEOF converts io.EOF to io.ErrUnexpectedEOF for files which end too
+// early.
+func noEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+// Read reads all triangles of an STL file and returns them as a mesh.
+// Vertices with exactly the same position are shared by the triangles. The
+// facet normals of the file are not used since the mesh computes them from
+// the winding of the triangles.
+func Read(r io.Reader) (*geom.Mesh, error) {
+	rd, err := NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var positions []geom.Vector
+	var indices []int
+	vertices := make(map[geom.Vector]int)
+
+	for {
+		t, err := rd.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		for _, v := range t.Vertices {
+			index, ok := vertices[v]
+			if !ok {
+				index = len(positions)
+				vertices[v] = index
+				positions = append(positions, v)
+			}
+			indices = append(indices, index)
+		}
+	}
+
+	return geom.NewMesh(positions, nil, nil, indices)
+}
+
+// Write writes `mesh` to `w` in the binary STL format. The coordinates are
+// stored as float32 as required by the format.
+func Write(w io.Writer, mesh *geom.Mesh) error {
+	if mesh == nil {
+		return errors.New("stl: no mesh to write")
+	}
+	faces := mesh.NumFaces()
+	if uint64(faces) > math.MaxUint32 {
+		return fmt.Errorf("stl: mesh has too many faces: %d", faces)
+	}
+
+	bw := bufio.NewWriter(w)
+
+	var header [headerSize + 4]byte
+	copy(header[:], "binary STL")
+	binary.LittleEndian.PutUint32(header[headerSize:], uint32(faces))
+	bw.Write(header[:])
+
+	var buf [triangleSize]byte
+	put := func(offset int, v geom.Vector) {
+		binary.LittleEndian.PutUint32(buf[offset:], math.Float32bits(float32(v.X)))
+		binary.LittleEndian.PutUint32(buf[offset+4:], math.Float32bits(float32(v.Y)))
+		binary.LittleEndian.PutUint32(buf[offset+8:], math.Float32bits(float32(v.Z)))
+	}
+
+	positions := mesh.Positions()
+	for i := 0; i < faces; i++ {
+		face := mesh.Face(i)
+		put(0, faceNormal(positions, face))
+		for j, index := range face {
+			put(12+12*j, positions[index])
+		}
+		bw.Write(buf[:])
+	}
+
+	return bw.Flush()
+}
+
+// WriteASCII writes `mesh` to `w` in the ASCII STL format as a solid named
+// `name`.
+func WriteASCII(w io.Writer, name string, mesh *geom.Mesh) error {
+	if mesh == nil {
+		return errors.New("stl: no mesh to write")
+	}
+
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "solid %s\n", name)
+
+	positions := mesh.Positions()
+	for i := 0; i < mesh.NumFaces(); i++ {
+		face := mesh.Face(i)
+		fmt.Fprintf(bw, "  facet normal %s\n    outer loop\n", formatVector(faceNormal(positions, face)))
+		for _, index := range face {
+			fmt.Fprintf(bw, "      vertex %s\n", formatVector(positions[index]))
+		}
+		bw.WriteString("    endloop\n  endfacet\n")
+	}
+
+	fmt.Fprintf(bw, "endsolid %s\n", name)
+	return bw.Flush()
+}
+
+// faceNormal returns the unit normal of a face given by its winding or the
+// zero vector for degenerate faces.
+func faceNormal(positions []geom.Vector, face [3]int) geom.Vector {
+	a, b, c := positions[face[0]], positions[face[1]], positions[face[2]]
+	n := geom.Cross(geom.Sub(b, a), geom.Sub(c, a))
+	if geom.Len(n) == 0 {
+		return geom.Vector{}
+	}
+	return geom.Normalize(n)
+}
+
+func formatVector(v geom.Vector) string {
+	return strconv.FormatFloat(v.X, 'g', -1, 64) + " " +
+		strconv.FormatFloat(v.Y, 'g', -1, 64) + " " +
+		strconv.FormatFloat(v.Z, 'g', -1, 64)
+}
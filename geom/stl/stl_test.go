@@ -0,0 +1,270 @@
+package stl
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/fmi/go-homework/geom"
+)
+
+const tetrahedron = `solid tetrahedron
+  facet normal 0 0 -1
+    outer loop
+      vertex 0 0 0
+      vertex 0 1 0
+      vertex 1 0 0
+    endloop
+  endfacet
+  facet normal 0 -1 0
+    outer loop
+      vertex 0 0 0
+      vertex 1 0 0
+      vertex 0 0 1
+    endloop
+  endfacet
+  facet normal -1 0 0
+    outer loop
+      vertex 0 0 0
+      vertex 0 0 1
+      vertex 0 1 0
+    endloop
+  endfacet
+  facet normal 0.577 0.577 0.577
+    outer loop
+      vertex 1 0 0
+      vertex 0 1 0
+      vertex 0 0 1
+    endloop
+  endfacet
+endsolid tetrahedron
+`
+
+func checkTetrahedron(t *testing.T, description string, mesh *geom.Mesh) {
+	t.Helper()
+
+	if n := mesh.NumFaces(); n != 4 {
+		t.Errorf("%s: expected 4 faces but got %d", description, n)
+	}
+	if n := len(mesh.Positions()); n != 4 {
+		t.Errorf("%s: expected 4 shared vertices but got %d", description, n)
+	}
+
+	ray := geom.NewRay(geom.NewVector(0.2, 0.2, 5), geom.NewVector(0, 0, -1))
+	hit, ok := mesh.IntersectHit(ray)
+	if !ok {
+		t.Fatalf("%s: expected the ray to hit the tetrahedron", description)
+	}
+	if math.Abs(hit.T-4.4) > 1e-6 || !hit.FrontFace {
+		t.Errorf("%s: expected a front face hit at t=4.4 but got %+v", description, hit)
+	}
+}
+
+func TestReadASCII(t *testing.T) {
+	rd, err := NewReader(strings.NewReader(tetrahedron))
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if rd.Binary() || rd.Name() != "tetrahedron" {
+		t.Errorf("Expected an ASCII solid named tetrahedron but got binary=%t, name=%q", rd.Binary(), rd.Name())
+	}
+
+	mesh, err := Read(strings.NewReader(tetrahedron))
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	checkTetrahedron(t, "ASCII", mesh)
+}
+
+func TestReadBinaryStartingWithSolid(t *testing.T) {
+	mesh, err := Read(strings.NewReader(tetrahedron))
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	var buf bytes.Buffer
+	if err := Write(&buf, mesh); err != nil {
+		t.Fatalf("Unexpected error writing: %s", err)
+	}
+
+	// Some exporters put "solid" at the start of binary headers too.
+	data := buf.Bytes()
+	copy(data, "solid exported by a careless tool")
+
+	rd, err := NewReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if !rd.Binary() {
+		t.Fatalf("Expected the file to be detected as binary")
+	}
+
+	count := 0
+	for {
+		_, err := rd.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		count++
+	}
+	if count != 4 {
+		t.Errorf("Expected 4 triangles but got %d", count)
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	mesh, err := Read(strings.NewReader(tetrahedron))
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	writers := []struct {
+		description string
+		write       func(io.Writer, *geom.Mesh) error
+	}{
+		{"binary", Write},
+		{"ASCII", func(w io.Writer, m *geom.Mesh) error { return WriteASCII(w, "copy", m) }},
+	}
+
+	for _, writer := range writers {
+		var buf bytes.Buffer
+		if err := writer.write(&buf, mesh); err != nil {
+			t.Errorf("%s: unexpected error writing: %s", writer.description, err)
+			continue
+		}
+		read, err := Read(&buf)
+		if err != nil {
+			t.Errorf("%s: unexpected error reading: %s", writer.description, err)
+			continue
+		}
+		checkTetrahedron(t, writer.description, read)
+	}
+}
+
+func TestReadErrors(t *testing.T) {
+	tests := []struct {
+		description string
+		input       string
+		line        int
+	}{
+		{
+			"malformed number",
+			"solid x\nfacet normal 0 0 1\nouter loop\nvertex 0 0 0\nvertex 1 0 0\nvertex 0 1 zero\n",
+			6,
+		},
+		{
+			"missing endloop",
+			"solid x\nfacet normal 0 0 1\nouter loop\nvertex 0 0 0\nvertex 1 0 0\nvertex 0 1 0\nendfacet\n",
+			7,
+		},
+		{
+			"too few coordinates",
+			"solid x\nfacet normal 0 0 1\nouter loop\nvertex 0 0\n",
+			4,
+		},
+		{
+			"missing endsolid",
+			"solid x\nfacet normal 0 0 1\nouter loop\nvertex 0 0 0\nvertex 1 0 0\nvertex 0 1 0\nendloop\nendfacet\n",
+			8,
+		},
+	}
+
+	for _, test := range tests {
+		_, err := Read(strings.NewReader(test.input))
+		var perr *ParseError
+		if !errors.As(err, &perr) {
+			t.Errorf("%s: expected a parse error but got %v", test.description, err)
+			continue
+		}
+		if perr.Line != test.line {
+			t.Errorf("%s: expected an error on line %d but got %q", test.description, test.line, err)
+		}
+	}
+}
+
+func TestReadTruncatedBinary(t *testing.T) {
+	var header [headerSize + 4]byte
+	binary.LittleEndian.PutUint32(header[headerSize:], 2)
+	data := append(header[:], make([]byte, triangleSize+10)...)
+
+	_, err := Read(bytes.NewReader(data))
+	if !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("Expected an unexpected EOF error but got %v", err)
+	}
+}
+
+func TestReadASCIIWithLongName(t *testing.T) {
+	for _, length := range []int{2000, 100000} {
+		name := strings.Repeat("long name ", length/10)
+		input := strings.Replace(tetrahedron, "solid tetrahedron", "solid "+name, 1)
+
+		rd, err := NewReader(strings.NewReader(input))
+		if err != nil {
+			t.Fatalf("Name of %d bytes: unexpected error: %s", length, err)
+		}
+		if rd.Binary() || rd.Name() != strings.TrimSpace(name) {
+			t.Errorf("Name of %d bytes: expected an ASCII solid with the long name but got binary=%t", length, rd.Binary())
+		}
+
+		mesh, err := Read(strings.NewReader(input))
+		if err != nil {
+			t.Fatalf("Name of %d bytes: unexpected error: %s", length, err)
+		}
+		checkTetrahedron(t, "long name", mesh)
+	}
+}
+
+func TestReadBinaryCount(t *testing.T) {
+	mesh, err := Read(strings.NewReader(tetrahedron))
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	var buf bytes.Buffer
+	if err := Write(&buf, mesh); err != nil {
+		t.Fatalf("Unexpected error writing: %s", err)
+	}
+
+	// withCount returns the file with `count` triangles in its header.
+	withCount := func(count uint32) []byte {
+		data := append([]byte(nil), buf.Bytes()...)
+		binary.LittleEndian.PutUint32(data[headerSize:], count)
+		return data
+	}
+
+	// stream hides the io.Seeker of its reader.
+	type stream struct{ io.Reader }
+
+	tests := []struct {
+		description string
+		r           io.Reader
+		err         error
+	}{
+		{"exact count", bytes.NewReader(withCount(4)), nil},
+		{"exact count in a stream", stream{bytes.NewReader(withCount(4))}, nil},
+		{"zero count", bytes.NewReader(withCount(0)), ErrTrailingData},
+		{"zero count in a stream", stream{bytes.NewReader(withCount(0))}, ErrTrailingData},
+		{"small count", bytes.NewReader(withCount(3)), ErrTrailingData},
+		{"small count in a stream", stream{bytes.NewReader(withCount(3))}, ErrTrailingData},
+		{"huge count", bytes.NewReader(withCount(math.MaxUint32)), io.ErrUnexpectedEOF},
+		{"huge count in a stream", stream{bytes.NewReader(withCount(math.MaxUint32))}, io.ErrUnexpectedEOF},
+	}
+
+	for _, test := range tests {
+		_, err := Read(test.r)
+		if !errors.Is(err, test.err) {
+			t.Errorf("%s: expected error %v but got %v", test.description, test.err, err)
+		}
+	}
+
+	// Seekable files are checked before reading any triangles.
+	if _, err := NewReader(bytes.NewReader(withCount(math.MaxUint32))); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("Expected the huge count to be reported by NewReader but got %v", err)
+	}
+}
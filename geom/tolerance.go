@@ -0,0 +1,48 @@
+package geom
+
+import "math"
+
+// Tolerance decides how close to the boundary of a shape a ray has to pass
+// for hitting it. Rays passing exactly through an edge, a vertex or tangent
+// to a curved surface always hit the shape. Because of rounding errors the
+// computed boundary points may end up slightly outside the shape, so points
+// which are within a small distance of it count as hits too.
+//
+// The distance is the sum of an absolute part and a part relative to the
+// size of the shape, so that tiny and huge shapes are treated alike. What the
+// size is depends on the shape, for example the radius of a sphere or the
+// longest edge of a triangle.
+//
+// Rays which are parallel to planar shapes never hit them, even when they lie
+// in their plane. Rays at angles to the plane up to Angle are parallel too,
+// since rounding errors make their hits meaningless.
+type Tolerance struct {
+	// Absolute is a distance in world units.
+	Absolute float64
+
+	// Relative is a fraction of the size of the shape.
+	Relative float64
+
+	// Angle is an angle in radians.
+	Angle float64
+}
+
+// Epsilon returns the distance within which points are considered on the
+// boundary of a shape with size `size`.
+func (tol Tolerance) Epsilon(size float64) float64 {
+	return tol.Absolute + tol.Relative*math.Abs(size)
+}
+
+// DefaultTolerance is the tolerance of new shapes. Every shape keeps its own
+// tolerance, so changing it does not affect the shapes which already exist.
+// Use the WithTolerance methods of the shapes for changing theirs.
+var DefaultTolerance = Tolerance{Absolute: 1e-12, Relative: 1e-9, Angle: 1e-9}
+
+// Parallel tells whether a ray with direction `d` is parallel to a plane
+// with normal `n`, that is whether the ray meets the plane at an angle of at
+// most Angle. The vectors do not have to be normalized.
+func (tol Tolerance) Parallel(d, n Vector) bool {
+	dn := Dot(d, n)
+	sin := math.Sin(tol.Angle)
+	return dn*dn <= sin*sin*Dot(d, d)*Dot(n, n)
+}
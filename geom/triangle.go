@@ -0,0 +1,242 @@
+package geom
+
+import "math"
+
+// TriangleAlgorithm selects the algorithm used for intersecting rays with
+// triangles.
+type TriangleAlgorithm int
+
+const (
+	// MollerTrumbore is the Möller–Trumbore algorithm from 1997. It is
+	// fast, but rounding errors may make a ray which passes exactly
+	// through an edge shared by two triangles miss both of them.
+	MollerTrumbore TriangleAlgorithm = iota
+
+	// Watertight is the algorithm of Woop, Benthin and Wald from 2013. It
+	// is a bit slower, but a ray always hits at least one of the
+	// triangles sharing an edge or a vertex, so it can never slip through
+	// a closed mesh.
+	Watertight
+)
+
+// IntersectTriangle intersects `ray` with the triangle with vertices `a`,
+// `b` and `c` using `algorithm`. It returns the ray parameter of the
+// intersection and its barycentric coordinates in respect to `b` and `c`.
+// The last return value is false when there is no intersection with ray
+// parameter in [`tMin`, `tMax`]. Both sides of the triangle and its edges
+// count as hits, as well as points within `tol` of the edges. Degenerate
+// triangles and rays parallel to the triangle, as decided by `tol`, never do.
+func IntersectTriangle(
+	algorithm TriangleAlgorithm,
+	tol Tolerance,
+	ray Ray,
+	a, b, c Vector,
+	tMin, tMax float64,
+) (t, u, v float64, ok bool) {
+	if algorithm == Watertight {
+		return intersectWatertight(tol, ray, a, b, c, tMin, tMax)
+	}
+	return intersectMollerTrumbore(tol, ray, a, b, c, tMin, tMax)
+}
+
+func intersectMollerTrumbore(tol Tolerance, ray Ray, a, b, c Vector, tMin, tMax float64) (float64, float64, float64, bool) {
+	edge1 := Sub(b, a)
+	edge2 := Sub(c, a)
+
+	if tol.Parallel(ray.Direction, Cross(edge1, edge2)) {
+		return 0, 0, 0, false
+	}
+	s1 := Cross(ray.Direction, edge2)
+	divisor := Dot(edge1, s1)
+	invDivisor := 1 / divisor
+
+	s := Sub(ray.Origin, a)
+	u := Dot(s, s1) * invDivisor
+	s2 := Cross(s, edge1)
+	v := Dot(ray.Direction, s2) * invDivisor
+	if !withinTriangle(tol, 1-u-v, u, v, a, b, c) {
+		return 0, 0, 0, false
+	}
+
+	t := Dot(edge2, s2) * invDivisor
+	if t < tMin || t > tMax {
+		return 0, 0, 0, false
+	}
+
+	return t, u, v, true
+}
+
+// intersectWatertight implements "Watertight Ray/Triangle Intersection" by
+// Sven Woop, Carsten Benthin and Ingo Wald (2013). The vertices are moved
+// in a space where the ray starts at the origin and goes along the Z axis,
+// so the test becomes two dimensional. The 2D edge functions of an edge
+// shared by two triangles are computed from the same values in both, so
+// their signs always agree and a ray can not pass between the triangles.
+//
+// The paper recomputes edge functions which are exactly zero in higher
+// precision. All computations here are already in float64, so they are
+// used as they are.
+func intersectWatertight(tol Tolerance, ray Ray, a, b, c Vector, tMin, tMax float64) (float64, float64, float64, bool) {
+	// kz is the dimension in which the direction is largest. The other
+	// two are swapped when needed to keep the winding of the triangle.
+	kz := 0
+	for k := 1; k < 3; k++ {
+		if math.Abs(axisOf(ray.Direction, k)) > math.Abs(axisOf(ray.Direction, kz)) {
+			kz = k
+		}
+	}
+	dz := axisOf(ray.Direction, kz)
+	if dz == 0 {
+		return 0, 0, 0, false
+	}
+	kx, ky := (kz+1)%3, (kz+2)%3
+	if dz < 0 {
+		kx, ky = ky, kx
+	}
+
+	// Shear and scale of the vertices.
+	sx, sy, sz := axisOf(ray.Direction, kx)/dz, axisOf(ray.Direction, ky)/dz, 1/dz
+
+	va, vb, vc := Sub(a, ray.Origin), Sub(b, ray.Origin), Sub(c, ray.Origin)
+	ax := axisOf(va, kx) - sx*axisOf(va, kz)
+	ay := axisOf(va, ky) - sy*axisOf(va, kz)
+	bx := axisOf(vb, kx) - sx*axisOf(vb, kz)
+	by := axisOf(vb, ky) - sy*axisOf(vb, kz)
+	cx := axisOf(vc, kx) - sx*axisOf(vc, kz)
+	cy := axisOf(vc, ky) - sy*axisOf(vc, kz)
+
+	// Scaled barycentric coordinates of the hit point.
+	eu := cx*by - cy*bx
+	ev := ax*cy - ay*cx
+	ew := bx*ay - by*ax
+
+	det := eu + ev + ew
+	if det == 0 || tol.Parallel(ray.Direction, Cross(Sub(b, a), Sub(c, a))) {
+		return 0, 0, 0, false
+	}
+	if !withinTriangle(tol, eu/det, ev/det, ew/det, a, b, c) {
+		return 0, 0, 0, false
+	}
+
+	az, bz, cz := sz*axisOf(va, kz), sz*axisOf(vb, kz), sz*axisOf(vc, kz)
+	t := (eu*az + ev*bz + ew*cz) / det
+	if t < tMin || t > tMax {
+		return 0, 0, 0, false
+	}
+
+	return t, ev / det, ew / det, true
+}
+
+// withinTriangle tells whether the point with barycentric coordinates `w`,
+// `u` and `v` in respect to `a`, `b` and `c` is in the triangle or within
+// `tol` of its edges. The size of the triangle is its longest edge.
+func withinTriangle(tol Tolerance, w, u, v float64, a, b, c Vector) bool {
+	if w >= 0 && u >= 0 && v >= 0 {
+		return true
+	}
+
+	// The barycentric coordinate of a vertex is the distance to the
+	// opposite edge divided by the height to it, which is twice the area
+	// divided by the length of the edge.
+	area2 := Len(Cross(Sub(b, a), Sub(c, a)))
+	if area2 == 0 || math.IsNaN(w+u+v) {
+		return false
+	}
+	ea, eb, ec := Len(Sub(c, b)), Len(Sub(c, a)), Len(Sub(b, a))
+	eps := tol.Epsilon(math.Max(ea, math.Max(eb, ec))) / area2
+
+	return w >= -eps*ea && u >= -eps*eb && v >= -eps*ec
+}
+
+// ClosestPointOnTriangle returns the point of the triangle with vertices `a`,
+// `b` and `c` which is closest to `p`. It uses the method from "Real-Time
+// Collision Detection" by Christer Ericson, which finds the Voronoi region of
+// the triangle `p` is in. The edges of the triangle go from `a` to `b`, from
+// `b` to `c` and from `c` to `a`.
+//
+// The U and V of the result are the barycentric coordinates of the closest
+// point in respect to `b` and `c`, as in IntersectTriangle.
+func ClosestPointOnTriangle(p, a, b, c Vector) Closest {
+	ab, ac, ap := Sub(b, a), Sub(c, a), Sub(p, a)
+	d1, d2 := Dot(ab, ap), Dot(ac, ap)
+	if d1 <= 0 && d2 <= 0 {
+		return newClosest(p, a, FeatureVertex, 0, 0, 0)
+	}
+
+	bp := Sub(p, b)
+	d3, d4 := Dot(ab, bp), Dot(ac, bp)
+	if d3 >= 0 && d4 <= d3 {
+		return newClosest(p, b, FeatureVertex, 1, 1, 0)
+	}
+
+	vc := d1*d4 - d3*d2
+	if vc <= 0 && d1 >= 0 && d3 <= 0 {
+		s := d1 / (d1 - d3)
+		return newClosest(p, Add(a, Mul(ab, s)), FeatureEdge, 0, s, 0)
+	}
+
+	cp := Sub(p, c)
+	d5, d6 := Dot(ab, cp), Dot(ac, cp)
+	if d6 >= 0 && d5 <= d6 {
+		return newClosest(p, c, FeatureVertex, 2, 0, 1)
+	}
+
+	vb := d5*d2 - d1*d6
+	if vb <= 0 && d2 >= 0 && d6 <= 0 {
+		s := d2 / (d2 - d6)
+		return newClosest(p, Add(a, Mul(ac, s)), FeatureEdge, 2, 0, s)
+	}
+
+	va := d3*d6 - d5*d4
+	if va <= 0 && d4-d3 >= 0 && d5-d6 >= 0 {
+		s := (d4 - d3) / ((d4 - d3) + (d5 - d6))
+		return newClosest(p, Add(b, Mul(Sub(c, b), s)), FeatureEdge, 1, 1-s, s)
+	}
+
+	// Degenerate triangles have no inside, so the closest point is on one
+	// of their edges. Rounding errors may let p slip through the checks
+	// of their Voronoi regions above.
+	denom := va + vb + vc
+	if denom == 0 {
+		vertices := [3]Vector{a, b, c}
+		var closest Closest
+		for i, v := range vertices {
+			q, s := closestPointOnSegment(p, v, vertices[(i+1)%3])
+			if i > 0 && Len(Sub(q, p)) >= closest.Distance {
+				continue
+			}
+			var coords [3]float64
+			coords[i], coords[(i+1)%3] = 1-s, s
+			closest = newClosest(p, q, FeatureEdge, i, coords[1], coords[2])
+		}
+		return closest
+	}
+
+	v, w := vb/denom, vc/denom
+	return newClosest(p, Add(a, Add(Mul(ab, v), Mul(ac, w))), FeatureFace, 0, v, w)
+}
+
+// closestPointOnSegment returns the point of the segment from `a` to `b`
+// which is closest to `p` and its parameter along the segment, from 0 at `a`
+// to 1 at `b`.
+func closestPointOnSegment(p, a, b Vector) (Vector, float64) {
+	ab := Sub(b, a)
+	l := Dot(ab, ab)
+	if l == 0 {
+		return a, 0
+	}
+	s := math.Max(0, math.Min(1, Dot(Sub(p, a), ab)/l))
+	return Add(a, Mul(ab, s)), s
+}
+
+// solidAngle returns the signed solid angle under which the triangle with
+// vertices `a`, `b` and `c` is seen from `p`. It is positive when `p` is
+// behind the triangle, so that its vertices look ordered clockwise from there.
+// It uses the formula of Van Oosterom and Strackee.
+func solidAngle(p, a, b, c Vector) float64 {
+	a, b, c = Sub(a, p), Sub(b, p), Sub(c, p)
+	la, lb, lc := Len(a), Len(b), Len(c)
+	numerator := Dot(a, Cross(b, c))
+	denominator := la*lb*lc + Dot(a, b)*lc + Dot(a, c)*lb + Dot(b, c)*la
+	return 2 * math.Atan2(numerator, denominator)
+}
@@ -61,3 +61,21 @@ func Mul(v Vector, n float64) (result Vector) {
 	result.Z = v.Z * n
 	return
 }
+
+// Neg returns a Vector, the opposite of v.
+func Neg(v Vector) (result Vector) {
+	result.X = -v.X
+	result.Y = -v.Y
+	result.Z = -v.Z
+	return
+}
+
+// Normalize returns a Vector with the direction of v and length 1. The zero
+// vector is returned unchanged.
+func Normalize(v Vector) Vector {
+	l := Len(v)
+	if l == 0 {
+		return v
+	}
+	return Mul(v, 1/l)
+}
@@ -0,0 +1,179 @@
+package render
+
+import (
+	"math"
+	"math/rand"
+
+	"github.com/fmi/go-homework/geom"
+)
+
+// Camera generates the rays which form an image.
+type Camera interface {
+
+	// Ray returns the ray through the point (`x`, `y`) of the image. The
+	// coordinates are in pixels divided by the size of the image, so (0, 0)
+	// is the top left corner of the image and (1, 1) is its bottom right
+	// one. Cameras which need randomness, such as cameras with a lens, take
+	// it from `rnd`. They must accept a nil `rnd` and fall back to a fixed
+	// choice then.
+	Ray(x, y float64, rnd *rand.Rand) geom.Ray
+}
+
+// Jitter returns the image coordinates, as expected by Camera.Ray, of a random
+// point in pixel (`x`, `y`) of an image with size `width` x `height`. The point
+// is the center of the pixel when `rnd` is nil. Averaging many jittered
+// samples per pixel smooths the edges of objects in the image.
+func Jitter(x, y, width, height int, rnd *rand.Rand) (float64, float64) {
+	dx, dy := 0.5, 0.5
+	if rnd != nil {
+		dx, dy = rnd.Float64(), rnd.Float64()
+	}
+	return (float64(x) + dx) / float64(width), (float64(y) + dy) / float64(height)
+}
+
+// frame is the orthonormal basis of a camera at `eye`. The camera looks along
+// `forward` and `right` and `up` are the directions of the horizontal and the
+// vertical axes of its image.
+type frame struct {
+	eye, forward, right, up geom.Vector
+}
+
+// newFrame returns the frame of a camera at `eye` which looks towards `target`
+// and for which `up` appears upwards. `up` must not be parallel to
+// `target - eye`.
+func newFrame(eye, target, up geom.Vector) frame {
+	forward := geom.Normalize(geom.Sub(target, eye))
+	right := geom.Normalize(geom.Cross(forward, up))
+	return frame{
+		eye:     eye,
+		forward: forward,
+		right:   right,
+		up:      geom.Cross(right, forward),
+	}
+}
+
+// offset returns the vector which is `x` along the right axis and `y` along
+// the up axis of the frame.
+func (f frame) offset(x, y float64) geom.Vector {
+	return geom.Add(geom.Mul(f.right, x), geom.Mul(f.up, y))
+}
+
+// screen returns the coordinates on a screen with height 2 and aspect ratio
+// `aspect`, centered at the origin and with the Y axis pointing upwards, of
+// the point (`x`, `y`) of the image.
+func screen(x, y, aspect float64) (float64, float64) {
+	return (2*x - 1) * aspect, 1 - 2*y
+}
+
+// Perspective is a pinhole camera. All of its rays start at its eye, so
+// everything in front of it is in focus.
+type Perspective struct {
+	frame
+
+	// scale is the tangent of half the vertical field of view.
+	scale  float64
+	aspect float64
+}
+
+// NewPerspective returns a Perspective camera at `eye` which looks towards
+// `target`. `up` is the direction which appears upwards in the image and must
+// not be parallel to `target - eye`. `fov` is the vertical field of view in
+// radians and `aspect` is the ratio of the width to the height of the image.
+func NewPerspective(eye, target, up geom.Vector, fov, aspect float64) *Perspective {
+	return &Perspective{
+		frame:  newFrame(eye, target, up),
+		scale:  math.Tan(fov / 2),
+		aspect: aspect,
+	}
+}
+
+// Ray implements the Camera interface. The direction of the ray is of unit
+// length, so its parameter is the distance from the eye.
+func (c *Perspective) Ray(x, y float64, rnd *rand.Rand) geom.Ray {
+	return geom.NewRay(c.eye, c.direction(x, y))
+}
+
+// direction returns the unit direction from the eye towards the point (`x`,
+// `y`) of the image.
+func (c *Perspective) direction(x, y float64) geom.Vector {
+	sx, sy := screen(x, y, c.aspect)
+	return geom.Normalize(geom.Add(c.forward, c.offset(sx*c.scale, sy*c.scale)))
+}
+
+// Orthographic is a camera with parallel rays. Objects keep their size in the
+// image regardless of their distance from it.
+type Orthographic struct {
+	frame
+
+	// halfHeight is half the height of the visible area.
+	halfHeight float64
+	aspect     float64
+}
+
+// NewOrthographic returns an Orthographic camera centered at `eye` which
+// looks towards `target`. `up` is the direction which appears upwards in the
+// image and must not be parallel to `target - eye`. The camera sees a
+// rectangle with height `height` and width `height * aspect`.
+func NewOrthographic(eye, target, up geom.Vector, height, aspect float64) *Orthographic {
+	return &Orthographic{
+		frame:      newFrame(eye, target, up),
+		halfHeight: height / 2,
+		aspect:     aspect,
+	}
+}
+
+// Ray implements the Camera interface. The ray starts on the plane through
+// the eye which is perpendicular to the viewing direction. Its direction is
+// of unit length, so its parameter is the distance from this plane.
+func (c *Orthographic) Ray(x, y float64, rnd *rand.Rand) geom.Ray {
+	sx, sy := screen(x, y, c.aspect)
+	origin := geom.Add(c.eye, c.offset(sx*c.halfHeight, sy*c.halfHeight))
+	return geom.NewRay(origin, c.forward)
+}
+
+// ThinLens is a camera with a lens, which adds depth of field to the image.
+// Only the points at the focus distance from it are sharp. The farther other
+// points are from this distance, the more blurred they are.
+type ThinLens struct {
+	Perspective
+
+	// radius is the radius of the lens and focus is the distance from the
+	// eye to the plane of focus along the viewing direction.
+	radius, focus float64
+}
+
+// NewThinLens returns a ThinLens camera like the Perspective camera returned by
+// NewPerspective with the same arguments. Its lens, centered at `eye`, has
+// diameter `aperture` and focuses on the plane at distance `focus` from the
+// eye. A zero aperture makes it a pinhole camera.
+func NewThinLens(eye, target, up geom.Vector, fov, aspect, aperture, focus float64) *ThinLens {
+	return &ThinLens{
+		Perspective: *NewPerspective(eye, target, up, fov, aspect),
+		radius:      aperture / 2,
+		focus:       focus,
+	}
+}
+
+// Ray implements the Camera interface. The ray starts at a random point of
+// the lens, or at its center when `rnd` is nil, and goes through the point
+// of the plane of focus which a Perspective camera would see at (`x`, `y`).
+// The direction of the ray is of unit length.
+func (c *ThinLens) Ray(x, y float64, rnd *rand.Rand) geom.Ray {
+	dir := c.direction(x, y)
+	if rnd == nil || c.radius == 0 {
+		return geom.NewRay(c.eye, dir)
+	}
+
+	target := geom.Add(c.eye, geom.Mul(dir, c.focus/geom.Dot(dir, c.forward)))
+	lx, ly := sampleDisk(rnd.Float64(), rnd.Float64())
+	origin := geom.Add(c.eye, c.offset(lx*c.radius, ly*c.radius))
+	return geom.NewRay(origin, geom.Normalize(geom.Sub(target, origin)))
+}
+
+// sampleDisk maps the point (`u`, `v`) of the unit square to the unit disk,
+// so that uniformly distributed points stay uniformly distributed.
+func sampleDisk(u, v float64) (float64, float64) {
+	r := math.Sqrt(u)
+	s, c := math.Sincos(2 * math.Pi * v)
+	return r * c, r * s
+}
@@ -0,0 +1,122 @@
+package render
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/fmi/go-homework/geom"
+)
+
+func checkRay(t *testing.T, description string, ray geom.Ray, origin, dir geom.Vector) {
+	t.Helper()
+
+	if geom.Len(geom.Sub(ray.Origin, origin)) > 1e-12 {
+		t.Errorf("%s: expected the ray to start at %v but it started at %v", description, origin, ray.Origin)
+	}
+	if geom.Len(geom.Sub(ray.Direction, dir)) > 1e-12 {
+		t.Errorf("%s: expected direction %v but it was %v", description, dir, ray.Direction)
+	}
+}
+
+func TestCameraCorners(t *testing.T) {
+	eye, target := geom.NewVector(1, 2, 3), geom.NewVector(1, 2, -7)
+	up := geom.NewVector(0, 1, 0)
+	forward := geom.NewVector(0, 0, -1)
+
+	perspective := NewPerspective(eye, target, up, math.Pi/2, 2)
+	narrow := NewPerspective(eye, target, geom.NewVector(1, 0, 0), math.Pi/3, 1)
+	orthographic := NewOrthographic(eye, target, up, 4, 1.5)
+	lens := NewThinLens(eye, target, up, math.Pi/2, 2, 0.5, 10)
+
+	tan := math.Tan(math.Pi / 6)
+
+	tests := []struct {
+		description string
+		camera      Camera
+		x, y        float64
+		origin, dir geom.Vector
+	}{
+		{"perspective center", perspective, 0.5, 0.5, eye, forward},
+		{"perspective top left", perspective, 0, 0, eye, geom.Normalize(geom.NewVector(-2, 1, -1))},
+		{"perspective top right", perspective, 1, 0, eye, geom.Normalize(geom.NewVector(2, 1, -1))},
+		{"perspective bottom left", perspective, 0, 1, eye, geom.Normalize(geom.NewVector(-2, -1, -1))},
+		{"perspective bottom right", perspective, 1, 1, eye, geom.Normalize(geom.NewVector(2, -1, -1))},
+		{"perspective right edge", perspective, 1, 0.5, eye, geom.Normalize(geom.NewVector(2, 0, -1))},
+		{"rolled top left", narrow, 0, 0, eye, geom.Normalize(geom.NewVector(tan, tan, -1))},
+		{"rolled bottom left", narrow, 0, 1, eye, geom.Normalize(geom.NewVector(-tan, tan, -1))},
+		{"orthographic center", orthographic, 0.5, 0.5, eye, forward},
+		{"orthographic top left", orthographic, 0, 0, geom.NewVector(-2, 4, 3), forward},
+		{"orthographic bottom right", orthographic, 1, 1, geom.NewVector(4, 0, 3), forward},
+		{"lens without randomness", lens, 1, 1, eye, geom.Normalize(geom.NewVector(2, -1, -1))},
+	}
+
+	for _, test := range tests {
+		checkRay(t, test.description, test.camera.Ray(test.x, test.y, nil), test.origin, test.dir)
+	}
+}
+
+func TestThinLens(t *testing.T) {
+	eye, target := geom.NewVector(0, 0, 0), geom.NewVector(0, 0, 10)
+	up := geom.NewVector(0, 1, 0)
+	lens := NewThinLens(eye, target, up, math.Pi/2, 1, 0.5, 4)
+	pinhole := NewPerspective(eye, target, up, math.Pi/2, 1)
+	rnd := rand.New(rand.NewSource(5))
+
+	// Rays through a point of the image start on the lens and meet on the
+	// plane of focus where the pinhole ray crosses it.
+	for _, p := range [][2]float64{{0.5, 0.5}, {0, 0}, {1, 0.25}, {0.3, 0.9}} {
+		center := pinhole.Ray(p[0], p[1], nil)
+		focus := center.At(4 / center.Direction.Z)
+
+		spread := 0.0
+		for i := 0; i < 100; i++ {
+			ray := lens.Ray(p[0], p[1], rnd)
+			if r := geom.Len(ray.Origin); ray.Origin.Z != 0 || r > 0.25+1e-12 {
+				t.Fatalf("Expected the ray to start on the lens but it started at %v", ray.Origin)
+			}
+			spread = math.Max(spread, geom.Len(ray.Origin))
+
+			if l := geom.Len(ray.Direction); math.Abs(l-1) > 1e-12 {
+				t.Errorf("Expected a unit direction but its length was %g", l)
+			}
+			if at := ray.At((4 - ray.Origin.Z) / ray.Direction.Z); geom.Len(geom.Sub(at, focus)) > 1e-9 {
+				t.Errorf("Expected the ray through %v to cross the plane of focus at %v but it crossed it at %v", p, focus, at)
+			}
+		}
+		if spread < 0.2 {
+			t.Errorf("Expected the rays to start all over the lens, but they were within %g of its center", spread)
+		}
+	}
+
+	closed := NewThinLens(eye, target, up, math.Pi/2, 1, 0, 4)
+	checkRay(t, "zero aperture", closed.Ray(0.2, 0.7, rnd), eye, pinhole.Ray(0.2, 0.7, nil).Direction)
+}
+
+func TestJitter(t *testing.T) {
+	if x, y := Jitter(3, 1, 4, 2, nil); x != 3.5/4 || y != 1.5/2 {
+		t.Errorf("Expected the center of the pixel without randomness but it was (%g, %g)", x, y)
+	}
+
+	rnd := rand.New(rand.NewSource(7))
+	var sumX, sumY float64
+	for i := 0; i < 1000; i++ {
+		x, y := Jitter(3, 1, 4, 2, rnd)
+		if x < 0.75 || x >= 1 || y < 0.5 || y >= 1 {
+			t.Fatalf("Expected (%g, %g) to be in the pixel", x, y)
+		}
+		sumX, sumY = sumX+x, sumY+y
+	}
+	if math.Abs(sumX/1000-3.5/4) > 0.01 || math.Abs(sumY/1000-1.5/2) > 0.02 {
+		t.Errorf("Expected the samples to be spread around the center of the pixel")
+	}
+
+	a, b := rand.New(rand.NewSource(1)), rand.New(rand.NewSource(1))
+	for i := 0; i < 10; i++ {
+		x1, y1 := Jitter(0, 0, 10, 10, a)
+		x2, y2 := Jitter(0, 0, 10, 10, b)
+		if x1 != x2 || y1 != y2 {
+			t.Fatalf("Expected equal seeds to produce equal samples")
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package render
+
+import (
+	"image/color"
+	"math"
+)
+
+// Color is an amount of light in linear RGB. Its components are not limited
+// to [0, 1]: a bright light can have any positive intensity.
+type Color struct {
+	R, G, B float64
+}
+
+// NewColor returns a Color with components `r`, `g` and `b`.
+func NewColor(r, g, b float64) Color {
+	return Color{R: r, G: g, B: b}
+}
+
+// Gray returns a Color with all components equal to `v`.
+func Gray(v float64) Color {
+	return Color{R: v, G: v, B: v}
+}
+
+// Add returns the sum of `c` and `d`.
+func (c Color) Add(d Color) Color {
+	return Color{R: c.R + d.R, G: c.G + d.G, B: c.B + d.B}
+}
+
+// Mul returns the component-wise product of `c` and `d`. It is used for
+// filtering light through a surface which reflects only part of it.
+func (c Color) Mul(d Color) Color {
+	return Color{R: c.R * d.R, G: c.G * d.G, B: c.B * d.B}
+}
+
+// Scale returns `c` with all components multiplied by `s`.
+func (c Color) Scale(s float64) Color {
+	return Color{R: c.R * s, G: c.G * s, B: c.B * s}
+}
+
+// Max returns the largest component of `c`.
+func (c Color) Max() float64 {
+	return math.Max(c.R, math.Max(c.G, c.B))
+}
+
+// IsBlack returns true when `c` has no light in any of its components.
+func (c Color) IsBlack() bool {
+	return c.R <= 0 && c.G <= 0 && c.B <= 0
+}
+
+// SRGB returns `c` as an opaque 8 bit color. The components are clamped to
+// [0, 1] and encoded with the sRGB transfer function, which is what image
+// viewers expect.
+func (c Color) SRGB() color.RGBA {
+	return color.RGBA{R: srgb(c.R), G: srgb(c.G), B: srgb(c.B), A: 255}
+}
+
+// srgb returns the 8 bit sRGB encoding of the linear intensity `v`.
+func srgb(v float64) uint8 {
+	v = math.Max(0, math.Min(1, v))
+	if v <= 0.0031308 {
+		v *= 12.92
+	} else {
+		v = 1.055*math.Pow(v, 1/2.4) - 0.055
+	}
+	return uint8(math.Round(v * 255))
+}
@@ -0,0 +1,122 @@
+package render
+
+import (
+	"math"
+	"math/rand"
+
+	"github.com/fmi/go-homework/geom"
+)
+
+// Light is a source of light which illuminates the objects of a scene.
+type Light interface {
+
+	// Sample returns a sample of the light which arrives at `p`, ignoring
+	// anything that may block it. Lights with an area pick a random point
+	// of it using `rnd`. They must accept a nil `rnd` and use their center
+	// then.
+	Sample(p geom.Vector, rnd *rand.Rand) LightSample
+}
+
+// LightSample describes the light which arrives at a point from one point of
+// a Light.
+type LightSample struct {
+	// Direction is the unit vector from the lit point towards the light.
+	Direction geom.Vector
+
+	// Distance is the distance to the light along Direction. It is +Inf
+	// for lights which are infinitely far away.
+	Distance float64
+
+	// Intensity is the irradiance at the lit point from this sample on a
+	// surface perpendicular to Direction.
+	Intensity Color
+}
+
+// PointLight is a light which shines equally in all directions from a single
+// point. It casts hard shadows.
+type PointLight struct {
+	Position geom.Vector
+
+	// Intensity is the irradiance at distance 1 from the light. It falls
+	// off with the square of the distance.
+	Intensity Color
+}
+
+// Sample implements the Light interface.
+func (l PointLight) Sample(p geom.Vector, rnd *rand.Rand) LightSample {
+	return pointSample(p, l.Position, l.Intensity)
+}
+
+// pointSample returns the sample of light with intensity `intensity` at
+// distance 1 from the point `position`, which arrives at `p`.
+func pointSample(p, position geom.Vector, intensity Color) LightSample {
+	d := geom.Sub(position, p)
+	l := geom.Len(d)
+	if l == 0 {
+		return LightSample{}
+	}
+	return LightSample{
+		Direction: geom.Mul(d, 1/l),
+		Distance:  l,
+		Intensity: intensity.Scale(1 / (l * l)),
+	}
+}
+
+// DirectionalLight is a light which is infinitely far away, such as the sun.
+// All of its rays are parallel and it casts hard shadows.
+type DirectionalLight struct {
+	// Direction is the direction in which the light travels. It does not
+	// have to be of unit length.
+	Direction geom.Vector
+
+	// Intensity is the irradiance of the light, which is the same
+	// everywhere.
+	Intensity Color
+}
+
+// Sample implements the Light interface.
+func (l DirectionalLight) Sample(p geom.Vector, rnd *rand.Rand) LightSample {
+	return LightSample{
+		Direction: geom.Normalize(geom.Neg(l.Direction)),
+		Distance:  math.Inf(1),
+		Intensity: l.Intensity,
+	}
+}
+
+// AreaLight is a light emitted by a parallelogram with a corner at Corner and
+// sides Edge1 and Edge2. It shines only from the side which the normal
+// Edge1 x Edge2 points to. Rendering several samples per pixel with it
+// produces soft shadows.
+type AreaLight struct {
+	Corner, Edge1, Edge2 geom.Vector
+
+	// Radiance is the light emitted by every point of the parallelogram
+	// in every direction.
+	Radiance Color
+}
+
+// Sample implements the Light interface. The sample is chosen uniformly over
+// the area of the light and its intensity accounts for the whole area, so
+// that averaging many samples gives the light from the whole parallelogram.
+func (l AreaLight) Sample(p geom.Vector, rnd *rand.Rand) LightSample {
+	u, v := 0.5, 0.5
+	if rnd != nil {
+		u, v = rnd.Float64(), rnd.Float64()
+	}
+	position := geom.Add(l.Corner, geom.Add(geom.Mul(l.Edge1, u), geom.Mul(l.Edge2, v)))
+
+	normal := geom.Cross(l.Edge1, l.Edge2)
+	area := geom.Len(normal)
+	if area == 0 {
+		return LightSample{}
+	}
+	sample := pointSample(p, position, l.Radiance.Scale(area))
+
+	// The light leaving the parallelogram is weaker at grazing angles.
+	cos := -geom.Dot(sample.Direction, normal) / area
+	if cos <= 0 {
+		return LightSample{}
+	}
+	sample.Intensity = sample.Intensity.Scale(cos)
+	return sample
+}
@@ -0,0 +1,120 @@
+package render
+
+import (
+	"math"
+
+	"github.com/fmi/go-homework/geom"
+)
+
+// Material describes how the surface of an object reflects light.
+type Material interface {
+
+	// BRDF returns the bidirectional reflectance distribution function of
+	// the surface: the fraction of the light arriving from direction `in`
+	// which is reflected towards direction `out`, per unit of solid angle.
+	// `normal` is the unit normal of the surface. All three vectors are of
+	// unit length and point away from the surface, and `in` and `out` are
+	// on the side of the surface which `normal` points to.
+	BRDF(normal, in, out geom.Vector) Color
+}
+
+// Reflector is a Material which also reflects light like a mirror, in a
+// single direction. This part of the reflection is not included in its BRDF.
+type Reflector interface {
+	Material
+
+	// Reflectance returns the fraction of light which the surface
+	// reflects like a mirror.
+	Reflectance() Color
+}
+
+// Emitter is a Material which emits light, such as the surface of a lamp.
+type Emitter interface {
+	Material
+
+	// Emission returns the light which the surface emits in every
+	// direction from the side which its geometric normal points to.
+	Emission() Color
+}
+
+// Emissive is a Material which emits light and reflects none.
+type Emissive struct {
+	Radiance Color
+}
+
+// BRDF implements the Material interface. An Emissive material reflects no
+// light, so its BRDF is always black.
+func (m Emissive) BRDF(normal, in, out geom.Vector) Color {
+	return Color{}
+}
+
+// Emission implements the Emitter interface.
+func (m Emissive) Emission() Color {
+	return m.Radiance
+}
+
+// Lambert is a matte Material which reflects light equally in all directions,
+// like chalk or paper.
+type Lambert struct {
+	// Albedo is the fraction of the arriving light which is reflected.
+	Albedo Color
+}
+
+// BRDF implements the Material interface.
+func (m Lambert) BRDF(normal, in, out geom.Vector) Color {
+	return m.Albedo.Scale(1 / math.Pi)
+}
+
+// Phong is a Material with the Blinn–Phong model. It reflects light like a
+// Lambert material and adds highlights around the mirror direction, like
+// plastic. The highlights are normalized, so that the surface reflects about
+// the same amount of light however sharp they are.
+type Phong struct {
+	// Diffuse is the albedo of the matte part of the surface.
+	Diffuse Color
+
+	// Specular is the color of the highlights and Shininess sets their
+	// size. The higher the shininess, the smaller and brighter they are.
+	Specular  Color
+	Shininess float64
+
+	// Mirror is the fraction of light which is reflected like a mirror.
+	// It makes the surface reflect the objects around it.
+	Mirror Color
+}
+
+// BRDF implements the Material interface.
+func (m Phong) BRDF(normal, in, out geom.Vector) Color {
+	half := geom.Normalize(geom.Add(in, out))
+	cos := math.Max(geom.Dot(normal, half), 0)
+	highlight := (m.Shininess + 8) / (8 * math.Pi) * math.Pow(cos, m.Shininess)
+	return m.Diffuse.Scale(1 / math.Pi).Add(m.Specular.Scale(highlight))
+}
+
+// Reflectance implements the Reflector interface.
+func (m Phong) Reflectance() Color {
+	return m.Mirror
+}
+
+// Mirror is a Material which reflects light only like a perfect mirror.
+type Mirror struct {
+	// Color is the fraction of light which the mirror reflects.
+	Color Color
+}
+
+// BRDF implements the Material interface. A perfect mirror reflects light from
+// a single direction only, so its BRDF is always black.
+func (m Mirror) BRDF(normal, in, out geom.Vector) Color {
+	return Color{}
+}
+
+// Reflectance implements the Reflector interface.
+func (m Mirror) Reflectance() Color {
+	return m.Color
+}
+
+// reflect returns the mirror image of direction `d` against the surface with
+// unit normal `normal`.
+func reflect(d, normal geom.Vector) geom.Vector {
+	return geom.Sub(d, geom.Mul(normal, 2*geom.Dot(d, normal)))
+}
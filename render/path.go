@@ -0,0 +1,171 @@
+package render
+
+import (
+	"math"
+	"math/rand"
+
+	"github.com/fmi/go-homework/geom"
+)
+
+// DefaultRouletteDepth is the number of bounces after which paths traced by a
+// PathTracer which does not set its own depth may be terminated early.
+const DefaultRouletteDepth = 3
+
+// maxSurvival is the largest probability with which Russian roulette lets a
+// path continue. It keeps paths between mirrors from bouncing forever.
+const maxSurvival = 0.95
+
+// PathTracer is an Integrator which follows random paths of light bouncing
+// around the scene. Unlike Whitted, it captures all light, including the one
+// reflected between matte surfaces and the one emitted by Emissive surfaces.
+// The result of a single path is noisy, but it is right on average, so
+// averaging more samples per pixel converges to the exact image.
+//
+// At every bounce the light from the lights of the scene is added directly,
+// with a shadow ray per light, and the path continues in a direction which
+// is more likely where the cosine factor of the reflected light is larger.
+// Emissive surfaces are only found when a path happens to hit them, so small
+// bright ones make the image noisy. Use an AreaLight for them instead.
+type PathTracer struct {
+	// MaxDepth is the number of bounces after which paths end. Zero means
+	// that they end only by Russian roulette.
+	MaxDepth int
+
+	// RouletteDepth is the number of bounces after which paths carrying
+	// little light are terminated at random, with their light scaled up
+	// when they survive so that the result stays right on average. Zero
+	// means DefaultRouletteDepth.
+	RouletteDepth int
+}
+
+// Radiance implements the Integrator interface. A PathTracer needs random
+// numbers, so when `rnd` is nil it uses a source seeded with `ray`, which
+// gives the same result for the same ray.
+func (p PathTracer) Radiance(scene *Scene, ray geom.Ray, rnd *rand.Rand) Color {
+	if rnd == nil {
+		rnd = rand.New(newRaySource(ray))
+	}
+	rouletteDepth := p.RouletteDepth
+	if rouletteDepth <= 0 {
+		rouletteDepth = DefaultRouletteDepth
+	}
+
+	var result Color
+	throughput := Gray(1)
+	for depth := 0; ; depth++ {
+		hit, obj, ok := scene.ClosestHit(ray)
+		if !ok {
+			return result.Add(throughput.Mul(scene.Background))
+		}
+		if obj.Material == nil {
+			return result
+		}
+
+		out := geom.Normalize(geom.Neg(ray.Direction))
+		light := emission(hit, obj.Material).Add(scene.direct(hit, obj.Material, out, rnd))
+		result = result.Add(throughput.Mul(light))
+		if p.MaxDepth > 0 && depth >= p.MaxDepth {
+			return result
+		}
+
+		dir, weight := sampleBounce(obj.Material, hit.Normal, out, rnd)
+		throughput = throughput.Mul(weight)
+		if throughput.IsBlack() {
+			return result
+		}
+
+		if depth >= rouletteDepth {
+			survival := math.Min(throughput.Max(), maxSurvival)
+			if rnd.Float64() >= survival {
+				return result
+			}
+			throughput = throughput.Scale(1 / survival)
+		}
+
+		ray, _ = scene.leave(hit, dir)
+	}
+}
+
+// raySource is a small source of random numbers, using the SplitMix64
+// generator. Unlike the sources of math/rand, it is cheap to create for
+// every ray.
+type raySource struct {
+	state uint64
+}
+
+// newRaySource returns a raySource seeded with the origin and the direction
+// of `ray`.
+func newRaySource(ray geom.Ray) *raySource {
+	s := &raySource{}
+	for _, c := range [...]float64{ray.Origin.X, ray.Origin.Y, ray.Origin.Z, ray.Direction.X, ray.Direction.Y, ray.Direction.Z} {
+		s.state = (s.state ^ math.Float64bits(c)) * 0x9E3779B97F4A7C15
+	}
+	return s
+}
+
+// Uint64 implements the rand.Source64 interface.
+func (s *raySource) Uint64() uint64 {
+	s.state += 0x9E3779B97F4A7C15
+	z := s.state
+	z = (z ^ z>>30) * 0xBF58476D1CE4E5B9
+	z = (z ^ z>>27) * 0x94D049BB133111EB
+	return z ^ z>>31
+}
+
+// Int63 implements the rand.Source interface.
+func (s *raySource) Int63() int64 {
+	return int64(s.Uint64() >> 1)
+}
+
+// Seed implements the rand.Source interface.
+func (s *raySource) Seed(seed int64) {
+	s.state = uint64(seed)
+}
+
+// sampleBounce picks the direction in which a path continues after hitting
+// a surface with `material` and unit `normal` from direction `out`. It returns
+// the direction and the factor by which the light coming from it is scaled,
+// which accounts for the probability of picking it.
+//
+// Reflectors reflect the path like a mirror with probability equal to their
+// largest reflectance component. Otherwise the direction is picked with
+// probability proportional to its cosine with the normal.
+func sampleBounce(material Material, normal, out geom.Vector, rnd *rand.Rand) (geom.Vector, Color) {
+	mirror := 0.0
+	var reflectance Color
+	if r, ok := material.(Reflector); ok {
+		reflectance = r.Reflectance()
+		mirror = math.Max(0, math.Min(1, reflectance.Max()))
+	}
+	if mirror > 0 && rnd.Float64() < mirror {
+		return reflect(geom.Neg(out), normal), reflectance.Scale(1 / mirror)
+	}
+
+	in := sampleCosine(normal, rnd.Float64(), rnd.Float64())
+
+	// The probability density of `in` is cos/π, so the cosine cancels.
+	weight := material.BRDF(normal, in, out).Scale(math.Pi / (1 - mirror))
+	return in, weight
+}
+
+// sampleCosine maps the point (`u`, `v`) of the unit square to a direction in
+// the hemisphere around the unit vector `normal`, so that uniformly
+// distributed points give directions distributed proportionally to their
+// cosine with the normal.
+func sampleCosine(normal geom.Vector, u, v float64) geom.Vector {
+	x, y := sampleDisk(u, v)
+	z := math.Sqrt(math.Max(0, 1-x*x-y*y))
+	tangent, bitangent := orthonormalBasis(normal)
+	return geom.Add(geom.Mul(normal, z), geom.Add(geom.Mul(tangent, x), geom.Mul(bitangent, y)))
+}
+
+// orthonormalBasis returns two unit vectors which are perpendicular to each
+// other and to the unit vector `n`.
+func orthonormalBasis(n geom.Vector) (geom.Vector, geom.Vector) {
+	helper := geom.NewVector(1, 0, 0)
+	if math.Abs(n.X) > 0.9 {
+		helper = geom.NewVector(0, 1, 0)
+	}
+	tangent := geom.Normalize(geom.Cross(helper, n))
+	return tangent, geom.Cross(n, tangent)
+}
@@ -0,0 +1,229 @@
+package render
+
+import (
+	"context"
+	"errors"
+	"image"
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/fmi/go-homework/geom"
+	"github.com/fmi/go-homework/geom/sdf"
+)
+
+// glowing is a matte material which also emits light.
+type glowing struct {
+	Lambert
+	emission Color
+}
+
+func (m glowing) Emission() Color {
+	return m.emission
+}
+
+// furnace returns a scene made of the inside of a unit sphere with material
+// `material`. Its surface faces the center.
+func furnace(material Material) *Scene {
+	inside := sdf.Func(func(p geom.Vector) float64 {
+		return 1 - geom.Len(p)
+	})
+	return &Scene{Objects: []Object{{sdf.NewShape(inside, cube(1)), material}}}
+}
+
+func cube(half float64) geom.AABB {
+	return geom.NewAABB(geom.NewVector(-half, -half, -half), geom.NewVector(half, half, half))
+}
+
+func TestSampleCosine(t *testing.T) {
+	rnd := rand.New(rand.NewSource(8))
+	normal := geom.Normalize(geom.NewVector(1, -2, 0.5))
+
+	const n = 100000
+	var sum, sum2 float64
+	for i := 0; i < n; i++ {
+		d := sampleCosine(normal, rnd.Float64(), rnd.Float64())
+		if l := geom.Len(d); math.Abs(l-1) > 1e-9 {
+			t.Fatalf("Expected a unit direction but its length was %g", l)
+		}
+		cos := geom.Dot(d, normal)
+		if cos < 0 {
+			t.Fatalf("Expected %v to be in the hemisphere around the normal", d)
+		}
+		sum, sum2 = sum+cos, sum2+cos*cos
+	}
+
+	// For a density of cos/π the cosine has mean 2/3 and mean square 1/2.
+	if mean := sum / n; math.Abs(mean-2.0/3) > 0.005 {
+		t.Errorf("Expected the mean cosine to be 2/3 but it was %g", mean)
+	}
+	if mean := sum2 / n; math.Abs(mean-0.5) > 0.005 {
+		t.Errorf("Expected the mean square cosine to be 1/2 but it was %g", mean)
+	}
+}
+
+func TestPathTracer(t *testing.T) {
+	floor := halfSpace(geom.NewVector(0, 0, 1), geom.Vector{})
+	ceiling := halfSpace(geom.NewVector(0, 0, -1), geom.NewVector(0, 0, 2))
+	down := geom.NewRay(geom.NewVector(0.3, 0.2, 1), geom.NewVector(0, 0, -1))
+	rnd := rand.New(rand.NewSource(9))
+
+	// average returns the average of `n` paths along `ray`.
+	average := func(tracer PathTracer, scene *Scene, ray geom.Ray, n int) Color {
+		var sum Color
+		for i := 0; i < n; i++ {
+			sum = sum.Add(tracer.Radiance(scene, ray, rnd))
+		}
+		return sum.Scale(1 / float64(n))
+	}
+
+	// Paths leaving a matte floor always hit an emitting ceiling, which
+	// lights it with its full radiance.
+	lamp := &Scene{Objects: []Object{
+		{floor, Lambert{NewColor(0.5, 0.25, 1)}},
+		{ceiling, Emissive{Gray(2)}},
+	}}
+	checkColor(t, "emissive ceiling", average(PathTracer{}, lamp, down, 100), NewColor(1, 0.5, 2), 1e-9)
+
+	// Paths which escape from a lone floor carry no light, so only the
+	// light found directly from the lights is left.
+	light := PointLight{geom.NewVector(1, 0, 3), Gray(10)}
+	lone := &Scene{
+		Objects: []Object{{floor, Lambert{Gray(0.7)}}},
+		Lights:  []Light{light},
+	}
+	direct := Whitted{}.Radiance(lone, down, nil)
+	checkColor(t, "direct light", PathTracer{}.Radiance(lone, down, rnd), direct, 1e-12)
+
+	// A sphere over the floor reflects more light on it.
+	lit := &Scene{
+		Objects: []Object{
+			{floor, Lambert{Gray(0.7)}},
+			{sphere(geom.NewVector(-1, 0, 1.5), 0.5), Lambert{Gray(0.9)}},
+		},
+		Lights: []Light{light},
+	}
+	if indirect := average(PathTracer{}, lit, down, 2000); indirect.R <= direct.R*1.01 {
+		t.Errorf("Expected the light reflected by the sphere to brighten the floor, but it was %v and the direct light %v", indirect, direct)
+	}
+
+	// Inside a glowing sphere every bounce adds the emission, scaled by
+	// the albedo of the surface, so the light adds up to E / (1 - albedo).
+	glow := furnace(glowing{Lambert{Gray(0.5)}, Gray(1)})
+	center := geom.NewRay(geom.Vector{}, geom.NewVector(0, 1, 0))
+	checkColor(t, "glowing furnace with a single bounce", PathTracer{MaxDepth: 1}.Radiance(glow, center, rnd), Gray(1.5), 1e-9)
+	checkColor(t, "glowing furnace with two bounces", PathTracer{MaxDepth: 2}.Radiance(glow, center, rnd), Gray(1.75), 1e-9)
+	checkColor(t, "glowing furnace", average(PathTracer{}, glow, center, 20000), Gray(2), 0.03)
+
+	// Mirrors are sampled in proportion to their reflectance.
+	sky := &Scene{
+		Objects:    []Object{{floor, Phong{Diffuse: Gray(0.2), Mirror: Gray(0.6)}}},
+		Background: Gray(1),
+	}
+	checkColor(t, "mirror", average(PathTracer{}, sky, down, 20000), Gray(0.8), 0.02)
+}
+
+func TestPathTracerSingleSample(t *testing.T) {
+	// Paths from a half mirror under a bright sky are either reflected into
+	// the sky or absorbed, so independent paths light half of the pixels.
+	scene := &Scene{
+		Objects:    []Object{{halfSpace(geom.NewVector(0, 0, 1), geom.Vector{}), Phong{Mirror: Gray(0.5)}}},
+		Background: Gray(1),
+	}
+	camera := NewPerspective(geom.NewVector(0, 0, 2), geom.NewVector(0, 0, 0), geom.NewVector(0, 1, 0), 1, 1)
+
+	img, err := RenderScene(context.Background(), camera, scene, PathTracer{}, 8, 8, Options{Seed: 3, Workers: 2})
+	if err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+	lit := 0
+	for i := 0; i < len(img.Pix); i += 4 {
+		if img.Pix[i] > 0 {
+			lit++
+		}
+	}
+	if lit < 16 || lit > 48 {
+		t.Errorf("Expected the paths of the pixels to be independent, but %d of 64 pixels are lit", lit)
+	}
+
+	// Without a source of random numbers the result is finite and the same
+	// for the same ray.
+	ray := geom.NewRay(geom.NewVector(0.1, 0.2, 0.5), geom.NewVector(0, 0, -1))
+	c := PathTracer{}.Radiance(scene, ray, nil)
+	for _, v := range [...]float64{c.R, c.G, c.B} {
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			t.Fatalf("Expected a finite result without a source of random numbers but got %v", c)
+		}
+	}
+	if again := (PathTracer{}).Radiance(scene, ray, nil); again != c {
+		t.Errorf("Expected the same result for the same ray without a source but got %v and %v", c, again)
+	}
+}
+
+func TestRenderProgressive(t *testing.T) {
+	scene := &Scene{
+		Objects: []Object{
+			{halfSpace(geom.NewVector(0, 0, 1), geom.Vector{}), Lambert{Gray(0.7)}},
+			{sphere(geom.NewVector(0, 0, 1), 1), glowing{Lambert{NewColor(0.8, 0.3, 0.3)}, Gray(0.1)}},
+		},
+		Lights: []Light{
+			AreaLight{geom.NewVector(-2, -1, 4), geom.NewVector(0, 2, 0), geom.NewVector(1, 0, 0), Gray(3)},
+		},
+		Background: Gray(0.1),
+	}
+	camera := NewPerspective(geom.NewVector(0, -5, 3), geom.NewVector(0, 0, 0.5), geom.NewVector(0, 0, 1), 1, 1)
+
+	// deviation returns the average difference of the pixels of `img` from
+	// the ones of `reference`.
+	deviation := func(img, reference *image.RGBA) float64 {
+		var sum float64
+		for i := range img.Pix {
+			sum += math.Abs(float64(img.Pix[i]) - float64(reference.Pix[i]))
+		}
+		return sum / float64(len(img.Pix))
+	}
+
+	var snapshots []*image.RGBA
+	opts := Options{Seed: 4, TileSize: 6, Workers: 3}
+	img, err := RenderProgressive(context.Background(), camera, scene, PathTracer{}, 16, 16, 32, opts, func(pass int, img *image.RGBA) error {
+		if pass != len(snapshots)+1 {
+			t.Errorf("Expected pass %d but it was %d", len(snapshots)+1, pass)
+		}
+		snapshots = append(snapshots, img)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+	if len(snapshots) != 32 || snapshots[31] != img {
+		t.Fatalf("Expected 32 snapshots ending with the final image but there were %d", len(snapshots))
+	}
+	if first, later := deviation(snapshots[0], img), deviation(snapshots[7], img); later >= first/2 {
+		t.Errorf("Expected the image to converge, but its deviation went from %g to %g", first, later)
+	}
+
+	opts.Workers = 1
+	single, err := RenderProgressive(context.Background(), camera, scene, PathTracer{}, 16, 16, 32, opts, nil)
+	if err != nil || string(single.Pix) != string(img.Pix) {
+		t.Errorf("Expected the image rendered with a single worker to be the same, got error %v", err)
+	}
+
+	stop := errors.New("stop")
+	passes := 0
+	partial, err := RenderProgressive(context.Background(), camera, scene, PathTracer{}, 16, 16, 16, opts, func(pass int, img *image.RGBA) error {
+		passes = pass
+		if pass == 3 {
+			return stop
+		}
+		return nil
+	})
+	if err != stop || passes != 3 || string(partial.Pix) != string(snapshots[2].Pix) {
+		t.Errorf("Expected the snapshot error to stop rendering after 3 passes, got %v after %d", err, passes)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if img, err := RenderProgressive(ctx, camera, scene, PathTracer{}, 16, 16, 4, opts, nil); img != nil || err != context.Canceled {
+		t.Errorf("Expected a cancelled context to stop rendering, but got %v", err)
+	}
+}
@@ -0,0 +1,59 @@
+package render
+
+import (
+	"context"
+	"image"
+)
+
+// RenderProgressive renders `scene` like RenderScene, but in `passes` passes of
+// opts.Samples random samples per pixel each. After every pass it calls
+// `snapshot` with the number of finished passes and the image averaged over
+// all of them, so that the image can be watched while it converges, for
+// example by writing it with WritePNG. The calls are made from the goroutine
+// which called RenderProgressive and `snapshot` may keep the images.
+//
+// Every pass uses its own seeds, derived from opts.Seed, and the images are
+// the same for any number of workers. Unlike RenderScene, the samples are
+// random even with a single sample per pass.
+//
+// RenderProgressive returns the image after the last pass. It stops early
+// when `ctx` is cancelled or when `snapshot` returns an error, and returns the
+// image after the last finished pass, nil if there is none, with the error.
+func RenderProgressive(ctx context.Context, camera Camera, scene *Scene, integrator Integrator, width, height, passes int, opts Options, snapshot func(pass int, img *image.RGBA) error) (*image.RGBA, error) {
+	if width < 0 || height < 0 {
+		width, height = 0, 0
+	}
+
+	var img *image.RGBA
+	sum := make([]Color, width*height)
+	samples := 0
+	for pass := 0; pass < passes; pass++ {
+		passOpts := opts
+		passOpts.Seed = passSeed(opts.Seed, pass)
+		pixels, err := renderColors(ctx, camera, scene, integrator, width, height, passOpts, true)
+		if err != nil {
+			return img, err
+		}
+
+		for i, c := range pixels {
+			sum[i] = sum[i].Add(c)
+		}
+		samples += opts.samples()
+		img = toImage(sum, width, height, 1/float64(samples))
+
+		if snapshot != nil {
+			if err := snapshot(pass+1, img); err != nil {
+				return img, err
+			}
+		}
+	}
+	return img, nil
+}
+
+// passSeed returns the seed of the pass with index `pass` of a progressive
+// rendering with seed `seed`.
+func passSeed(seed int64, pass int) int64 {
+	// Use a different multiplier from tileSeed, so that the seeds of the
+	// tiles of different passes are not related.
+	return seed + int64(uint64(pass)*0x9E3779B97F4A7C15)
+}
@@ -0,0 +1,167 @@
+/*
+Package render turns objects in the 3D space into images by tracing rays
+through the pixels.
+
+Render and RenderContext produce depth-shaded images, which are meant for
+inspecting geometry. RenderScene shades a Scene of objects with materials and
+lights with an Integrator: Whitted for direct lighting and mirror reflections
+or PathTracer for global illumination. RenderProgressive shows how the noisy
+images of a PathTracer converge.
+
+All images depend only on their inputs, including the seed of the random
+numbers, so the same scene always produces the same image and can be compared
+with reference images in tests.
+*/
+package render
+
+import (
+	"context"
+	"image"
+	"image/png"
+	"io"
+	"math"
+	"math/rand"
+
+	"github.com/fmi/go-homework/geom"
+)
+
+const (
+	// nearShade and farShade are the gray levels of the nearest and the
+	// farthest hit in a depth-shaded image. The farthest hits are kept
+	// brighter than the black background.
+	nearShade = 255
+	farShade  = 64
+)
+
+// Render is like RenderContext with a background context and the default
+// Options. It renders a single sample per pixel on all available CPUs.
+func Render(camera Camera, objects []geom.Intersectable, width, height int) *image.Gray {
+	img, _ := RenderContext(context.Background(), camera, objects, width, height, Options{})
+	return img
+}
+
+// RenderContext traces rays from `camera` through every pixel of an image
+// with size `width` x `height` and returns the result as a grayscale image.
+// The background is black. The aspect ratio of the camera should match the
+// one of the image, or the image will be stretched.
+//
+// Objects which are geom.Intersectors are depth-shaded: the pixel where the
+// nearest hit in the image is found is white and the shade gets darker
+// linearly with the distance along the ray. Objects which can only report
+// whether a ray intersects them have no depth, so their pixels are always
+// white, as if they were in front of everything else. Rendering a scene
+// without Intersectors thus produces a hit/miss mask.
+//
+// With a single sample per pixel the ray goes through the center of the pixel
+// and the center of the lens of the camera. With more samples the shades of
+// the samples are averaged, which smooths the edges of objects. The image is
+// split into tiles which are rendered concurrently, as described by `opts`,
+// and is the same for any number of workers. `camera` and `objects` must be
+// safe for concurrent use. When `ctx` is cancelled before the image is done,
+// RenderContext returns a nil image and the error of `ctx`.
+func RenderContext(ctx context.Context, camera Camera, objects []geom.Intersectable, width, height int, opts Options) (*image.Gray, error) {
+	if width < 0 || height < 0 {
+		width, height = 0, 0
+	}
+	samples := opts.samples()
+	pixels := make([]depthPixel, width*height)
+
+	err := renderTiles(ctx, width, height, opts, func(tile image.Rectangle, rnd *rand.Rand) {
+		var sampler *rand.Rand
+		if samples > 1 {
+			sampler = rnd
+		}
+		for y := tile.Min.Y; y < tile.Max.Y; y++ {
+			for x := tile.Min.X; x < tile.Max.X; x++ {
+				p := &pixels[y*width+x]
+				for i := 0; i < samples; i++ {
+					u, v := Jitter(x, y, width, height, sampler)
+					p.add(trace(camera.Ray(u, v, sampler), objects))
+				}
+			}
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	near, far := math.Inf(1), math.Inf(-1)
+	for _, p := range pixels {
+		if p.hits > 0 {
+			d := p.depth / float64(p.hits)
+			near, far = math.Min(near, d), math.Max(far, d)
+		}
+	}
+
+	img := image.NewGray(image.Rect(0, 0, width, height))
+	for i, p := range pixels {
+		img.Pix[i] = p.shade(near, far, samples)
+	}
+	return img, nil
+}
+
+// WritePNG encodes `img` in the PNG format and writes it to `w`. The encoder
+// settings are fixed, so equal images are always encoded to the same bytes.
+func WritePNG(w io.Writer, img image.Image) error {
+	encoder := png.Encoder{CompressionLevel: png.DefaultCompression}
+	return encoder.Encode(w, img)
+}
+
+// trace returns the distance along `ray` to the nearest of `objects` which
+// it hits. The distance is +Inf when nothing is hit and zero when an object
+// without hit records is hit.
+func trace(ray geom.Ray, objects []geom.Intersectable) float64 {
+	depth := math.Inf(1)
+	for _, obj := range objects {
+		if _, ok := obj.(geom.Intersector); !ok {
+			if obj.Intersect(ray) {
+				return 0
+			}
+			continue
+		}
+		if hit, ok := geom.ClosestHit(obj, ray, 0, depth); ok {
+			depth = hit.T
+		}
+	}
+	return depth
+}
+
+// depthPixel accumulates the samples of a pixel of a depth-shaded image.
+type depthPixel struct {
+	// masked is the number of samples which hit objects without depth and
+	// hits is the number of the ones with depth, at total distance depth.
+	masked, hits int
+	depth        float64
+}
+
+// add adds a sample at distance `d`, as returned by trace.
+func (p *depthPixel) add(d float64) {
+	switch {
+	case math.IsInf(d, 1):
+	case d == 0:
+		p.masked++
+	default:
+		p.hits++
+		p.depth += d
+	}
+}
+
+// shade returns the gray level of the pixel, when it has `samples` samples
+// and the hits in the image are between `near` and `far`. The samples which
+// hit an object at all are shaded at their average distance.
+func (p *depthPixel) shade(near, far float64, samples int) uint8 {
+	s := float64(p.masked) * nearShade
+	if p.hits > 0 {
+		s += float64(p.hits) * shade(p.depth/float64(p.hits), near, far)
+	}
+	return uint8(math.Round(s / float64(samples)))
+}
+
+// shade returns the gray level of a hit at distance `d` along its ray, when
+// the hits in the image are between `near` and `far`.
+func shade(d, near, far float64) float64 {
+	if far <= near {
+		return nearShade
+	}
+	return nearShade - (d-near)/(far-near)*(nearShade-farShade)
+}
@@ -0,0 +1,126 @@
+package render
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/fmi/go-homework/geom"
+	"github.com/fmi/go-homework/geom/sdf"
+)
+
+// mask hides everything but the Intersect method of an object.
+type mask struct {
+	obj geom.Intersectable
+}
+
+func (m mask) Intersect(ray geom.Ray) bool {
+	return m.obj.Intersect(ray)
+}
+
+func sphere(center geom.Vector, r float64) *sdf.Shape {
+	half := geom.NewVector(r, r, r)
+	return sdf.NewShape(sdf.Translate(sdf.Sphere(r), center), geom.NewAABB(geom.Sub(center, half), geom.Add(center, half)))
+}
+
+// ascii draws `img` with a `#` for every lit pixel and a `.` for every black
+// one.
+func ascii(img *image.Gray) string {
+	var b strings.Builder
+	for y := 0; y < img.Rect.Dy(); y++ {
+		for x := 0; x < img.Rect.Dx(); x++ {
+			if img.GrayAt(x, y).Y > 0 {
+				b.WriteByte('#')
+			} else {
+				b.WriteByte('.')
+			}
+		}
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+func TestRenderMask(t *testing.T) {
+	camera := NewPerspective(geom.NewVector(0, 0, 5), geom.NewVector(0, 0, 0), geom.NewVector(0, 1, 0), math.Pi/2, 2)
+	objects := []geom.Intersectable{
+		mask{sphere(geom.NewVector(0, 0, 0), 2)},
+		mask{sphere(geom.NewVector(6, 3, 0), 1)},
+	}
+
+	expected := `
+................
+............##..
+.......##.......
+......####......
+......####......
+.......##.......
+................
+................
+`[1:]
+	img := Render(camera, objects, 16, 8)
+	if got := ascii(img); got != expected {
+		t.Errorf("Expected the mask\n%s\nbut it was\n%s", expected, got)
+	}
+	for i, p := range img.Pix {
+		if p != 0 && p != 255 {
+			t.Fatalf("Expected only black and white pixels in a mask, but pixel %d is %d", i, p)
+		}
+	}
+}
+
+func TestRenderDepth(t *testing.T) {
+	camera := NewPerspective(geom.NewVector(0, 0, 5), geom.NewVector(0, 0, 0), geom.NewVector(0, 1, 0), math.Pi/2, 2)
+	near := sphere(geom.NewVector(-2, 0, 0), 1)
+	far := sphere(geom.NewVector(2, 0, -5), 1)
+
+	img := Render(camera, []geom.Intersectable{far, near}, 64, 32)
+	nearShade, farShade := img.GrayAt(25, 15).Y, img.GrayAt(35, 15).Y
+	if nearShade != 255 {
+		t.Errorf("Expected the nearest point to be white but it was %d", nearShade)
+	}
+	if farShade == 0 || farShade >= nearShade {
+		t.Errorf("Expected the far sphere to be darker than the near one, but they were %d and %d", farShade, nearShade)
+	}
+	if background := img.GrayAt(0, 0).Y; background != 0 {
+		t.Errorf("Expected a black background but it was %d", background)
+	}
+
+	// Objects without hit records are drawn over everything else.
+	img = Render(camera, []geom.Intersectable{far, mask{far}, near}, 64, 32)
+	if shade := img.GrayAt(35, 15).Y; shade != 255 {
+		t.Errorf("Expected a masked object to be white but it was %d", shade)
+	}
+
+	if empty := Render(camera, nil, 0, 10); empty.Rect.Dx() != 0 {
+		t.Errorf("Expected an empty image but it was %v", empty.Rect)
+	}
+}
+
+func TestWritePNG(t *testing.T) {
+	camera := NewPerspective(geom.NewVector(3, 4, 5), geom.NewVector(0, 0, 0), geom.NewVector(0, 0, 1), 1, 4.0/3)
+	objects := []geom.Intersectable{sphere(geom.NewVector(0, 0, 0), 2), sphere(geom.NewVector(1, 0, 1.5), 1)}
+
+	var first, second bytes.Buffer
+	img := Render(camera, objects, 40, 30)
+	if err := WritePNG(&first, img); err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+	if err := WritePNG(&second, Render(camera, objects, 40, 30)); err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+	if !bytes.Equal(first.Bytes(), second.Bytes()) {
+		t.Errorf("Expected rendering the same scene twice to produce the same file")
+	}
+
+	decoded, err := png.Decode(&first)
+	if err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+	gray, ok := decoded.(*image.Gray)
+	if !ok || !bytes.Equal(gray.Pix, img.Pix) || gray.Rect != img.Rect {
+		t.Errorf("Expected the decoded image to match the rendered one")
+	}
+}
@@ -0,0 +1,230 @@
+package render
+
+import (
+	"context"
+	"image"
+	"math"
+	"math/rand"
+
+	"github.com/fmi/go-homework/geom"
+)
+
+// DefaultOffset is the tolerance used by scenes which do not set their own.
+var DefaultOffset = geom.Tolerance{Absolute: 1e-4, Relative: 1e-6}
+
+// Object is a shape in a scene together with the material of its surface.
+// Any geom.Intersectable can be a shape, but only geom.Intersectors report
+// where rays hit them, so only they are visible. The others still cast
+// shadows.
+type Object struct {
+	Shape geom.Intersectable
+
+	// Material is the material of the surface of the shape. Shapes without
+	// a material absorb all light which arrives at them.
+	Material Material
+}
+
+// Scene is a collection of objects and the lights which illuminate them. A
+// Scene is safe for concurrent use as long as its objects are, which holds
+// for all geom.Intersectables.
+type Scene struct {
+	Objects []Object
+	Lights  []Light
+
+	// Background is the light coming from the directions in which there
+	// are no objects.
+	Background Color
+
+	// Offset is the distance from the surface of the shapes at which rays
+	// leaving them, such as shadow rays and reflected rays, start. It
+	// keeps such rays from hitting the surface they leave because of
+	// rounding errors. The relative part of it is multiplied by the
+	// distance of the surface point from the origin. The zero value means
+	// DefaultOffset.
+	Offset geom.Tolerance
+}
+
+// ClosestHit returns the closest intersection of `ray` with the objects of
+// the scene and the object which it hit. Its last return value is false when
+// the ray hits nothing.
+func (s *Scene) ClosestHit(ray geom.Ray) (geom.Hit, *Object, bool) {
+	var closest geom.Hit
+	var object *Object
+	tMax := math.Inf(1)
+	for i := range s.Objects {
+		if hit, ok := geom.ClosestHit(s.Objects[i].Shape, ray, 0, tMax); ok {
+			closest, object, tMax = hit, &s.Objects[i], hit.T
+		}
+	}
+	return closest, object, object != nil
+}
+
+// Occluded returns true when any object of the scene intersects `ray` with a
+// ray parameter in [0, `tMax`].
+func (s *Scene) Occluded(ray geom.Ray, tMax float64) bool {
+	for _, obj := range s.Objects {
+		if geom.Occluded(obj.Shape, ray, tMax) {
+			return true
+		}
+	}
+	return false
+}
+
+// offset returns the distance from the surface at `p` at which rays leaving
+// it start.
+func (s *Scene) offset(p geom.Vector) float64 {
+	tol := s.Offset
+	if tol == (geom.Tolerance{}) {
+		tol = DefaultOffset
+	}
+	return tol.Epsilon(geom.Len(p))
+}
+
+// leave returns the ray which leaves the surface of `hit` in direction `dir`
+// on the side of its normal, together with the offset of its origin.
+func (s *Scene) leave(hit geom.Hit, dir geom.Vector) (geom.Ray, float64) {
+	offset := s.offset(hit.Point)
+	origin := geom.Add(hit.Point, geom.Mul(hit.Normal, offset))
+	return geom.NewRay(origin, dir), offset
+}
+
+// direct returns the light from the lights of the scene which `material`
+// reflects at `hit` towards `out`. Lights blocked by objects cast shadows.
+func (s *Scene) direct(hit geom.Hit, material Material, out geom.Vector, rnd *rand.Rand) Color {
+	var result Color
+	for _, light := range s.Lights {
+		sample := light.Sample(hit.Point, rnd)
+		cos := geom.Dot(hit.Normal, sample.Direction)
+		if cos <= 0 || sample.Intensity.IsBlack() {
+			continue
+		}
+
+		shadow, offset := s.leave(hit, sample.Direction)
+		if s.Occluded(shadow, sample.Distance-offset) {
+			continue
+		}
+
+		f := material.BRDF(hit.Normal, sample.Direction, out)
+		result = result.Add(f.Mul(sample.Intensity).Scale(cos))
+	}
+	return result
+}
+
+// emission returns the light which `material` emits at `hit` towards the ray
+// which hit it.
+func emission(hit geom.Hit, material Material) Color {
+	if e, ok := material.(Emitter); ok && hit.FrontFace {
+		return e.Emission()
+	}
+	return Color{}
+}
+
+// Integrator computes the light which arrives along rays in a scene.
+type Integrator interface {
+
+	// Radiance returns the light which arrives at the origin of `ray`
+	// from the opposite of its direction. Integrators which need
+	// randomness take it from `rnd`. RenderScene and RenderProgressive
+	// always pass a source, but integrators must accept a nil `rnd` too
+	// and fall back to deterministic choices.
+	Radiance(scene *Scene, ray geom.Ray, rnd *rand.Rand) Color
+}
+
+// Whitted is an Integrator which lights surfaces only directly from the lights
+// of the scene and follows the reflections of mirror-like materials. Shadows
+// are hard, except for the ones of area lights, which are lit from a random
+// point of the light and get soft as the samples are averaged. Emissive
+// surfaces are visible, but do not light other objects.
+type Whitted struct {
+	// MaxDepth is the number of reflections which are followed. Zero turns
+	// mirror reflections off.
+	MaxDepth int
+}
+
+// Radiance implements the Integrator interface.
+func (w Whitted) Radiance(scene *Scene, ray geom.Ray, rnd *rand.Rand) Color {
+	return w.radiance(scene, ray, rnd, 0)
+}
+
+// radiance returns the light arriving along `ray`, which has been reflected
+// `depth` times already.
+func (w Whitted) radiance(scene *Scene, ray geom.Ray, rnd *rand.Rand, depth int) Color {
+	hit, obj, ok := scene.ClosestHit(ray)
+	if !ok {
+		return scene.Background
+	}
+	if obj.Material == nil {
+		return Color{}
+	}
+
+	out := geom.Normalize(geom.Neg(ray.Direction))
+	result := emission(hit, obj.Material).Add(scene.direct(hit, obj.Material, out, rnd))
+
+	if r, ok := obj.Material.(Reflector); ok && depth < w.MaxDepth {
+		if reflectance := r.Reflectance(); !reflectance.IsBlack() {
+			reflected, _ := scene.leave(hit, reflect(geom.Neg(out), hit.Normal))
+			result = result.Add(reflectance.Mul(w.radiance(scene, reflected, rnd, depth+1)))
+		}
+	}
+
+	return result
+}
+
+// RenderScene renders `scene` as seen by `camera` with `integrator` in an image
+// with size `width` x `height`. The light of the samples of every pixel is
+// averaged and converted to sRGB, with intensities above one clipped to
+// white. With a single sample per pixel the rays go through the centers of
+// the pixels, see Options.Samples. Tiles are rendered concurrently as
+// described by `opts`, and the image is the same for any number of workers.
+// When `ctx` is cancelled before the image is done, RenderScene returns a nil
+// image and the error of `ctx`.
+func RenderScene(ctx context.Context, camera Camera, scene *Scene, integrator Integrator, width, height int, opts Options) (*image.RGBA, error) {
+	if width < 0 || height < 0 {
+		width, height = 0, 0
+	}
+	pixels, err := renderColors(ctx, camera, scene, integrator, width, height, opts, opts.samples() > 1)
+	if err != nil {
+		return nil, err
+	}
+	return toImage(pixels, width, height, 1/float64(opts.samples())), nil
+}
+
+// renderColors returns the sums of the light of the samples of every pixel
+// of an image with size `width` x `height`, in rows from top to bottom. The
+// rays of the samples are random only when `random` is true, but the
+// integrator always gets the source of random numbers of the tile.
+func renderColors(ctx context.Context, camera Camera, scene *Scene, integrator Integrator, width, height int, opts Options, random bool) ([]Color, error) {
+	samples := opts.samples()
+	pixels := make([]Color, width*height)
+
+	err := renderTiles(ctx, width, height, opts, func(tile image.Rectangle, rnd *rand.Rand) {
+		rays := rnd
+		if !random {
+			rays = nil
+		}
+		for y := tile.Min.Y; y < tile.Max.Y; y++ {
+			for x := tile.Min.X; x < tile.Max.X; x++ {
+				var sum Color
+				for i := 0; i < samples; i++ {
+					u, v := Jitter(x, y, width, height, rays)
+					sum = sum.Add(integrator.Radiance(scene, camera.Ray(u, v, rays), rnd))
+				}
+				pixels[y*width+x] = sum
+			}
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return pixels, nil
+}
+
+// toImage returns the image with size `width` x `height` of `pixels`, scaled
+// by `scale`.
+func toImage(pixels []Color, width, height int, scale float64) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for i, c := range pixels {
+		img.SetRGBA(i%width, i/width, c.Scale(scale).SRGB())
+	}
+	return img
+}
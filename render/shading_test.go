@@ -0,0 +1,278 @@
+package render
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/fmi/go-homework/geom"
+	"github.com/fmi/go-homework/geom/sdf"
+)
+
+func checkColor(t *testing.T, description string, got, expected Color, tolerance float64) {
+	t.Helper()
+
+	d := got.Add(expected.Scale(-1))
+	if math.Abs(d.R) > tolerance || math.Abs(d.G) > tolerance || math.Abs(d.B) > tolerance {
+		t.Errorf("%s: expected color %v but it was %v", description, expected, got)
+	}
+}
+
+// halfSpace returns the shape of the points below the plane with normal
+// `normal` through `point`.
+func halfSpace(normal, point geom.Vector) *sdf.Shape {
+	return sdf.NewShape(sdf.Plane(normal, geom.Dot(geom.Normalize(normal), point)), geom.InfiniteAABB())
+}
+
+func TestLights(t *testing.T) {
+	p := geom.NewVector(1, 2, 3)
+	area := AreaLight{
+		Corner:   geom.NewVector(0, 0, 13),
+		Edge1:    geom.NewVector(0, 4, 0),
+		Edge2:    geom.NewVector(2, 0, 0),
+		Radiance: Gray(1),
+	}
+	rnd := rand.New(rand.NewSource(1))
+
+	tests := []struct {
+		description string
+		light       Light
+		dir         geom.Vector
+		distance    float64
+		intensity   Color
+	}{
+		{
+			"point", PointLight{geom.NewVector(1, 2, 7), NewColor(16, 32, 0)},
+			geom.NewVector(0, 0, 1), 4, NewColor(1, 2, 0),
+		},
+		{
+			"directional", DirectionalLight{geom.NewVector(0, -3, 0), Gray(2)},
+			geom.NewVector(0, 1, 0), math.Inf(1), Gray(2),
+		},
+		{
+			"area center", area,
+			geom.NewVector(0, 0, 1), 10, Gray(8.0 / 100),
+		},
+		{
+			"area back side", AreaLight{area.Corner, area.Edge2, area.Edge1, area.Radiance},
+			geom.Vector{}, 0, Color{},
+		},
+	}
+
+	for _, test := range tests {
+		sample := test.light.Sample(p, nil)
+		if geom.Len(geom.Sub(sample.Direction, test.dir)) > 1e-12 || sample.Distance != test.distance {
+			t.Errorf("%s: expected direction %v at distance %g but it was %v at %g",
+				test.description, test.dir, test.distance, sample.Direction, sample.Distance)
+		}
+		checkColor(t, test.description, sample.Intensity, test.intensity, 1e-12)
+
+		// Random samples of lights without area are all the same.
+		if _, ok := test.light.(AreaLight); !ok && test.light.Sample(p, rnd) != sample {
+			t.Errorf("%s: expected random samples to be the same as the fixed one", test.description)
+		}
+	}
+
+	// Random samples of an area light are spread over it and add up to
+	// the light of the whole area.
+	var sum Color
+	for i := 0; i < 10000; i++ {
+		sample := area.Sample(p, rnd)
+		if at := geom.Add(p, geom.Mul(sample.Direction, sample.Distance)); math.Abs(at.Z-13) > 1e-9 || at.X < 0 || at.X > 2 || at.Y < 0 || at.Y > 4 {
+			t.Fatalf("Expected the sample %v to be on the light", at)
+		}
+		sum = sum.Add(sample.Intensity)
+	}
+	// The light of the whole area, integrated over a fine grid.
+	var total float64
+	for x := 0.005; x < 2; x += 0.01 {
+		for y := 0.005; y < 4; y += 0.01 {
+			d2 := (x-1)*(x-1) + (y-2)*(y-2) + 100
+			total += 10 / (d2 * math.Sqrt(d2)) * 0.0001
+		}
+	}
+	checkColor(t, "area average", sum.Scale(1.0/10000), Gray(total), 2e-4)
+}
+
+func TestMaterials(t *testing.T) {
+	normal := geom.NewVector(0, 0, 1)
+	rnd := rand.New(rand.NewSource(2))
+
+	// The light which a material reflects from a light above it in all
+	// directions, estimated with uniform samples of the hemisphere.
+	reflected := func(m Material, in geom.Vector) Color {
+		var sum Color
+		const n = 100000
+		for i := 0; i < n; i++ {
+			z := rnd.Float64()
+			r := math.Sqrt(1 - z*z)
+			s, c := math.Sincos(2 * math.Pi * rnd.Float64())
+			out := geom.NewVector(r*c, r*s, z)
+			sum = sum.Add(m.BRDF(normal, in, out).Scale(z))
+		}
+		return sum.Scale(2 * math.Pi / n)
+	}
+
+	lambert := Lambert{NewColor(0.2, 0.5, 0.8)}
+	checkColor(t, "lambert", reflected(lambert, normal), lambert.Albedo, 0.01)
+
+	phong := Phong{Specular: Gray(1), Shininess: 50}
+	if energy := reflected(phong, normal); energy.R > 1.05 || energy.R < 0.9 {
+		t.Errorf("Expected the highlights to reflect about all the light but they reflected %g", energy.R)
+	}
+
+	// The highlight is the brightest in the mirror direction.
+	in := geom.Normalize(geom.NewVector(1, 0, 1))
+	peak := phong.BRDF(normal, in, geom.Normalize(geom.NewVector(-1, 0, 1))).R
+	for _, out := range []geom.Vector{
+		geom.NewVector(-1, 0.1, 1),
+		geom.NewVector(-1.2, 0, 1),
+		geom.NewVector(0, 0, 1),
+		geom.NewVector(1, 0, 1),
+	} {
+		if f := phong.BRDF(normal, in, geom.Normalize(out)).R; f >= peak {
+			t.Errorf("Expected the highlight towards %v to be weaker than in the mirror direction", out)
+		}
+	}
+
+	if d := reflect(geom.NewVector(1, -2, 3), geom.NewVector(0, 1, 0)); d != geom.NewVector(1, 2, 3) {
+		t.Errorf("Expected the reflection to flip the normal component but it was %v", d)
+	}
+}
+
+func TestWhittedShadows(t *testing.T) {
+	albedo := NewColor(0.5, 0.25, 1)
+	scene := &Scene{
+		Objects: []Object{
+			{halfSpace(geom.NewVector(0, 0, 1), geom.Vector{}), Lambert{albedo}},
+			{sphere(geom.NewVector(0, 0, 2), 1), Lambert{Gray(1)}},
+			{mask{sphere(geom.NewVector(5, 0, 8), 1)}, nil},
+		},
+		Lights:     []Light{PointLight{geom.NewVector(0, 0, 4), Gray(16)}},
+		Background: Gray(0.3),
+	}
+	integrator := Whitted{}
+
+	tests := []struct {
+		description string
+		ray         geom.Ray
+		expected    Color
+	}{
+		{"background", geom.NewRay(geom.NewVector(0, 0, 10), geom.NewVector(0, 1, 0)), Gray(0.3)},
+		{"shadow", geom.NewRay(geom.NewVector(0.3, -10, 10), geom.NewVector(0, 1, -1)), Color{}},
+		{
+			// The floor at (3, 0, 0) is 5 away from the light, and the
+			// light arrives at an angle with cosine 4/5.
+			"lit floor", geom.NewRay(geom.NewVector(3, 0, 10), geom.NewVector(0, 0, -1)),
+			albedo.Scale(1 / math.Pi * 16 / 25 * 4 / 5),
+		},
+		{
+			"top of the sphere", geom.NewRay(geom.NewVector(0, 0, 10), geom.NewVector(0, 0, -1)),
+			Gray(1 / math.Pi * 16),
+		},
+		{
+			// Objects without hits are invisible, but still block the
+			// light, even beyond it.
+			"masked shadow", geom.NewRay(geom.NewVector(-5, 0, 10), geom.NewVector(0, 0, -1)),
+			Color{},
+		},
+	}
+
+	for _, test := range tests {
+		checkColor(t, test.description, integrator.Radiance(scene, test.ray, nil), test.expected, 1e-4)
+	}
+}
+
+func TestWhittedReflections(t *testing.T) {
+	// Two mirrors meet at a right angle, so the ray bounces off both of
+	// them before escaping to the sky.
+	scene := &Scene{
+		Objects: []Object{
+			{halfSpace(geom.NewVector(0, 0, 1), geom.Vector{}), Mirror{Gray(0.5)}},
+			{halfSpace(geom.NewVector(1, 0, 0), geom.Vector{}), Phong{Diffuse: Gray(0.5), Mirror: NewColor(0.5, 1, 0)}},
+		},
+		Lights:     []Light{DirectionalLight{geom.NewVector(-1, 0, -1), Gray(1)}},
+		Background: Gray(1),
+	}
+	ray := geom.NewRay(geom.NewVector(2, 0, 1), geom.NewVector(-1, 0, -1))
+
+	// The floor is a perfect mirror, so only the wall is lit and it is
+	// visible only in the floor.
+	wall := 0.5 / math.Pi * math.Sqrt2 / 2
+	tests := []struct {
+		depth    int
+		expected Color
+	}{
+		{0, Color{}},
+		{1, Gray(0.5 * wall)},
+		{2, Gray(0.5 * wall).Add(NewColor(0.25, 0.5, 0))},
+		{10, Gray(0.5 * wall).Add(NewColor(0.25, 0.5, 0))},
+	}
+
+	for _, test := range tests {
+		got := Whitted{MaxDepth: test.depth}.Radiance(scene, ray, nil)
+		checkColor(t, fmt.Sprintf("depth %d", test.depth), got, test.expected, 1e-4)
+	}
+}
+
+func TestRenderScene(t *testing.T) {
+	scene := &Scene{
+		Objects: []Object{
+			{halfSpace(geom.NewVector(0, 0, 1), geom.Vector{}), Lambert{Gray(0.8)}},
+			{sphere(geom.NewVector(0, 0, 1), 1), Phong{Diffuse: NewColor(0.8, 0.1, 0.1), Specular: Gray(0.2), Shininess: 20}},
+		},
+		Lights: []Light{
+			AreaLight{geom.NewVector(-1, -1, 4), geom.NewVector(2, 0, 0), geom.NewVector(0, 2, 0), Gray(10)},
+			DirectionalLight{geom.NewVector(1, 1, -2), Gray(0.3)},
+		},
+		Background: NewColor(0.2, 0.3, 0.5),
+	}
+	camera := NewPerspective(geom.NewVector(0, -6, 3), geom.NewVector(0, 0, 1), geom.NewVector(0, 0, 1), 1, 1)
+
+	var reference []byte
+	for _, workers := range []int{1, 3} {
+		img, err := RenderScene(context.Background(), camera, scene, Whitted{MaxDepth: 2}, 24, 24, Options{Samples: 3, TileSize: 5, Workers: workers})
+		if err != nil {
+			t.Fatalf("Unexpected error %s", err)
+		}
+		if reference == nil {
+			reference = img.Pix
+		} else if !bytes.Equal(img.Pix, reference) {
+			t.Errorf("Expected the image rendered with %d workers to match the one with a single worker", workers)
+		}
+
+		if c := img.RGBAAt(0, 0); c != scene.Background.SRGB() {
+			t.Errorf("Expected the background in the corner but it was %v", c)
+		}
+		if c := img.RGBAAt(12, 12); c.R <= c.G || c.R <= c.B {
+			t.Errorf("Expected the sphere in the center to be red but it was %v", c)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if img, err := RenderScene(ctx, camera, scene, Whitted{}, 24, 24, Options{}); img != nil || err != context.Canceled {
+		t.Errorf("Expected a cancelled context to stop rendering, but got %v", err)
+	}
+}
+
+func TestSRGB(t *testing.T) {
+	tests := []struct {
+		c       Color
+		r, g, b uint8
+	}{
+		{Color{}, 0, 0, 0},
+		{Gray(1), 255, 255, 255},
+		{NewColor(2, -1, 0.5), 255, 0, 188},
+		{Gray(0.002), 7, 7, 7},
+	}
+
+	for _, test := range tests {
+		if got := test.c.SRGB(); got.R != test.r || got.G != test.g || got.B != test.b || got.A != 255 {
+			t.Errorf("Expected %v to be encoded as (%d, %d, %d) but it was %v", test.c, test.r, test.g, test.b, got)
+		}
+	}
+}
@@ -0,0 +1,155 @@
+package render
+
+import (
+	"context"
+	"image"
+	"math/rand"
+	"runtime"
+	"sync"
+)
+
+// DefaultTileSize is the width and the height in pixels of the tiles rendered
+// when Options.TileSize is not set.
+const DefaultTileSize = 32
+
+// Options configures how an image is rendered. The zero value is ready to use.
+type Options struct {
+	// Samples is the number of rays traced per pixel. With more than one
+	// sample the rays go through random points of their pixel and of the
+	// lens of the camera. With a single one they go through the center of
+	// both. The random choices of the integrator, such as the point of an
+	// area light, are random either way. Zero means a single sample.
+	Samples int
+
+	// Seed is the seed of the random numbers used for sampling. Every tile
+	// has its own source, seeded with Seed and the position of the tile,
+	// so the image does not depend on the number of workers or on the
+	// order in which tiles are rendered.
+	Seed int64
+
+	// TileSize is the width and the height of the tiles in pixels. Tiles
+	// are the units of work of the workers. Zero means DefaultTileSize.
+	TileSize int
+
+	// Workers is the number of goroutines which render tiles. Zero means
+	// one per available CPU.
+	Workers int
+
+	// Progress, when set, is called after each tile with the number of
+	// rendered tiles and the total number of tiles. The calls are never
+	// concurrent and are made from the goroutine which started rendering.
+	Progress func(done, total int)
+}
+
+// samples returns the number of samples per pixel.
+func (o Options) samples() int {
+	if o.Samples <= 0 {
+		return 1
+	}
+	return o.Samples
+}
+
+// tileSize returns the size of the tiles.
+func (o Options) tileSize() int {
+	if o.TileSize <= 0 {
+		return DefaultTileSize
+	}
+	return o.TileSize
+}
+
+// workers returns the number of goroutines which render tiles.
+func (o Options) workers() int {
+	if o.Workers <= 0 {
+		return runtime.GOMAXPROCS(0)
+	}
+	return o.Workers
+}
+
+// tiles returns the tiles which cover an image with size `width` x `height`,
+// in rows from top to bottom and from left to right within a row.
+func tiles(width, height, size int) []image.Rectangle {
+	var result []image.Rectangle
+	for y := 0; y < height; y += size {
+		for x := 0; x < width; x += size {
+			tile := image.Rect(x, y, x+size, y+size)
+			result = append(result, tile.Intersect(image.Rect(0, 0, width, height)))
+		}
+	}
+	return result
+}
+
+// tileSeed returns the seed of the random numbers of the tile with index
+// `index`.
+func tileSeed(seed int64, index int) int64 {
+	// Mix the index with the multiplier of a 64 bit LCG so that nearby
+	// tiles and nearby seeds do not get related sources.
+	return seed ^ int64(uint64(index+1)*6364136223846793005)
+}
+
+// renderTiles splits an image with size `width` x `height` into tiles and
+// calls `render` for each of them from a pool of workers. Each call gets its
+// own source of random numbers. `render` must only write the pixels of its
+// tile, so that the calls do not race. When `ctx` is cancelled before all
+// tiles are rendered, renderTiles stops early and returns its error. Tiles
+// which have started are finished first, so `render` is never running when
+// renderTiles returns.
+func renderTiles(ctx context.Context, width, height int, opts Options, render func(tile image.Rectangle, rnd *rand.Rand)) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	all := tiles(width, height, opts.tileSize())
+	indices := make(chan int)
+
+	// done receives whether each tile which was taken by a worker was
+	// rendered or skipped because of cancellation.
+	done := make(chan bool)
+
+	var wg sync.WaitGroup
+	for i := 0; i < opts.workers(); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for index := range indices {
+				if ctx.Err() != nil {
+					done <- false
+					continue
+				}
+				render(all[index], rand.New(rand.NewSource(tileSeed(opts.Seed, index))))
+				done <- true
+			}
+		}()
+	}
+
+	go func() {
+		defer close(indices)
+		for i := range all {
+			select {
+			case indices <- i:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	finished := 0
+	for rendered := range done {
+		if !rendered {
+			continue
+		}
+		finished++
+		if opts.Progress != nil {
+			opts.Progress(finished, len(all))
+		}
+	}
+
+	if finished < len(all) {
+		return ctx.Err()
+	}
+	return nil
+}
@@ -0,0 +1,151 @@
+package render
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"math"
+	"testing"
+
+	"github.com/fmi/go-homework/geom"
+)
+
+func TestTiles(t *testing.T) {
+	tests := []struct {
+		width, height, size int
+		count               int
+	}{
+		{64, 32, 32, 2},
+		{65, 32, 32, 3},
+		{10, 7, 3, 12},
+		{5, 5, 100, 1},
+		{0, 10, 4, 0},
+	}
+
+	for _, test := range tests {
+		all := tiles(test.width, test.height, test.size)
+		if len(all) != test.count {
+			t.Errorf("%dx%d: expected %d tiles of size %d but there were %d",
+				test.width, test.height, test.count, test.size, len(all))
+		}
+
+		covered := make([]int, test.width*test.height)
+		for _, tile := range all {
+			if tile.Dx() > test.size || tile.Dy() > test.size || tile.Empty() {
+				t.Errorf("%dx%d: unexpected tile %v", test.width, test.height, tile)
+			}
+			for y := tile.Min.Y; y < tile.Max.Y; y++ {
+				for x := tile.Min.X; x < tile.Max.X; x++ {
+					covered[y*test.width+x]++
+				}
+			}
+		}
+		for i, c := range covered {
+			if c != 1 {
+				t.Errorf("%dx%d: expected pixel %d to be in a single tile but it was in %d", test.width, test.height, i, c)
+			}
+		}
+	}
+}
+
+func TestRenderContextWorkers(t *testing.T) {
+	camera := NewThinLens(geom.NewVector(0, -6, 2), geom.NewVector(0, 0, 0), geom.NewVector(0, 0, 1), 1, 1.5, 0.4, 6)
+	objects := []geom.Intersectable{
+		sphere(geom.NewVector(0, 0, 0), 1),
+		sphere(geom.NewVector(1.5, 2, 0.5), 1),
+		mask{sphere(geom.NewVector(-1.5, -1, 0), 0.5)},
+	}
+
+	render := func(opts Options) *image.Gray {
+		t.Helper()
+		img, err := RenderContext(context.Background(), camera, objects, 45, 30, opts)
+		if err != nil {
+			t.Fatalf("Unexpected error %s", err)
+		}
+		return img
+	}
+
+	reference := render(Options{Samples: 4, Seed: 3, TileSize: 8, Workers: 1})
+	for _, workers := range []int{2, 5, 0} {
+		img := render(Options{Samples: 4, Seed: 3, TileSize: 8, Workers: workers})
+		if !bytes.Equal(img.Pix, reference.Pix) {
+			t.Errorf("Expected the image rendered with %d workers to match the one with a single worker", workers)
+		}
+	}
+
+	if img := render(Options{Samples: 4, Seed: 4, TileSize: 8}); bytes.Equal(img.Pix, reference.Pix) {
+		t.Errorf("Expected a different seed to produce different samples")
+	}
+
+	// Edges are smoothed with many samples.
+	gray := 0
+	for _, p := range reference.Pix {
+		if p != 0 && p != 255 && p < 64 {
+			gray++
+		}
+	}
+	if gray == 0 {
+		t.Errorf("Expected some pixels on the edges of objects to be blended with the background")
+	}
+
+	single := render(Options{TileSize: 7, Workers: 3})
+	if !bytes.Equal(single.Pix, Render(camera, objects, 45, 30).Pix) {
+		t.Errorf("Expected a single sample per pixel not to depend on the tiles")
+	}
+}
+
+func TestRenderContextProgress(t *testing.T) {
+	camera := NewOrthographic(geom.NewVector(0, 0, 5), geom.NewVector(0, 0, 0), geom.NewVector(0, 1, 0), 4, 1)
+	objects := []geom.Intersectable{sphere(geom.NewVector(0, 0, 0), 1)}
+
+	last, calls := 0, 0
+	opts := Options{
+		TileSize: 10,
+		Workers:  4,
+		Progress: func(done, total int) {
+			calls++
+			if total != 12 || done != last+1 {
+				t.Errorf("Expected progress %d of 12 but it was %d of %d", last+1, done, total)
+			}
+			last = done
+		},
+	}
+	if _, err := RenderContext(context.Background(), camera, objects, 40, 30, opts); err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+	if calls != 12 || last != 12 {
+		t.Errorf("Expected 12 progress reports but there were %d", calls)
+	}
+}
+
+func TestRenderContextCancel(t *testing.T) {
+	camera := NewPerspective(geom.NewVector(0, 0, 5), geom.NewVector(0, 0, 0), geom.NewVector(0, 1, 0), math.Pi/3, 1)
+	objects := []geom.Intersectable{sphere(geom.NewVector(0, 0, 0), 1)}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if img, err := RenderContext(ctx, camera, objects, 100, 100, Options{}); img != nil || err != context.Canceled {
+		t.Errorf("Expected a cancelled context to stop rendering, but got %v", err)
+	}
+
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+	rendered := 0
+	opts := Options{
+		TileSize: 4,
+		Workers:  2,
+		Progress: func(done, total int) {
+			rendered = done
+			if done == 3 {
+				cancel()
+			}
+		},
+	}
+	img, err := RenderContext(ctx, camera, objects, 100, 100, opts)
+	if img != nil || err != context.Canceled {
+		t.Errorf("Expected cancelling the context to stop rendering, but got %v", err)
+	}
+	if rendered > 10 {
+		t.Errorf("Expected rendering to stop soon after cancellation, but %d tiles were rendered", rendered)
+	}
+}
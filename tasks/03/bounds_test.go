@@ -0,0 +1,60 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/fmi/go-homework/geom"
+)
+
+func TestBounds(t *testing.T) {
+	tests := []struct {
+		description string
+		figure      geom.Bounded
+		min, max    geom.Vector
+	}{
+		{
+			description: "triangle",
+			figure: NewTriangle(
+				geom.NewVector(-1, -1, 0),
+				geom.NewVector(1, -1, 2),
+				geom.NewVector(0, 1, 0),
+			),
+			min: geom.NewVector(-1, -1, 0),
+			max: geom.NewVector(1, 1, 2),
+		},
+		{
+			description: "quad",
+			figure: NewQuad(
+				geom.NewVector(-1, -1, 0),
+				geom.NewVector(1, -1, 0),
+				geom.NewVector(10, 10, 0),
+				geom.NewVector(-1, 1, 0),
+			),
+			min: geom.NewVector(-1, -1, 0),
+			max: geom.NewVector(10, 10, 0),
+		},
+		{
+			description: "sphere",
+			figure:      NewSphere(geom.NewVector(5, 5, 5), 3),
+			min:         geom.NewVector(2, 2, 2),
+			max:         geom.NewVector(8, 8, 8),
+		},
+		{
+			description: "transformed sphere",
+			figure: transformed(
+				NewSphere(geom.NewVector(0, 0, 0), 1),
+				geom.Translate(geom.NewVector(1, 0, 0)).Mul(geom.Scale(geom.NewVector(2, 1, 1))),
+			),
+			min: geom.NewVector(-1, -1, -1),
+			max: geom.NewVector(3, 1, 1),
+		},
+	}
+
+	for _, test := range tests {
+		b := test.figure.Bounds()
+		if b.Min != test.min || b.Max != test.max {
+			t.Errorf("%s: expected bounds [%v, %v] but got [%v, %v]",
+				test.description, test.min, test.max, b.Min, b.Max)
+		}
+	}
+}
@@ -0,0 +1,277 @@
+package main
+
+import (
+	"math"
+
+	"github.com/fmi/go-homework/geom"
+)
+
+// Box is an Intersectable which represents a solid box with faces parallel to
+// the coordinate planes.
+type Box struct {
+	min, max  geom.Vector
+	tolerance geom.Tolerance
+}
+
+// NewBox returns a new Box with opposite corners `a` and `b`.
+func NewBox(a, b geom.Vector) *Box {
+	bounds := geom.NewAABB(a, b)
+	return &Box{min: bounds.Min, max: bounds.Max, tolerance: geom.DefaultTolerance}
+}
+
+// WithTolerance returns a copy of the box which is intersected with
+// `tolerance`. NewBox uses geom.DefaultTolerance.
+func (b *Box) WithTolerance(tolerance geom.Tolerance) *Box {
+	box := *b
+	box.tolerance = tolerance
+	return &box
+}
+
+// Intersect implements the geom.Intersecatble interface.
+func (b *Box) Intersect(ray geom.Ray) bool {
+	return b.Occluded(ray, math.Inf(1))
+}
+
+// IntersectHit implements the geom.Intersector interface.
+func (b *Box) IntersectHit(ray geom.Ray) (geom.Hit, bool) {
+	return b.ClosestHit(ray, 0, math.Inf(1))
+}
+
+// Occluded implements the geom.Occluder interface.
+func (b *Box) Occluded(ray geom.Ray, tMax float64) bool {
+	_, ok := b.ClosestHit(ray, 0, tMax)
+	return ok
+}
+
+// ClosestHit implements the geom.ClosestHitter interface. It uses the slab
+// method: the ray is clipped between each pair of parallel faces and the hit
+// is where it enters or leaves all three slabs. The edges and corners of the
+// box are part of it. The slabs are widened by the tolerance of the box,
+// relative to its longest side, so rays grazing the edges hit it.
+//
+// The U and V of the returned hit are the coordinates of the hit point on the
+// face which was hit, scaled to [0, 1].
+func (b *Box) ClosestHit(ray geom.Ray, tMin, tMax float64) (geom.Hit, bool) {
+	tMin, tMax = ray.Clip(tMin, tMax)
+	tNear, tFar, nearAxis, farAxis, ok := b.slabs(ray)
+	if !ok {
+		return geom.Hit{}, false
+	}
+
+	t, axis := tNear, nearAxis
+	if t < tMin {
+		t, axis = tFar, farAxis
+	}
+	if t < tMin || t > tMax {
+		return geom.Hit{}, false
+	}
+
+	return b.hit(ray, t, axis), true
+}
+
+// Intervals implements the geom.Solid interface.
+func (b *Box) Intervals(ray geom.Ray) []geom.Interval {
+	tNear, tFar, nearAxis, farAxis, ok := b.slabs(ray)
+	if !ok {
+		return nil
+	}
+	return []geom.Interval{newInterval(b.hit(ray, tNear, nearAxis), b.hit(ray, tFar, farAxis))}
+}
+
+// Contains implements the geom.Container interface. Points within the
+// tolerance of the box, relative to its longest side, are inside.
+func (b *Box) Contains(p geom.Vector) bool {
+	size := geom.Sub(b.max, b.min)
+	eps := b.tolerance.Epsilon(math.Max(size.X, math.Max(size.Y, size.Z)))
+	return p.X >= b.min.X-eps && p.X <= b.max.X+eps &&
+		p.Y >= b.min.Y-eps && p.Y <= b.max.Y+eps &&
+		p.Z >= b.min.Z-eps && p.Z <= b.max.Z+eps
+}
+
+// slabs returns the ray parameters at which the line of `ray` enters and
+// leaves the box and the axes of the faces through which it does so. Its last
+// return value is false when the line misses the box.
+func (b *Box) slabs(ray geom.Ray) (float64, float64, int, int, bool) {
+	origin := [3]float64{ray.Origin.X, ray.Origin.Y, ray.Origin.Z}
+	dir := [3]float64{ray.Direction.X, ray.Direction.Y, ray.Direction.Z}
+	size := geom.Sub(b.max, b.min)
+	eps := b.tolerance.Epsilon(math.Max(size.X, math.Max(size.Y, size.Z)))
+	min := [3]float64{b.min.X, b.min.Y, b.min.Z}
+	max := [3]float64{b.max.X, b.max.Y, b.max.Z}
+
+	// tNear and tFar are where the ray enters and leaves the box. The slabs
+	// widened by eps only decide whether it hits the box at all.
+	tNear, tFar := math.Inf(-1), math.Inf(1)
+	wideNear, wideFar := math.Inf(-1), math.Inf(1)
+	nearAxis, farAxis := -1, -1
+	for axis := 0; axis < 3; axis++ {
+		if dir[axis] == 0 {
+			if origin[axis] < min[axis]-eps || origin[axis] > max[axis]+eps {
+				return 0, 0, 0, 0, false
+			}
+			continue
+		}
+
+		t0 := (min[axis] - origin[axis]) / dir[axis]
+		t1 := (max[axis] - origin[axis]) / dir[axis]
+		if t0 > t1 {
+			t0, t1 = t1, t0
+		}
+		slack := eps / math.Abs(dir[axis])
+		wideNear = math.Max(wideNear, t0-slack)
+		wideFar = math.Min(wideFar, t1+slack)
+		if t0 > tNear {
+			tNear, nearAxis = t0, axis
+		}
+		if t1 < tFar {
+			tFar, farAxis = t1, axis
+		}
+	}
+
+	if wideNear > wideFar || nearAxis < 0 {
+		return 0, 0, 0, 0, false
+	}
+
+	// Rays which miss the box by less than eps graze an edge or a corner.
+	// They enter and leave the box at the same point.
+	if tNear > tFar {
+		tNear = (tNear + tFar) / 2
+		tFar = tNear
+	}
+	return tNear, tFar, nearAxis, farAxis, true
+}
+
+// hit returns the hit of `ray` with the face of the box perpendicular to
+// `axis` at ray parameter `t`.
+func (b *Box) hit(ray geom.Ray, t float64, axis int) geom.Hit {
+	min := [3]float64{b.min.X, b.min.Y, b.min.Z}
+	max := [3]float64{b.max.X, b.max.Y, b.max.Z}
+	hit := geom.Hit{T: t, Point: ray.At(t)}
+
+	var normal [3]float64
+	p := [3]float64{hit.Point.X, hit.Point.Y, hit.Point.Z}
+	if p[axis]-min[axis] < max[axis]-p[axis] {
+		normal[axis] = -1
+	} else {
+		normal[axis] = 1
+	}
+	hit.SetFaceNormal(ray, geom.NewVector(normal[0], normal[1], normal[2]))
+
+	u, v := (axis+1)%3, (axis+2)%3
+	hit.U = math.Max(0, math.Min(1, boxCoordinate(p[u], min[u], max[u])))
+	hit.V = math.Max(0, math.Min(1, boxCoordinate(p[v], min[v], max[v])))
+
+	return hit
+}
+
+// Bounds implements the geom.Bounded interface.
+func (b *Box) Bounds() geom.AABB {
+	return geom.AABB{Min: b.min, Max: b.max}
+}
+
+// boxCoordinate returns `x` scaled from [`min`, `max`] to [0, 1].
+func boxCoordinate(x, min, max float64) float64 {
+	if max == min {
+		return 0
+	}
+	return (x - min) / (max - min)
+}
+
+// OBB is an Intersectable which represents an oriented box, a solid box
+// which may be arbitrary rotated in the space.
+type OBB struct {
+	box Box
+
+	// toWorld is a rigid transformation which places the center of the
+	// box in the world. toLocal is its inverse.
+	toWorld, toLocal geom.Matrix
+}
+
+// NewOBB returns a new OBB with center `center`. `u`, `v` and `w` go from the
+// center to the centers of three of its faces, so they should be perpendicular
+// to each other. When they are not, the box is straightened: its second axis
+// is made perpendicular to `u` in the plane of `u` and `v` and its third axis
+// is made perpendicular to both, keeping the lengths of all three. `u` and `v`
+// must not be parallel.
+func NewOBB(center, u, v, w geom.Vector) *OBB {
+	x := geom.Normalize(u)
+	y := geom.Normalize(geom.Sub(v, geom.Mul(x, geom.Dot(v, x))))
+	z := geom.Cross(x, y)
+	frame := geom.Matrix{
+		{x.X, y.X, z.X, center.X},
+		{x.Y, y.Y, z.Y, center.Y},
+		{x.Z, y.Z, z.Z, center.Z},
+		{0, 0, 0, 1},
+	}
+	toLocal, _ := frame.Inverse()
+	half := geom.NewVector(geom.Len(u), geom.Len(v), geom.Len(w))
+
+	return &OBB{
+		box:     Box{min: geom.Neg(half), max: half, tolerance: geom.DefaultTolerance},
+		toWorld: frame,
+		toLocal: toLocal,
+	}
+}
+
+// WithTolerance returns a copy of the oriented box which is intersected with
+// `tolerance`. NewOBB uses geom.DefaultTolerance.
+func (o *OBB) WithTolerance(tolerance geom.Tolerance) *OBB {
+	obb := *o
+	obb.box.tolerance = tolerance
+	return &obb
+}
+
+// Intersect implements the geom.Intersecatble interface.
+func (o *OBB) Intersect(ray geom.Ray) bool {
+	return o.Occluded(ray, math.Inf(1))
+}
+
+// IntersectHit implements the geom.Intersector interface.
+func (o *OBB) IntersectHit(ray geom.Ray) (geom.Hit, bool) {
+	return o.ClosestHit(ray, 0, math.Inf(1))
+}
+
+// Occluded implements the geom.Occluder interface.
+func (o *OBB) Occluded(ray geom.Ray, tMax float64) bool {
+	_, ok := o.ClosestHit(ray, 0, tMax)
+	return ok
+}
+
+// ClosestHit implements the geom.ClosestHitter interface. The ray is
+// transformed in the local coordinate system of the box, where it is
+// axis-aligned.
+func (o *OBB) ClosestHit(ray geom.Ray, tMin, tMax float64) (geom.Hit, bool) {
+	hit, ok := o.box.ClosestHit(o.toLocal.Ray(ray), tMin, tMax)
+	if !ok {
+		return geom.Hit{}, false
+	}
+	return o.hitToWorld(ray, hit), true
+}
+
+// Intervals implements the geom.Solid interface.
+func (o *OBB) Intervals(ray geom.Ray) []geom.Interval {
+	intervals := o.box.Intervals(o.toLocal.Ray(ray))
+	for i := range intervals {
+		intervals[i].In = o.hitToWorld(ray, intervals[i].In)
+		intervals[i].Out = o.hitToWorld(ray, intervals[i].Out)
+	}
+	return intervals
+}
+
+// Contains implements the geom.Container interface.
+func (o *OBB) Contains(p geom.Vector) bool {
+	return o.box.Contains(o.toLocal.Point(p))
+}
+
+// hitToWorld converts `hit` of the ray in the local coordinate system of the
+// box to a hit of `ray`.
+func (o *OBB) hitToWorld(ray geom.Ray, hit geom.Hit) geom.Hit {
+	hit.Point = ray.At(hit.T)
+	hit.Normal = o.toWorld.Direction(hit.Normal)
+	return hit
+}
+
+// Bounds implements the geom.Bounded interface.
+func (o *OBB) Bounds() geom.AABB {
+	return o.box.Bounds().Transform(o.toWorld)
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"math"
+	"testing"
+
+	"github.com/fmi/go-homework/geom"
+)
+
+func TestBoxes(t *testing.T) {
+	box := NewBox(geom.NewVector(1, 1, 1), geom.NewVector(-1, -1, -1))
+	obb := NewOBB(
+		geom.NewVector(0, 0, 0),
+		geom.NewVector(1, 1, 0),
+		geom.NewVector(-1, 1, 0),
+		geom.NewVector(0, 0, 1),
+	)
+	skewed := NewOBB(
+		geom.NewVector(0, 0, 0),
+		geom.NewVector(1, 1, 0),
+		geom.NewVector(0, math.Sqrt2, 0),
+		geom.NewVector(0, 0.6, 0.8),
+	)
+
+	tests := []struct {
+		description string
+		figure      geom.ClosestHitter
+		ray         geom.Ray
+		intersected bool
+		t           float64
+		normal      geom.Vector
+	}{
+		{
+			"box face", box,
+			geom.NewRay(geom.NewVector(0, 0, -5), geom.NewVector(0, 0, 1)),
+			true, 4, geom.NewVector(0, 0, -1),
+		},
+		{
+			"box from inside", box,
+			geom.NewRay(geom.NewVector(0, 0, 0), geom.NewVector(0, 2, 0)),
+			true, 0.5, geom.NewVector(0, -1, 0),
+		},
+		{
+			"box edge", box,
+			geom.NewRay(geom.NewVector(1, 1, -5), geom.NewVector(0, 0, 1)),
+			true, 4, geom.NewVector(0, 0, -1),
+		},
+		{
+			"box corner", box,
+			geom.NewRay(geom.NewVector(2, 2, 2), geom.NewVector(-1, -1, -1)),
+			true, 1, geom.NewVector(1, 0, 0),
+		},
+		{
+			"box grazing edge diagonally", box,
+			geom.NewRay(geom.NewVector(0, 2, -1), geom.NewVector(1, -1, 0)),
+			true, 1, geom.NewVector(0, 1, 0),
+		},
+		{
+			"box near miss", box,
+			geom.NewRay(geom.NewVector(1.001, 0, -5), geom.NewVector(0, 0, 1)),
+			false, 0, geom.Vector{},
+		},
+		{
+			"box opposite direction", box,
+			geom.NewRay(geom.NewVector(0, 0, -5), geom.NewVector(0, 0, -1)),
+			false, 0, geom.Vector{},
+		},
+		{
+			"oriented box side", obb,
+			geom.NewRay(geom.NewVector(-5, 0.5, 0), geom.NewVector(1, 0, 0)),
+			true, 3.5, geom.Normalize(geom.NewVector(-1, 1, 0)),
+		},
+		{
+			"oriented box face", obb,
+			geom.NewRay(geom.NewVector(3, 3, 0), geom.NewVector(-1, -1, 0)),
+			true, 2, geom.Normalize(geom.NewVector(1, 1, 0)),
+		},
+		{
+			"oriented box near miss", obb,
+			geom.NewRay(geom.NewVector(2.01, 0, -5), geom.NewVector(0, 0, 1)),
+			false, 0, geom.Vector{},
+		},
+		{
+			"straightened oriented box side", skewed,
+			geom.NewRay(geom.NewVector(-5, 0.5, 0), geom.NewVector(1, 0, 0)),
+			true, 3.5, geom.Normalize(geom.NewVector(-1, 1, 0)),
+		},
+		{
+			"straightened oriented box top", skewed,
+			geom.NewRay(geom.NewVector(0, 0, 5), geom.NewVector(0, 0, -1)),
+			true, 4, geom.NewVector(0, 0, 1),
+		},
+	}
+
+	for _, test := range tests {
+		hit, ok := test.figure.ClosestHit(test.ray, 0, math.Inf(1))
+		if ok != test.intersected {
+			t.Errorf("%s: expected intersection to be %t but it was %t", test.description, test.intersected, ok)
+			continue
+		}
+		if !ok {
+			continue
+		}
+		if math.Abs(hit.T-test.t) > 1e-9 {
+			t.Errorf("%s: expected hit at %g but it was at %g", test.description, test.t, hit.T)
+		}
+		if geom.Len(geom.Sub(hit.Normal, test.normal)) > 1e-9 {
+			t.Errorf("%s: expected normal %v but it was %v", test.description, test.normal, hit.Normal)
+		}
+	}
+}
+
+func TestBoxGrazingIntervals(t *testing.T) {
+	box := NewBox(geom.NewVector(1, 1, 1), geom.NewVector(-1, -1, -1))
+
+	// The ray misses the corner edge of the box by less than the tolerance,
+	// so it enters the box where it should have left it.
+	ray := geom.NewRay(geom.NewVector(0, 2+1e-10, 0), geom.NewVector(1, -1, 0))
+
+	intervals := box.Intervals(ray)
+	if len(intervals) != 1 {
+		t.Fatalf("Expected the grazing ray to give one interval but it gave %+v", intervals)
+	}
+	if in := intervals[0]; in.In.T > in.Out.T || math.Abs(in.In.T-1) > 1e-9 {
+		t.Errorf("Expected an empty interval at 1 but it was %+v", in)
+	}
+}
+
+func TestBoxBounds(t *testing.T) {
+	obb := NewOBB(
+		geom.NewVector(1, 1, 1),
+		geom.NewVector(1, 1, 0),
+		geom.NewVector(-1, 1, 0),
+		geom.NewVector(0, 0, 1),
+	)
+	b := obb.Bounds()
+	if geom.Len(geom.Sub(b.Min, geom.NewVector(-1, -1, 0))) > 1e-9 ||
+		geom.Len(geom.Sub(b.Max, geom.NewVector(3, 3, 2))) > 1e-9 {
+		t.Errorf("Unexpected oriented box bounds %v", b)
+	}
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/fmi/go-homework/geom"
+)
+
+func TestBVHMatchesLinearScan(t *testing.T) {
+	rnd := rand.New(rand.NewSource(42))
+	objects := randomScene(rnd, 2000)
+	bvh := geom.NewBVH(objects)
+
+	for i := 0; i < 2000; i++ {
+		ray := randomRay(rnd)
+
+		expected := false
+		for _, obj := range objects {
+			if obj.Intersect(ray) {
+				expected = true
+				break
+			}
+		}
+		if actual := bvh.Intersect(ray); actual != expected {
+			t.Fatalf("Expected intersection of %#v to be %t but it was %t", ray, expected, actual)
+		}
+
+		closest, found := linearClosestHit(objects, ray)
+		hit, ok := bvh.IntersectHit(ray)
+		if ok != found {
+			t.Fatalf("Expected closest hit of %#v to be found: %t but it was %t", ray, found, ok)
+		}
+		if ok && math.Abs(hit.T-closest.T) > 1e-9 {
+			t.Fatalf("Expected closest hit of %#v at %g but it was at %g", ray, closest.T, hit.T)
+		}
+	}
+}
+
+func TestBVHUnboundedAndNested(t *testing.T) {
+	far := geom.NewBVH([]geom.Intersectable{
+		NewSphere(geom.NewVector(0, 0, 10), 1),
+	})
+	inner := geom.NewBVH([]geom.Intersectable{
+		NewSphere(geom.NewVector(0, 0, 5), 1),
+		NewSphere(geom.NewVector(10, 0, 5), 1),
+	})
+	bvh := geom.NewBVH([]geom.Intersectable{far, inner, unboundedFigure{}})
+
+	if b := bvh.Bounds(); b.IsFinite() {
+		t.Errorf("Expected a BVH with unbounded objects to have infinite bounds, got %v", b)
+	}
+
+	ray := geom.NewRay(geom.NewVector(0, 0, 0), geom.NewVector(0, 0, 1))
+	hit, ok := bvh.IntersectHit(ray)
+	if !ok {
+		t.Fatalf("Expected ray %#v to hit", ray)
+	}
+	checkFloat(t, "t", hit.T, 4)
+
+	checkFigure(t, bvh, geom.NewRay(geom.NewVector(0, 5, 0), geom.NewVector(0, 0, 1)), true)
+	checkFigure(t, geom.NewBVH(nil), ray, false)
+}
+
+// unboundedFigure is an Intersectable without bounds which intersects all rays.
+type unboundedFigure struct{}
+
+func (unboundedFigure) Intersect(geom.Ray) bool { return true }
+
+func BenchmarkLinearScan(b *testing.B) {
+	rnd := rand.New(rand.NewSource(1))
+	objects := randomScene(rnd, 20000)
+	rays := randomRays(rnd, 1024)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		linearClosestHit(objects, rays[i%len(rays)])
+	}
+}
+
+func BenchmarkBVH(b *testing.B) {
+	rnd := rand.New(rand.NewSource(1))
+	bvh := geom.NewBVH(randomScene(rnd, 20000))
+	rays := randomRays(rnd, 1024)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		bvh.IntersectHit(rays[i%len(rays)])
+	}
+}
+
+func BenchmarkBVHBuild(b *testing.B) {
+	objects := randomScene(rand.New(rand.NewSource(1)), 20000)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		geom.NewBVH(objects)
+	}
+}
+
+// randomScene returns `n` small triangles, quads and spheres scattered in the
+// cube [-10, 10]^3.
+func randomScene(rnd *rand.Rand, n int) []geom.Intersectable {
+	objects := make([]geom.Intersectable, n)
+	for i := range objects {
+		c := randomVector(rnd, 10)
+		switch i % 3 {
+		case 0:
+			objects[i] = NewTriangle(
+				geom.Add(c, randomVector(rnd, 0.3)),
+				geom.Add(c, randomVector(rnd, 0.3)),
+				geom.Add(c, randomVector(rnd, 0.3)),
+			)
+		case 1:
+			e1, e2 := randomVector(rnd, 0.3), randomVector(rnd, 0.3)
+			objects[i] = NewQuad(c, geom.Add(c, e1), geom.Add(c, geom.Add(e1, e2)), geom.Add(c, e2))
+		default:
+			objects[i] = NewSphere(c, 0.1+rnd.Float64()*0.2)
+		}
+	}
+	return objects
+}
+
+func randomRays(rnd *rand.Rand, n int) []geom.Ray {
+	rays := make([]geom.Ray, n)
+	for i := range rays {
+		rays[i] = randomRay(rnd)
+	}
+	return rays
+}
+
+func randomRay(rnd *rand.Rand) geom.Ray {
+	return geom.NewRay(randomVector(rnd, 15), randomVector(rnd, 1))
+}
+
+func randomVector(rnd *rand.Rand, scale float64) geom.Vector {
+	return geom.NewVector(
+		(rnd.Float64()*2-1)*scale,
+		(rnd.Float64()*2-1)*scale,
+		(rnd.Float64()*2-1)*scale,
+	)
+}
+
+func linearClosestHit(objects []geom.Intersectable, ray geom.Ray) (geom.Hit, bool) {
+	var closest geom.Hit
+	found := false
+	for _, obj := range objects {
+		hit, ok := obj.(geom.Intersector).IntersectHit(ray)
+		if ok && (!found || hit.T < closest.T) {
+			closest, found = hit, true
+		}
+	}
+	return closest, found
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/fmi/go-homework/geom"
+)
+
+func TestContains(t *testing.T) {
+	sphere := NewSphere(geom.NewVector(1, 0, 0), 2)
+	box := NewBox(geom.NewVector(-1, -1, -1), geom.NewVector(1, 1, 1))
+	obb := NewOBB(
+		geom.NewVector(0, 0, 0),
+		geom.NewVector(1, 1, 0),
+		geom.NewVector(-1, 1, 0),
+		geom.NewVector(0, 0, 1),
+	)
+	cylinder := NewCylinder(geom.NewVector(0, 0, 0), geom.NewVector(0, 0, 2), 1, true)
+	open := NewCylinder(geom.NewVector(0, 0, 0), geom.NewVector(0, 0, 2), 1, false)
+	cone := NewCone(geom.NewVector(0, 0, 0), geom.NewVector(0, 0, 2), 1, true)
+	torus := NewTorus(geom.NewVector(0, 0, 0), geom.NewVector(0, 0, 1), 2, 0.5)
+	drilled := geom.Difference(box, NewCylinder(geom.NewVector(0, 0, -2), geom.NewVector(0, 0, 2), 0.5, true))
+	moved := transformed(sphere, geom.Translate(geom.NewVector(0, 10, 0)))
+
+	tests := []struct {
+		description string
+		solid       geom.Container
+		p           geom.Vector
+		inside      bool
+	}{
+		{"sphere center", sphere, geom.NewVector(1, 0, 0), true},
+		{"sphere surface", sphere, geom.NewVector(1, 2, 0), true},
+		{"sphere just outside", sphere, geom.NewVector(1, 2+1e-6, 0), false},
+		{"sphere outside", sphere, geom.NewVector(-2, 0, 0), false},
+		{"box inside", box, geom.NewVector(0.5, -0.5, 0.9), true},
+		{"box face", box, geom.NewVector(1, 0.5, 0), true},
+		{"box corner", box, geom.NewVector(-1, 1, -1), true},
+		{"box just outside", box, geom.NewVector(0, 0, -1-1e-6), false},
+		{"oriented box inside", obb, geom.NewVector(0, 1.3, 0), true},
+		{"oriented box corner", obb, geom.NewVector(0, math.Sqrt2, 1), true},
+		{"oriented box outside", obb, geom.NewVector(1.1, 1, 0), false},
+		{"cylinder axis", cylinder, geom.NewVector(0, 0, 1), true},
+		{"cylinder side", cylinder, geom.NewVector(0, 1, 1), true},
+		{"cylinder cap", cylinder, geom.NewVector(0.5, 0, 2), true},
+		{"cylinder rim", cylinder, geom.NewVector(1, 0, 0), true},
+		{"cylinder above", cylinder, geom.NewVector(0, 0, 2.001), false},
+		{"cylinder beside", cylinder, geom.NewVector(1.001, 0, 1), false},
+		{"open cylinder axis", open, geom.NewVector(0, 0, 1), true},
+		{"open cylinder above", open, geom.NewVector(0, 0, 2.001), false},
+		{"cone base", cone, geom.NewVector(0.5, 0.5, 0), true},
+		{"cone apex", cone, geom.NewVector(0, 0, 2), true},
+		{"cone side", cone, geom.NewVector(0.5, 0, 1), true},
+		{"cone outside", cone, geom.NewVector(0.6, 0, 1), false},
+		{"torus tube", torus, geom.NewVector(2, 0, 0), true},
+		{"torus surface", torus, geom.NewVector(0, -2.5, 0), true},
+		{"torus hole", torus, geom.NewVector(0, 0, 0), false},
+		{"torus above", torus, geom.NewVector(2, 0, 0.501), false},
+		{"drilled solid part", drilled, geom.NewVector(0.8, 0, 0), true},
+		{"drilled hole", drilled, geom.NewVector(0.2, 0, 0), false},
+		{"drilled wall of the hole", drilled, geom.NewVector(0.5, 0, 0), true},
+		{"drilled just in the hole", drilled, geom.NewVector(0.5-1e-6, 0, 0), false},
+		{"moved sphere", moved, geom.NewVector(1, 11, 0), true},
+		{"moved sphere origin", moved, geom.NewVector(1, 0, 0), false},
+	}
+
+	for _, test := range tests {
+		if inside := test.solid.Contains(test.p); inside != test.inside {
+			t.Errorf("%s: expected containment to be %t but it was %t", test.description, test.inside, inside)
+		}
+	}
+}
+
+func TestContainsIntervals(t *testing.T) {
+	solids := []interface {
+		geom.Solid
+		geom.Container
+	}{
+		NewSphere(geom.NewVector(0.5, 0, 0), 2),
+		NewOBB(
+			geom.NewVector(0, 0, 0),
+			geom.NewVector(1, 1, 0),
+			geom.NewVector(-1, 1, 0),
+			geom.NewVector(0, 0, 2),
+		),
+		NewCone(geom.NewVector(0, 0, -2), geom.NewVector(0, 0, 2), 2, true),
+		NewTorus(geom.NewVector(0, 0, 0), geom.NewVector(1, 1, 0), 2, 0.5),
+		geom.Union(
+			NewEllipsoid(geom.NewVector(0, 0, 0), geom.NewVector(1, 2, 3)),
+			NewCylinder(geom.NewVector(0, -3, 0), geom.NewVector(0, 3, 0), 0.5, true),
+		),
+	}
+
+	// The points in the middle of the intervals of a solid are inside it
+	// and the points between them are not.
+	rnd := rand.New(rand.NewSource(11))
+	for i, solid := range solids {
+		for j := 0; j < 1000; j++ {
+			ray := towards(randomVector(rnd, 10), randomVector(rnd, 2))
+			intervals := solid.Intervals(ray)
+			for k, in := range intervals {
+				if in.Out.T-in.In.T < 1e-6 {
+					continue
+				}
+				if p := ray.At((in.In.T + in.Out.T) / 2); !solid.Contains(p) {
+					t.Errorf("Solid %d: expected %+v in the middle of an interval to be inside it", i, p)
+				}
+				if k == 0 {
+					continue
+				}
+				gap := ray.At((intervals[k-1].Out.T + in.In.T) / 2)
+				if in.In.T-intervals[k-1].Out.T > 1e-6 && solid.Contains(gap) {
+					t.Errorf("Solid %d: expected %+v between intervals to be outside it", i, gap)
+				}
+			}
+		}
+	}
+}
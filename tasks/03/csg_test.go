@@ -0,0 +1,205 @@
+package main
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/fmi/go-homework/geom"
+)
+
+func TestSolidIntervals(t *testing.T) {
+	solids := []struct {
+		description string
+		solid       geom.Solid
+	}{
+		{"sphere", NewSphere(geom.NewVector(0.5, 0, 0), 2)},
+		{"box", NewBox(geom.NewVector(-1, -2, -1), geom.NewVector(2, 1, 1))},
+		{"oriented box", NewOBB(
+			geom.NewVector(0, 0, 0),
+			geom.NewVector(1, 1, 0),
+			geom.NewVector(-1, 1, 0),
+			geom.NewVector(0, 0, 2),
+		)},
+		{"capped cylinder", NewCylinder(geom.NewVector(0, -2, 0), geom.NewVector(0, 2, 1), 1.5, true)},
+		{"capped cone", NewCone(geom.NewVector(0, 0, -2), geom.NewVector(0, 0, 2), 2, true)},
+		{"ellipsoid", NewEllipsoid(geom.NewVector(0, 0, 0), geom.NewVector(1, 2, 3))},
+		{"capped paraboloid", NewParaboloid(geom.NewVector(0, 0, -2), geom.NewVector(0, 1, 1), 2, 3, true)},
+		{"torus", NewTorus(geom.NewVector(0, 0, 0), geom.NewVector(1, 1, 0), 2, 0.5)},
+	}
+
+	rnd := rand.New(rand.NewSource(7))
+	for _, test := range solids {
+		for i := 0; i < 2000; i++ {
+			ray := towards(randomVector(rnd, 10), randomVector(rnd, 3))
+			intervals := test.solid.Intervals(ray)
+
+			for j, in := range intervals {
+				if in.In.T > in.Out.T || (j > 0 && intervals[j-1].Out.T > in.In.T) {
+					t.Fatalf("%s: intervals %+v are not sorted", test.description, intervals)
+				}
+				if !in.In.FrontFace || in.Out.FrontFace {
+					t.Fatalf("%s: interval %+v is not entered at its front face", test.description, in)
+				}
+			}
+
+			// The first boundary in front of the origin is the closest hit.
+			expected, ok := test.solid.IntersectHit(ray)
+			var hit geom.Hit
+			found := false
+			for _, in := range intervals {
+				if in.In.T >= 0 {
+					hit, found = in.In, true
+					break
+				}
+				if in.Out.T >= 0 {
+					hit, found = in.Out, true
+					break
+				}
+			}
+
+			if found != ok {
+				t.Errorf("%s: intervals %+v disagree with the closest hit %+v for ray %+v",
+					test.description, intervals, expected, ray)
+				continue
+			}
+			if ok && math.Abs(hit.T-expected.T) > 1e-9 {
+				t.Errorf("%s: expected the first boundary to be at %g but it was at %g",
+					test.description, expected.T, hit.T)
+			}
+		}
+	}
+}
+
+func TestCSG(t *testing.T) {
+	// A sphere with a hole along the Z axis.
+	drilled := geom.Difference(
+		NewSphere(geom.NewVector(0, 0, 0), 2),
+		NewCylinder(geom.NewVector(0, 0, -3), geom.NewVector(0, 0, 3), 1, true),
+	)
+
+	// Two boxes overlapping in [0, 1]³.
+	first := NewBox(geom.NewVector(-1, -1, -1), geom.NewVector(1, 1, 1))
+	second := NewBox(geom.NewVector(0, 0, 0), geom.NewVector(2, 2, 2))
+	union := geom.Union(first, second)
+	common := geom.Intersection(first, second)
+
+	// A box with a rounded corner cut out, which is moved along X.
+	nested := transformed(
+		geom.Difference(first, geom.Intersection(second, NewSphere(geom.NewVector(1, 1, 1), 1))),
+		geom.Translate(geom.NewVector(5, 0, 0)),
+	)
+
+	tests := []struct {
+		description string
+		figure      geom.ClosestHitter
+		ray         geom.Ray
+		intersected bool
+		t           float64
+		point       geom.Vector
+		normal      geom.Vector
+		front       bool
+	}{
+		{
+			"ray through the hole", drilled,
+			geom.NewRay(geom.NewVector(0, 0, 5), geom.NewVector(0, 0, -1)),
+			false, 0, geom.Vector{}, geom.Vector{}, false,
+		},
+		{
+			"ray next to the hole", drilled,
+			geom.NewRay(geom.NewVector(1.5, 0, 5), geom.NewVector(0, 0, -1)),
+			true, 5 - math.Sqrt(1.75), geom.NewVector(1.5, 0, math.Sqrt(1.75)),
+			geom.Normalize(geom.NewVector(1.5, 0, math.Sqrt(1.75))), true,
+		},
+		{
+			"ray into the hole", drilled,
+			geom.NewRay(geom.NewVector(0, 0, 0), geom.NewVector(1, 0, 0)),
+			true, 1, geom.NewVector(1, 0, 0), geom.NewVector(-1, 0, 0), true,
+		},
+		{
+			"ray across the hole", drilled,
+			geom.NewRay(geom.NewVector(-5, 0, 0), geom.NewVector(1, 0, 0)),
+			true, 3, geom.NewVector(-2, 0, 0), geom.NewVector(-1, 0, 0), true,
+		},
+		{
+			"ray from the solid part", drilled,
+			geom.NewRay(geom.NewVector(-1.5, 0, 0), geom.NewVector(1, 0, 0)),
+			true, 0.5, geom.NewVector(-1, 0, 0), geom.NewVector(-1, 0, 0), false,
+		},
+		{
+			"union from outside", union,
+			geom.NewRay(geom.NewVector(0.5, 0.5, 5), geom.NewVector(0, 0, -1)),
+			true, 3, geom.NewVector(0.5, 0.5, 2), geom.NewVector(0, 0, 1), true,
+		},
+		{
+			"union from the common part", union,
+			geom.NewRay(geom.NewVector(0.5, 0.5, 0.5), geom.NewVector(0, 0, 1)),
+			true, 1.5, geom.NewVector(0.5, 0.5, 2), geom.NewVector(0, 0, -1), false,
+		},
+		{
+			"union along a shared face", union,
+			geom.NewRay(geom.NewVector(0.5, 0.5, -5), geom.NewVector(0, 0, 1)),
+			true, 4, geom.NewVector(0.5, 0.5, -1), geom.NewVector(0, 0, -1), true,
+		},
+		{
+			"intersection", common,
+			geom.NewRay(geom.NewVector(0.5, 0.5, -5), geom.NewVector(0, 0, 1)),
+			true, 5, geom.NewVector(0.5, 0.5, 0), geom.NewVector(0, 0, -1), true,
+		},
+		{
+			"intersection miss", common,
+			geom.NewRay(geom.NewVector(-0.5, 0.5, -5), geom.NewVector(0, 0, 1)),
+			false, 0, geom.Vector{}, geom.Vector{}, false,
+		},
+		{
+			"nested outside the cut", nested,
+			geom.NewRay(geom.NewVector(4.5, -0.5, 5), geom.NewVector(0, 0, -1)),
+			true, 4, geom.NewVector(4.5, -0.5, 1), geom.NewVector(0, 0, 1), true,
+		},
+		{
+			"nested in the cut", nested,
+			geom.NewRay(geom.NewVector(6, 1, 5), geom.NewVector(0, 0, -1)),
+			true, 5, geom.NewVector(6, 1, 0), geom.NewVector(0, 0, 1), true,
+		},
+	}
+
+	for _, test := range tests {
+		hit, ok := test.figure.IntersectHit(test.ray)
+		if ok != test.intersected {
+			t.Errorf("%s: expected intersection to be %t but it was %t", test.description, test.intersected, ok)
+			continue
+		}
+		if !ok {
+			continue
+		}
+		checkHit(t, hit, test.t, test.point, test.normal, test.front)
+
+		if test.figure.Intersect(test.ray) != ok {
+			t.Errorf("%s: Intersect disagrees with IntersectHit", test.description)
+		}
+		if ok := test.figure.(geom.Occluder).Occluded(test.ray, test.t/2); ok {
+			t.Errorf("%s: expected no occlusion before the hit", test.description)
+		}
+	}
+}
+
+func TestCSGBounds(t *testing.T) {
+	first := NewBox(geom.NewVector(-1, -1, -1), geom.NewVector(1, 1, 1))
+	second := NewSphere(geom.NewVector(1, 0, 0), 1)
+
+	tests := []struct {
+		description string
+		csg         *geom.CSG
+		min, max    geom.Vector
+	}{
+		{"union", geom.Union(first, second), geom.NewVector(-1, -1, -1), geom.NewVector(2, 1, 1)},
+		{"intersection", geom.Intersection(first, second), geom.NewVector(0, -1, -1), geom.NewVector(1, 1, 1)},
+		{"difference", geom.Difference(first, second), geom.NewVector(-1, -1, -1), geom.NewVector(1, 1, 1)},
+	}
+
+	for _, test := range tests {
+		bounds := test.csg.Bounds()
+		checkVector(t, test.description+" min", bounds.Min, test.min)
+		checkVector(t, test.description+" max", bounds.Max, test.max)
+	}
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/fmi/go-homework/geom"
+)
+
+func TestClosestPoint(t *testing.T) {
+	triangle := NewTriangle(geom.NewVector(0, 0, 1), geom.NewVector(2, 0, 1), geom.NewVector(0, 2, 1))
+	quad := NewQuad(
+		geom.NewVector(0, 0, 0),
+		geom.NewVector(2, 0, 0),
+		geom.NewVector(2, 1, 0),
+		geom.NewVector(0, 1, 0),
+	)
+	sphere := NewSphere(geom.NewVector(1, 1, 1), 2)
+
+	// An L shape with a notch in its upper right corner.
+	polygon := NewPolygon(
+		geom.NewVector(0, 0, 0),
+		geom.NewVector(2, 0, 0),
+		geom.NewVector(2, 1, 0),
+		geom.NewVector(1, 1, 0),
+		geom.NewVector(1, 2, 0),
+		geom.NewVector(0, 2, 0),
+	)
+
+	tests := []struct {
+		description string
+		shape       geom.Distancer
+		p           geom.Vector
+		point       geom.Vector
+		feature     geom.Feature
+		index       int
+		u, v        float64
+	}{
+		{
+			"triangle face", triangle, geom.NewVector(0.5, 1, 3),
+			geom.NewVector(0.5, 1, 1), geom.FeatureFace, 0, 0.25, 0.5,
+		},
+		{
+			"triangle vertex", triangle, geom.NewVector(3, -1, 0),
+			geom.NewVector(2, 0, 1), geom.FeatureVertex, 1, 1, 0,
+		},
+		{
+			"triangle edge", triangle, geom.NewVector(-1, 1, 1),
+			geom.NewVector(0, 1, 1), geom.FeatureEdge, 2, 0, 0.5,
+		},
+		{
+			"quad face", quad, geom.NewVector(1.5, 0.5, -2),
+			geom.NewVector(1.5, 0.5, 0), geom.FeatureFace, 0, 0.75, 0.5,
+		},
+		{
+			"quad edge", quad, geom.NewVector(1, 3, 1),
+			geom.NewVector(1, 1, 0), geom.FeatureEdge, 2, 0.5, 1,
+		},
+		{
+			"quad vertex", quad, geom.NewVector(3, 2, 0),
+			geom.NewVector(2, 1, 0), geom.FeatureVertex, 2, 1, 1,
+		},
+		{
+			"quad edge from above", quad, geom.NewVector(0, 0.5, 1),
+			geom.NewVector(0, 0.5, 0), geom.FeatureEdge, 3, 0, 0.5,
+		},
+		{
+			"polygon notch", polygon, geom.NewVector(1.5, 1.3, 1),
+			geom.NewVector(1.5, 1, 0), geom.FeatureEdge, 2, 1.5, 1,
+		},
+		{
+			"polygon vertex", polygon, geom.NewVector(-1, -2, 1),
+			geom.NewVector(0, 0, 0), geom.FeatureVertex, 0, 0, 0,
+		},
+		{
+			"polygon face", polygon, geom.NewVector(0.5, 1.5, -1),
+			geom.NewVector(0.5, 1.5, 0), geom.FeatureFace, 0, 0.5, 1.5,
+		},
+		{
+			"polygon inner edge", polygon, geom.NewVector(1.2, 1.5, 0),
+			geom.NewVector(1, 1.5, 0), geom.FeatureEdge, 3, 1, 1.5,
+		},
+		{
+			"sphere outside", sphere, geom.NewVector(1, 5, 1),
+			geom.NewVector(1, 3, 1), geom.FeatureFace, 0, 0.5, 0,
+		},
+		{
+			"sphere inside", sphere, geom.NewVector(1, 1, 0),
+			geom.NewVector(1, 1, -1), geom.FeatureFace, 0, 0.25, 0.5,
+		},
+		{
+			"sphere center", sphere, geom.NewVector(1, 1, 1),
+			geom.NewVector(1, 3, 1), geom.FeatureFace, 0, 0.5, 0,
+		},
+	}
+
+	for _, test := range tests {
+		closest := test.shape.ClosestPoint(test.p)
+		checkVector(t, test.description+" point", closest.Point, test.point)
+
+		distance := geom.Len(geom.Sub(test.point, test.p))
+		checkFloat(t, test.description+" distance", closest.Distance, distance)
+		checkFloat(t, test.description+" Distance", test.shape.Distance(test.p), distance)
+
+		if closest.Feature != test.feature || closest.Index != test.index {
+			t.Errorf("%s: expected the closest point on %s %d but it was on %s %d",
+				test.description, test.feature, test.index, closest.Feature, closest.Index)
+		}
+		checkFloat(t, test.description+" u", closest.U, test.u)
+		checkFloat(t, test.description+" v", closest.V, test.v)
+	}
+}
+
+func TestClosestPointMatchesHits(t *testing.T) {
+	rnd := rand.New(rand.NewSource(13))
+	a, b := geom.NewVector(-1, -1, 1), geom.NewVector(2, -1, 2)
+	c, d := geom.NewVector(1.25, 2, 1.75), geom.NewVector(-1, 2, 1)
+
+	shapes := []struct {
+		description string
+		shape       interface {
+			geom.Distancer
+			geom.Intersector
+		}
+	}{
+		{"triangle", NewTriangle(a, b, c)},
+		{"quad", NewQuad(a, b, c, d)},
+		{"polygon", NewPolygon(a, b, c, d)},
+		{"sphere", NewSphere(a, 1.5)},
+	}
+
+	// A ray from a point towards its closest point hits the shape there,
+	// with the same surface coordinates.
+	for _, test := range shapes {
+		for i := 0; i < 1000; i++ {
+			p := randomVector(rnd, 5)
+			closest := test.shape.ClosestPoint(p)
+			if closest.Distance < 1e-3 || closest.Feature != geom.FeatureFace {
+				continue
+			}
+
+			hit, ok := test.shape.IntersectHit(towards(p, closest.Point))
+			if !ok {
+				t.Errorf("%s: expected the ray from %v to hit the closest point %v", test.description, p, closest.Point)
+				continue
+			}
+			checkVector(t, test.description+" point", hit.Point, closest.Point)
+			checkFloat(t, test.description+" u", hit.U, closest.U)
+			checkFloat(t, test.description+" v", hit.V, closest.V)
+
+			// The closest point of a face is the foot of the
+			// perpendicular from the query point.
+			if math.Abs(geom.Dot(hit.Normal, geom.Normalize(geom.Sub(p, closest.Point)))) < 1-1e-9 {
+				t.Errorf("%s: expected the closest point of %v to be along the normal", test.description, p)
+			}
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"math"
+	"testing"
+
+	"github.com/fmi/go-homework/geom"
+)
+
+func TestIntersectorImplementations(t *testing.T) {
+	var _ geom.Intersector = NewTriangle(geom.Vector{}, geom.Vector{}, geom.Vector{})
+	var _ geom.Intersector = NewQuad(geom.Vector{}, geom.Vector{}, geom.Vector{}, geom.Vector{})
+	var _ geom.Intersector = NewSphere(geom.Vector{}, 1)
+}
+
+func TestTriangleHit(t *testing.T) {
+	triangle := NewTriangle(
+		geom.NewVector(-1, -1, 0),
+		geom.NewVector(1, -1, 0),
+		geom.NewVector(-1, 1, 0),
+	)
+	ray := geom.NewRay(geom.NewVector(-0.5, 0, -2), geom.NewVector(0, 0, 2))
+
+	hit, ok := triangle.IntersectHit(ray)
+	if !ok {
+		t.Fatalf("Expected ray %#v to hit the triangle", ray)
+	}
+
+	checkHit(t, hit, 1, geom.NewVector(-0.5, 0, 0), geom.NewVector(0, 0, -1), false)
+	checkFloat(t, "u", hit.U, 0.25)
+	checkFloat(t, "v", hit.V, 0.5)
+}
+
+func TestQuadHit(t *testing.T) {
+	quad := NewQuad(
+		geom.NewVector(-1, -1, 0),
+		geom.NewVector(1, -1, 0),
+		geom.NewVector(1, 1, 0),
+		geom.NewVector(-1, 1, 0),
+	)
+	ray := geom.NewRay(geom.NewVector(0.5, 0, 3), geom.NewVector(0, 0, -1))
+
+	hit, ok := quad.IntersectHit(ray)
+	if !ok {
+		t.Fatalf("Expected ray %#v to hit the quad", ray)
+	}
+
+	checkHit(t, hit, 3, geom.NewVector(0.5, 0, 0), geom.NewVector(0, 0, 1), true)
+	checkFloat(t, "u", hit.U, 0.75)
+	checkFloat(t, "v", hit.V, 0.5)
+}
+
+func TestQuadHitNonParallelogram(t *testing.T) {
+	quad := NewQuad(
+		geom.NewVector(0, 0, 0),
+		geom.NewVector(2, 0, 0),
+		geom.NewVector(1, 1, 0),
+		geom.NewVector(0, 1, 0),
+	)
+	ray := geom.NewRay(geom.NewVector(0.75, 0.5, 1), geom.NewVector(0, 0, -1))
+
+	hit, ok := quad.IntersectHit(ray)
+	if !ok {
+		t.Fatalf("Expected ray %#v to hit the quad", ray)
+	}
+
+	checkFloat(t, "u", hit.U, 0.5)
+	checkFloat(t, "v", hit.V, 0.5)
+}
+
+func TestSphereHit(t *testing.T) {
+	sphere := NewSphere(geom.NewVector(0, 0, 5), 2)
+
+	ray := geom.NewRay(geom.NewVector(0, 0, 0), geom.NewVector(0, 0, 0.5))
+	hit, ok := sphere.IntersectHit(ray)
+	if !ok {
+		t.Fatalf("Expected ray %#v to hit the sphere", ray)
+	}
+	checkHit(t, hit, 6, geom.NewVector(0, 0, 3), geom.NewVector(0, 0, -1), true)
+
+	ray = geom.NewRay(geom.NewVector(0, 0, 5), geom.NewVector(0, 1, 0))
+	hit, ok = sphere.IntersectHit(ray)
+	if !ok {
+		t.Fatalf("Expected ray %#v to hit the sphere", ray)
+	}
+	checkHit(t, hit, 2, geom.NewVector(0, 2, 5), geom.NewVector(0, -1, 0), false)
+	checkFloat(t, "v", hit.V, 0)
+}
+
+func checkHit(t *testing.T, hit geom.Hit, tt float64, point, normal geom.Vector, front bool) {
+	t.Helper()
+	checkFloat(t, "t", hit.T, tt)
+	checkVector(t, "point", hit.Point, point)
+	checkVector(t, "normal", hit.Normal, normal)
+	if hit.FrontFace != front {
+		t.Errorf("Expected front face to be %t but it was %t", front, hit.FrontFace)
+	}
+}
+
+func checkVector(t *testing.T, name string, actual, expected geom.Vector) {
+	t.Helper()
+	if geom.Len(geom.Sub(actual, expected)) > 1e-9 {
+		t.Errorf("Expected %s to be %v but it was %v", name, expected, actual)
+	}
+}
+
+func checkFloat(t *testing.T, name string, actual, expected float64) {
+	t.Helper()
+	if math.Abs(actual-expected) > 1e-9 {
+		t.Errorf("Expected %s to be %g but it was %g", name, expected, actual)
+	}
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"math"
+
+	"github.com/fmi/go-homework/geom"
+)
+
+// newInterval returns the interval between the hits `in` and `out`, marking
+// them as an entry and an exit.
+func newInterval(in, out geom.Hit) geom.Interval {
+	in.FrontFace, out.FrontFace = true, false
+	return geom.Interval{In: in, Out: out}
+}
+
+// solidIntervals returns the intervals of a solid whose boundary is crossed by
+// a ray at `hits`, which are sorted by their ray parameters. `inside` tells
+// whether the point of the ray at a given parameter is inside the solid.
+//
+// Every part of the ray between two neighbouring hits is classified by its
+// middle point. Parts of zero length, such as between the two hits of a ray
+// touching the solid, are always inside it, so the boundary belongs to the
+// solid.
+func solidIntervals(hits []geom.Hit, inside func(t float64) bool) []geom.Interval {
+	if len(hits) == 0 {
+		if !inside(0) {
+			return nil
+		}
+		return []geom.Interval{newInterval(geom.Hit{T: math.Inf(-1)}, geom.Hit{T: math.Inf(1)})}
+	}
+
+	// The parts before the first and after the last hit are tested at a
+	// distance comparable to the distances between the hits.
+	step := hits[len(hits)-1].T - hits[0].T
+	if step <= 0 {
+		step = 1
+	}
+
+	var intervals []geom.Interval
+	in := geom.Hit{T: math.Inf(-1)}
+	wasInside := inside(hits[0].T - step)
+	for i, hit := range hits {
+		var isInside bool
+		switch {
+		case i == len(hits)-1:
+			isInside = inside(hit.T + step)
+		case hits[i+1].T == hit.T:
+			isInside = true
+		default:
+			isInside = inside((hit.T + hits[i+1].T) / 2)
+		}
+
+		switch {
+		case !wasInside && isInside:
+			in = hit
+		case wasInside && !isInside:
+			intervals = append(intervals, newInterval(in, hit))
+		}
+		wasInside = isInside
+	}
+
+	if wasInside {
+		intervals = append(intervals, newInterval(in, geom.Hit{T: math.Inf(1)}))
+	}
+	return intervals
+}
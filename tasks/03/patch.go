@@ -0,0 +1,166 @@
+package main
+
+import (
+	"math"
+
+	"github.com/fmi/go-homework/geom"
+)
+
+// BilinearPatch is an Intersectable which represents the bilinear surface
+// spanned by four points which do not have to be in one plane:
+//
+//	P(u, v) = (1-u)(1-v)*a + u(1-v)*b + uv*c + (1-u)v*d
+//
+// for u and v in [0, 1]. For coplanar points which form a parallelogram it is
+// the same as a Quad, otherwise it is curved.
+type BilinearPatch struct {
+	a, b, c, d geom.Vector
+	tolerance  geom.Tolerance
+}
+
+// NewBilinearPatch returns a new BilinearPatch with corners `a`, `b`, `c` and
+// `d`, in the same order as the vertices of a Quad.
+func NewBilinearPatch(a, b, c, d geom.Vector) *BilinearPatch {
+	return &BilinearPatch{a: a, b: b, c: c, d: d, tolerance: geom.DefaultTolerance}
+}
+
+// WithTolerance returns a copy of the patch which is intersected with
+// `tolerance`. NewBilinearPatch uses geom.DefaultTolerance.
+func (p *BilinearPatch) WithTolerance(tolerance geom.Tolerance) *BilinearPatch {
+	patch := *p
+	patch.tolerance = tolerance
+	return &patch
+}
+
+// Intersect implements the geom.Intersecatble interface.
+func (p *BilinearPatch) Intersect(ray geom.Ray) bool {
+	return p.Occluded(ray, math.Inf(1))
+}
+
+// IntersectHit implements the geom.Intersector interface.
+func (p *BilinearPatch) IntersectHit(ray geom.Ray) (geom.Hit, bool) {
+	return p.ClosestHit(ray, 0, math.Inf(1))
+}
+
+// Occluded implements the geom.Occluder interface.
+func (p *BilinearPatch) Occluded(ray geom.Ray, tMax float64) bool {
+	_, ok := p.ClosestHit(ray, 0, tMax)
+	return ok
+}
+
+// ClosestHit implements the geom.ClosestHitter interface. It uses the
+// algorithm from "Cool Patches: A Geometric Approach to Ray/Bilinear Patch
+// Intersections" by Alexander Reshetov (Ray Tracing Gems, 2019). A ray
+// crosses the patch at most twice. The u coordinates of the crossings are
+// the roots of a quadratic equation, and v and the ray parameter follow from
+// intersecting the ray with the line of the patch at the given u.
+//
+// Points closer to the edges than the tolerance of the patch, relative to the
+// longest edge, are part of it.
+//
+// The U and V of the returned hit are the bilinear coordinates of the hit
+// point, so U goes along the edge from the first to the second corner and V
+// along the edge from the first to the fourth corner.
+func (p *BilinearPatch) ClosestHit(ray geom.Ray, tMin, tMax float64) (geom.Hit, bool) {
+	tMin, tMax = ray.Clip(tMin, tMax)
+	dir := ray.Direction
+
+	e10 := geom.Sub(p.b, p.a)
+	e11 := geom.Sub(p.c, p.b)
+	e00 := geom.Sub(p.d, p.a)
+	qn := geom.Cross(e10, geom.Sub(p.d, p.c))
+	q00 := geom.Sub(p.a, ray.Origin)
+	q10 := geom.Sub(p.b, ray.Origin)
+
+	// The equation for u is qa + qb*u + qc*u² = 0.
+	qa := geom.Dot(geom.Cross(q00, dir), e00)
+	qc := geom.Dot(qn, dir)
+	qb := geom.Dot(geom.Cross(q10, dir), e11) - qa - qc
+
+	var roots [2]float64
+	switch {
+	case qc != 0:
+		t0, t1, ok := quadratic(qc, qb, qa, 0)
+		if !ok {
+			return geom.Hit{}, false
+		}
+		roots = [2]float64{t0, t1}
+	case qb != 0:
+		roots = [2]float64{-qa / qb, math.NaN()}
+	default:
+		return geom.Hit{}, false
+	}
+
+	eps := p.tolerance.Epsilon(p.size())
+	uSlack := eps / math.Min(geom.Len(e10), geom.Len(geom.Sub(p.c, p.d)))
+
+	found := false
+	var tHit, uHit, vHit float64
+	for _, u := range roots {
+		if !(u >= -uSlack && u <= 1+uSlack) {
+			continue
+		}
+		u = math.Max(0, math.Min(1, u))
+
+		// The line of the patch at u goes through pa in direction pb.
+		pa := lerp(q00, q10, u)
+		pb := lerp(e00, e11, u)
+		n := geom.Cross(dir, pb)
+		det := geom.Dot(n, n)
+		if det == 0 {
+			continue
+		}
+		n = geom.Cross(n, pa)
+		t := geom.Dot(n, pb) / det
+		v := geom.Dot(n, dir) / det
+
+		vSlack := eps / geom.Len(pb)
+		if t < tMin || t > tMax || v < -vSlack || v > 1+vSlack || (found && t >= tHit) {
+			continue
+		}
+		found, tHit, uHit, vHit = true, t, u, math.Max(0, math.Min(1, v))
+	}
+
+	if !found {
+		return geom.Hit{}, false
+	}
+
+	hit := geom.Hit{
+		T:     tHit,
+		Point: ray.At(tHit),
+		U:     uHit,
+		V:     vHit,
+	}
+	hit.SetFaceNormal(ray, p.normal(uHit, vHit))
+
+	return hit, true
+}
+
+// normal returns the normal, not normalized, of the patch at (`u`, `v`). It
+// is the cross product of the partial derivatives along u and v.
+func (p *BilinearPatch) normal(u, v float64) geom.Vector {
+	du := lerp(geom.Sub(p.b, p.a), geom.Sub(p.c, p.d), v)
+	dv := lerp(geom.Sub(p.d, p.a), geom.Sub(p.c, p.b), u)
+	return geom.Cross(du, dv)
+}
+
+// size returns the length of the longest edge of the patch.
+func (p *BilinearPatch) size() float64 {
+	corners := [4]geom.Vector{p.a, p.b, p.c, p.d}
+	var size float64
+	for i, v := range corners {
+		size = math.Max(size, geom.Len(geom.Sub(corners[(i+1)%4], v)))
+	}
+	return size
+}
+
+// Bounds implements the geom.Bounded interface. The patch is inside the
+// convex hull of its corners.
+func (p *BilinearPatch) Bounds() geom.AABB {
+	return geom.NewAABB(p.a, p.b, p.c, p.d)
+}
+
+// lerp returns the linear interpolation between `a` and `b` at `s`.
+func lerp(a, b geom.Vector, s float64) geom.Vector {
+	return geom.Add(a, geom.Mul(geom.Sub(b, a), s))
+}
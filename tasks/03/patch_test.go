@@ -0,0 +1,153 @@
+package main
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/fmi/go-homework/geom"
+)
+
+func TestBilinearPatchSaddle(t *testing.T) {
+	// The surface z = 2xy over the unit square.
+	patch := NewBilinearPatch(
+		geom.NewVector(0, 0, 0),
+		geom.NewVector(1, 0, 0),
+		geom.NewVector(1, 1, 2),
+		geom.NewVector(0, 1, 0),
+	)
+
+	tests := []struct {
+		description string
+		x, y        float64
+	}{
+		{"center", 0.5, 0.5},
+		{"off center", 0.25, 0.75},
+		{"twisted corner", 0.9, 0.8},
+		{"corner", 1, 1},
+		{"edge", 0, 0.3},
+	}
+
+	for _, test := range tests {
+		ray := geom.NewRay(geom.NewVector(test.x, test.y, 5), geom.NewVector(0, 0, -2))
+		hit, ok := patch.IntersectHit(ray)
+		if !ok {
+			t.Errorf("%s: expected the ray to hit the patch", test.description)
+			continue
+		}
+
+		z := 2 * test.x * test.y
+		normal := geom.Normalize(geom.NewVector(-2*test.y, -2*test.x, 1))
+		checkHit(t, hit, (5-z)/2, geom.NewVector(test.x, test.y, z), normal, true)
+		checkFloat(t, "u", hit.U, test.x)
+		checkFloat(t, "v", hit.V, test.y)
+	}
+
+	misses := []geom.Ray{
+		geom.NewRay(geom.NewVector(1+1e-6, 0.5, 5), geom.NewVector(0, 0, -1)),
+		geom.NewRay(geom.NewVector(0.5, 0.5, 5), geom.NewVector(0, 0, 1)),
+	}
+	for _, ray := range misses {
+		if patch.Intersect(ray) {
+			t.Errorf("Expected ray %+v to miss the patch", ray)
+		}
+	}
+
+	ray := geom.NewRay(geom.NewVector(0.5, 0.5, 5), geom.NewVector(0, 0, -1))
+	if patch.Occluded(ray, 4) {
+		t.Errorf("Expected the patch to be farther than the maximum distance")
+	}
+}
+
+func TestBilinearPatchTwoHits(t *testing.T) {
+	// The surface z = xy crosses the line x = s, y = 1 - s, z = 0.16 at
+	// s = 0.2 and s = 0.8.
+	patch := NewBilinearPatch(
+		geom.NewVector(0, 0, 0),
+		geom.NewVector(1, 0, 0),
+		geom.NewVector(1, 1, 1),
+		geom.NewVector(0, 1, 0),
+	)
+	ray := geom.NewRay(geom.NewVector(0, 1, 0.16), geom.NewVector(1, -1, 0))
+
+	hit, ok := patch.ClosestHit(ray, 0, math.Inf(1))
+	if !ok {
+		t.Fatalf("Expected the ray to hit the patch")
+	}
+	checkFloat(t, "t", hit.T, 0.2)
+	if !hit.FrontFace {
+		t.Errorf("Expected the first hit to be on the front face")
+	}
+
+	hit, ok = patch.ClosestHit(ray, 0.5, math.Inf(1))
+	if !ok {
+		t.Fatalf("Expected the ray to hit the patch again")
+	}
+	checkFloat(t, "t", hit.T, 0.8)
+	if hit.FrontFace {
+		t.Errorf("Expected the second hit to be on the back face")
+	}
+}
+
+func TestBilinearPatchPlanar(t *testing.T) {
+	rnd := rand.New(rand.NewSource(3))
+	a, b := geom.NewVector(-1, -1, 1), geom.NewVector(2, -1, 2)
+	c, d := geom.NewVector(2, 2, 2), geom.NewVector(-1, 2, 1)
+	quad := NewQuad(a, b, c, d)
+	patch := NewBilinearPatch(a, b, c, d)
+
+	for i := 0; i < 1000; i++ {
+		target := geom.Add(geom.NewVector(0.5, 0.5, 1.5), randomVector(rnd, 2))
+		ray := towards(randomVector(rnd, 10), target)
+		expected, ok1 := quad.IntersectHit(ray)
+		hit, ok2 := patch.IntersectHit(ray)
+		if ok1 != ok2 {
+			t.Errorf("Patch and quad disagree for ray %+v", ray)
+			continue
+		}
+		if !ok1 {
+			continue
+		}
+
+		checkHit(t, hit, expected.T, expected.Point, expected.Normal, expected.FrontFace)
+		checkFloat(t, "u", hit.U, expected.U)
+		checkFloat(t, "v", hit.V, expected.V)
+	}
+}
+
+func TestQuadBilinearFallback(t *testing.T) {
+	twisted := NewQuad(
+		geom.NewVector(0, 0, 0),
+		geom.NewVector(1, 0, 0),
+		geom.NewVector(1, 1, 1),
+		geom.NewVector(0, 1, 0),
+	)
+	fallback := twisted.WithBilinearFallback()
+
+	// Above the lower triangle of the quad, but below the patch.
+	ray := geom.NewRay(geom.NewVector(0.9, 0.8, 0.5), geom.NewVector(0, 0, 1))
+	hit, ok := fallback.IntersectHit(ray)
+	if !ok {
+		t.Fatalf("Expected the ray to hit the twisted quad")
+	}
+	checkFloat(t, "t", hit.T, 0.72-0.5)
+	checkFloat(t, "u", hit.U, 0.9)
+	checkFloat(t, "v", hit.V, 0.8)
+
+	planar := NewQuad(
+		geom.NewVector(0, 0, 0),
+		geom.NewVector(1, 0, 0),
+		geom.NewVector(1, 1, 0),
+		geom.NewVector(0, 1, 0),
+	).WithBilinearFallback()
+	if planar.patch != nil {
+		t.Errorf("Expected planar quads not to fall back to patches")
+	}
+
+	for _, test := range quadTests {
+		quad := test.quad.(*Quad).WithBilinearFallback()
+		if ok := quad.Intersect(test.ray); ok != test.intersected {
+			t.Errorf("%s: expected intersection to be %t but it was %t", test.description, test.intersected, ok)
+		}
+	}
+}
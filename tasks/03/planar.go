@@ -0,0 +1,190 @@
+package main
+
+import (
+	"math"
+
+	"github.com/fmi/go-homework/geom"
+)
+
+// Plane is an Intersectable which represents an infinite plane in the 3D space.
+type Plane struct {
+	p, normal geom.Vector
+
+	// tangent and bitangent complete the normal to an orthonormal basis.
+	// They define the U and V coordinates of the hits.
+	tangent, bitangent geom.Vector
+
+	tolerance geom.Tolerance
+}
+
+// NewPlane returns a new Plane which passes through the point `p` and is
+// perpendicular to `normal`.
+func NewPlane(p, normal geom.Vector) *Plane {
+	n := geom.Normalize(normal)
+	tangent, bitangent := orthonormalBasis(n)
+	return &Plane{
+		p:         p,
+		normal:    n,
+		tangent:   tangent,
+		bitangent: bitangent,
+		tolerance: geom.DefaultTolerance,
+	}
+}
+
+// WithTolerance returns a copy of the plane which is intersected with
+// `tolerance`. NewPlane uses geom.DefaultTolerance.
+func (pl *Plane) WithTolerance(tolerance geom.Tolerance) *Plane {
+	plane := *pl
+	plane.tolerance = tolerance
+	return &plane
+}
+
+// Intersect implements the geom.Intersecatble interface.
+func (pl *Plane) Intersect(ray geom.Ray) bool {
+	return pl.Occluded(ray, math.Inf(1))
+}
+
+// IntersectHit implements the geom.Intersector interface.
+func (pl *Plane) IntersectHit(ray geom.Ray) (geom.Hit, bool) {
+	return pl.ClosestHit(ray, 0, math.Inf(1))
+}
+
+// Occluded implements the geom.Occluder interface.
+func (pl *Plane) Occluded(ray geom.Ray, tMax float64) bool {
+	_, ok := pl.ClosestHit(ray, 0, tMax)
+	return ok
+}
+
+// ClosestHit implements the geom.ClosestHitter interface. Rays parallel to
+// the plane, as decided by its tolerance, never intersect it, even when they
+// lie in it.
+//
+// The U and V of the returned hit are the coordinates of the hit point along
+// two perpendicular directions in the plane, measured from the point used for
+// creating the plane.
+func (pl *Plane) ClosestHit(ray geom.Ray, tMin, tMax float64) (geom.Hit, bool) {
+	tMin, tMax = ray.Clip(tMin, tMax)
+	t, ok := planeHit(pl.tolerance, pl.p, pl.normal, ray)
+	if !ok || t < tMin || t > tMax {
+		return geom.Hit{}, false
+	}
+
+	hit := geom.Hit{T: t, Point: ray.At(t)}
+	local := geom.Sub(hit.Point, pl.p)
+	hit.U, hit.V = geom.Dot(local, pl.tangent), geom.Dot(local, pl.bitangent)
+	hit.SetFaceNormal(ray, pl.normal)
+
+	return hit, true
+}
+
+// Bounds implements the geom.Bounded interface. Planes are infinite.
+func (pl *Plane) Bounds() geom.AABB {
+	return geom.InfiniteAABB()
+}
+
+// Disk is an Intersectable which represents a flat disk in the 3D space.
+type Disk struct {
+	center, normal     geom.Vector
+	tangent, bitangent geom.Vector
+	r                  float64
+	tolerance          geom.Tolerance
+}
+
+// NewDisk returns a new Disk with center `center` and radius `r` which is
+// perpendicular to `normal`.
+func NewDisk(center, normal geom.Vector, r float64) *Disk {
+	n := geom.Normalize(normal)
+	tangent, bitangent := orthonormalBasis(n)
+	return &Disk{
+		center:    center,
+		normal:    n,
+		tangent:   tangent,
+		bitangent: bitangent,
+		r:         r,
+		tolerance: geom.DefaultTolerance,
+	}
+}
+
+// WithTolerance returns a copy of the disk which is intersected with
+// `tolerance`. NewDisk uses geom.DefaultTolerance.
+func (d *Disk) WithTolerance(tolerance geom.Tolerance) *Disk {
+	disk := *d
+	disk.tolerance = tolerance
+	return &disk
+}
+
+// Intersect implements the geom.Intersecatble interface.
+func (d *Disk) Intersect(ray geom.Ray) bool {
+	return d.Occluded(ray, math.Inf(1))
+}
+
+// IntersectHit implements the geom.Intersector interface.
+func (d *Disk) IntersectHit(ray geom.Ray) (geom.Hit, bool) {
+	return d.ClosestHit(ray, 0, math.Inf(1))
+}
+
+// Occluded implements the geom.Occluder interface.
+func (d *Disk) Occluded(ray geom.Ray, tMax float64) bool {
+	_, ok := d.ClosestHit(ray, 0, tMax)
+	return ok
+}
+
+// ClosestHit implements the geom.ClosestHitter interface. The edge of the
+// disk is part of it, as well as points within its tolerance from the edge,
+// relative to the radius.
+//
+// The U of the returned hit is the angle around the center, scaled to [0, 1]
+// and V is the distance from the center, divided by the radius.
+func (d *Disk) ClosestHit(ray geom.Ray, tMin, tMax float64) (geom.Hit, bool) {
+	tMin, tMax = ray.Clip(tMin, tMax)
+	t, ok := planeHit(d.tolerance, d.center, d.normal, ray)
+	if !ok || t < tMin || t > tMax {
+		return geom.Hit{}, false
+	}
+
+	hit := geom.Hit{T: t, Point: ray.At(t)}
+	local := geom.Sub(hit.Point, d.center)
+	x, y := geom.Dot(local, d.tangent), geom.Dot(local, d.bitangent)
+	dist := math.Sqrt(x*x + y*y)
+	if dist > d.r+d.tolerance.Epsilon(d.r) {
+		return geom.Hit{}, false
+	}
+
+	hit.U = (math.Atan2(y, x) + math.Pi) / (2 * math.Pi)
+	hit.V = math.Min(dist/d.r, 1)
+	hit.SetFaceNormal(ray, d.normal)
+
+	return hit, true
+}
+
+// Bounds implements the geom.Bounded interface.
+func (d *Disk) Bounds() geom.AABB {
+	n := d.normal
+	e := geom.NewVector(
+		d.r*math.Sqrt(math.Max(0, 1-n.X*n.X)),
+		d.r*math.Sqrt(math.Max(0, 1-n.Y*n.Y)),
+		d.r*math.Sqrt(math.Max(0, 1-n.Z*n.Z)),
+	)
+	return geom.NewAABB(geom.Sub(d.center, e), geom.Add(d.center, e))
+}
+
+// planeHit returns the ray parameter at which `ray` crosses the plane through
+// `p` with unit normal `n`. Its second return value is false when the ray is
+// parallel to the plane, as decided by `tol`.
+func planeHit(tol geom.Tolerance, p, n geom.Vector, ray geom.Ray) (float64, bool) {
+	if tol.Parallel(ray.Direction, n) {
+		return 0, false
+	}
+	return geom.Dot(n, geom.Sub(p, ray.Origin)) / geom.Dot(n, ray.Direction), true
+}
+
+// orthonormalBasis returns two unit vectors which are perpendicular to each
+// other and to the unit vector `n`.
+func orthonormalBasis(n geom.Vector) (geom.Vector, geom.Vector) {
+	helper := geom.NewVector(1, 0, 0)
+	if math.Abs(n.X) > 0.9 {
+		helper = geom.NewVector(0, 1, 0)
+	}
+	tangent := geom.Normalize(geom.Cross(helper, n))
+	return tangent, geom.Cross(n, tangent)
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"math"
+	"testing"
+
+	"github.com/fmi/go-homework/geom"
+)
+
+func TestPlanarFigures(t *testing.T) {
+	floor := NewPlane(geom.NewVector(0, -1, 0), geom.NewVector(0, 1, 0))
+	tilted := NewPlane(geom.NewVector(1, 0, 0), geom.NewVector(1, 1, 0))
+	disk := NewDisk(geom.NewVector(0, 0, 5), geom.NewVector(0, 0, 1), 2)
+	tiltedDisk := NewDisk(geom.NewVector(0, 0, 0), geom.NewVector(1, 0, 1), 1)
+
+	tests := []struct {
+		description string
+		figure      geom.ClosestHitter
+		ray         geom.Ray
+		intersected bool
+		t           float64
+		front       bool
+	}{
+		{"plane from above", floor, geom.NewRay(geom.NewVector(0, 5, 0), geom.NewVector(1, -1, 0)), true, 6, true},
+		{"plane from below", floor, geom.NewRay(geom.NewVector(3, -5, 0), geom.NewVector(0, 2, 0)), true, 2, false},
+		{"plane far away", floor, geom.NewRay(geom.NewVector(1e9, 0, 1e9), geom.NewVector(0, -1, 0)), true, 1, true},
+		{"plane opposite direction", floor, geom.NewRay(geom.NewVector(0, 5, 0), geom.NewVector(0, 1, 0)), false, 0, false},
+		{"plane parallel ray", floor, geom.NewRay(geom.NewVector(0, 5, 0), geom.NewVector(1, 0, 1)), false, 0, false},
+		{"tilted plane", tilted, geom.NewRay(geom.NewVector(0, 0, 0), geom.NewVector(1, 0, 0)), true, 1, false},
+		{"disk center", disk, geom.NewRay(geom.NewVector(0, 0, 0), geom.NewVector(0, 0, 1)), true, 5, false},
+		{"disk edge", disk, geom.NewRay(geom.NewVector(2, 0, 10), geom.NewVector(0, 0, -1)), true, 5, true},
+		{"disk near miss", disk, geom.NewRay(geom.NewVector(1.5, 1.5, 0), geom.NewVector(0, 0, 1)), false, 0, false},
+		{"tilted disk", tiltedDisk, geom.NewRay(geom.NewVector(0.5, 0, -5), geom.NewVector(0, 0, 1)), true, 4.5, false},
+		{"tilted disk near miss", tiltedDisk, geom.NewRay(geom.NewVector(0.75, 0, -5), geom.NewVector(0, 0, 1)), false, 0, false},
+	}
+
+	for _, test := range tests {
+		hit, ok := test.figure.ClosestHit(test.ray, 0, math.Inf(1))
+		if ok != test.intersected {
+			t.Errorf("%s: expected intersection to be %t but it was %t", test.description, test.intersected, ok)
+			continue
+		}
+		if !ok {
+			continue
+		}
+		if math.Abs(hit.T-test.t) > 1e-9 {
+			t.Errorf("%s: expected hit at %g but it was at %g", test.description, test.t, hit.T)
+		}
+		if hit.FrontFace != test.front {
+			t.Errorf("%s: expected front face to be %t but it was %t", test.description, test.front, hit.FrontFace)
+		}
+	}
+}
+
+func TestPlanarBounds(t *testing.T) {
+	if b := NewPlane(geom.Vector{}, geom.NewVector(0, 1, 0)).Bounds(); b.IsFinite() {
+		t.Errorf("Expected planes to have infinite bounds but got %v", b)
+	}
+
+	b := NewDisk(geom.NewVector(1, 1, 1), geom.NewVector(0, 0, 1), 2).Bounds()
+	if b.Min != geom.NewVector(-1, -1, 1) || b.Max != geom.NewVector(3, 3, 1) {
+		t.Errorf("Unexpected disk bounds %v", b)
+	}
+}
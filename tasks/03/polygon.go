@@ -0,0 +1,343 @@
+package main
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/fmi/go-homework/geom"
+)
+
+// Polygon is an Intersectable which represents a planar polygon with any
+// number of vertices. It may be convex or concave. Its edges and vertices
+// are part of it.
+//
+// NewPolygon accepts any vertices, so use Validate for checking that they
+// really form a simple planar polygon. For non-planar vertices the polygon
+// lies in the plane which fits them best.
+type Polygon struct {
+	vertices []geom.Vector
+
+	// The polygon lies in the plane through center with unit normal
+	// normal. tangent goes along the first edge and together with
+	// bitangent defines the 2D coordinates in the plane, measured from
+	// the first vertex.
+	center, normal     geom.Vector
+	tangent, bitangent geom.Vector
+
+	// points are the vertices in the 2D coordinates of the plane.
+	points [][2]float64
+
+	// size is the length of the longest edge.
+	size float64
+
+	tolerance geom.Tolerance
+}
+
+// NewPolygon returns a new Polygon with vertices `vertices` in this order.
+// The polygon faces the side from which the vertices are ordered counter
+// clockwise.
+func NewPolygon(vertices ...geom.Vector) *Polygon {
+	p := &Polygon{vertices: vertices, tolerance: geom.DefaultTolerance}
+	if len(vertices) == 0 {
+		return p
+	}
+
+	// Newell's method gives the normal of the plane which fits the
+	// vertices best. It works for concave polygons too.
+	var normal, center geom.Vector
+	var size float64
+	for i, a := range vertices {
+		b := vertices[(i+1)%len(vertices)]
+		normal.X += (a.Y - b.Y) * (a.Z + b.Z)
+		normal.Y += (a.Z - b.Z) * (a.X + b.X)
+		normal.Z += (a.X - b.X) * (a.Y + b.Y)
+		center = geom.Add(center, a)
+		size = math.Max(size, geom.Len(geom.Sub(b, a)))
+	}
+	p.normal = geom.Normalize(normal)
+	p.center = geom.Mul(center, 1/float64(len(vertices)))
+	p.size = size
+
+	if len(vertices) > 1 {
+		edge := geom.Sub(vertices[1], vertices[0])
+		p.tangent = geom.Normalize(geom.Sub(edge, geom.Mul(p.normal, geom.Dot(edge, p.normal))))
+	}
+	if p.tangent == (geom.Vector{}) {
+		p.tangent, _ = orthonormalBasis(p.normal)
+	}
+	p.bitangent = geom.Cross(p.normal, p.tangent)
+
+	p.points = make([][2]float64, len(vertices))
+	for i, v := range vertices {
+		p.points[i] = p.project(v)
+	}
+
+	return p
+}
+
+// project returns the 2D coordinates in the plane of the polygon of the
+// projection of `v` on it.
+func (p *Polygon) project(v geom.Vector) [2]float64 {
+	local := geom.Sub(v, p.vertices[0])
+	return [2]float64{geom.Dot(local, p.tangent), geom.Dot(local, p.bitangent)}
+}
+
+// WithTolerance returns a copy of the polygon which is intersected and
+// validated with `tolerance`. NewPolygon uses geom.DefaultTolerance.
+func (p *Polygon) WithTolerance(tolerance geom.Tolerance) *Polygon {
+	polygon := *p
+	polygon.tolerance = tolerance
+	return &polygon
+}
+
+// Vertices returns the vertices of the polygon.
+func (p *Polygon) Vertices() []geom.Vector {
+	return p.vertices
+}
+
+// Validate returns an error when the polygon has less than three vertices,
+// has no area, its vertices are not in one plane or its edges cross each
+// other. Vertices which are within the tolerance of the polygon from its plane
+// are considered in it.
+func (p *Polygon) Validate() error {
+	n := len(p.vertices)
+	if n < 3 {
+		return fmt.Errorf("polygon has %d vertices, at least 3 are needed", n)
+	}
+	if p.normal == (geom.Vector{}) {
+		return fmt.Errorf("polygon has no area")
+	}
+
+	if !p.planar() {
+		i, d := p.farthestVertex()
+		return fmt.Errorf("polygon is not planar: vertex %d is %g away from its plane", i, d)
+	}
+
+	for i := 0; i < n; i++ {
+		if p.points[i] == p.points[(i+1)%n] {
+			return fmt.Errorf("polygon edge %d has no length", i)
+		}
+	}
+
+	for i := 0; i < n; i++ {
+		a, b := p.points[i], p.points[(i+1)%n]
+
+		// Neighbouring edges share a vertex, so they only cross when
+		// the second one turns back over the first.
+		c := p.points[(i+2)%n]
+		if orientation(a, b, c) == 0 && (c[0]-b[0])*(a[0]-b[0])+(c[1]-b[1])*(a[1]-b[1]) > 0 {
+			return fmt.Errorf("polygon is self-intersecting: edges %d and %d overlap", i, (i+1)%n)
+		}
+
+		for j := i + 2; j < n; j++ {
+			if i == 0 && j == n-1 {
+				continue
+			}
+			if segmentsIntersect(a, b, p.points[j], p.points[(j+1)%n]) {
+				return fmt.Errorf("polygon is self-intersecting: edges %d and %d cross", i, j)
+			}
+		}
+	}
+
+	return nil
+}
+
+// farthestVertex returns the index of the vertex which is farthest from the
+// plane of the polygon and its distance to it.
+func (p *Polygon) farthestVertex() (int, float64) {
+	index, farthest := 0, 0.0
+	for i, v := range p.vertices {
+		if d := math.Abs(geom.Dot(geom.Sub(v, p.center), p.normal)); d > farthest {
+			index, farthest = i, d
+		}
+	}
+	return index, farthest
+}
+
+// planar tells whether all vertices are within the tolerance of the polygon
+// from its plane.
+func (p *Polygon) planar() bool {
+	_, d := p.farthestVertex()
+	return d <= p.tolerance.Epsilon(p.size)
+}
+
+// Intersect implements the geom.Intersecatble interface.
+func (p *Polygon) Intersect(ray geom.Ray) bool {
+	return p.Occluded(ray, math.Inf(1))
+}
+
+// IntersectHit implements the geom.Intersector interface.
+func (p *Polygon) IntersectHit(ray geom.Ray) (geom.Hit, bool) {
+	return p.ClosestHit(ray, 0, math.Inf(1))
+}
+
+// Occluded implements the geom.Occluder interface.
+func (p *Polygon) Occluded(ray geom.Ray, tMax float64) bool {
+	_, ok := p.ClosestHit(ray, 0, tMax)
+	return ok
+}
+
+// ClosestHit implements the geom.ClosestHitter interface. The ray is
+// intersected with the plane of the polygon and the hit point is tested
+// against the polygon in the 2D coordinates of the plane with the even-odd
+// rule. Points closer to the edges than the tolerance of the polygon,
+// relative to the longest edge, are part of it.
+//
+// The U and V of the returned hit are the coordinates of the hit point in the
+// plane, measured from the first vertex. U goes along the first edge.
+func (p *Polygon) ClosestHit(ray geom.Ray, tMin, tMax float64) (geom.Hit, bool) {
+	if len(p.vertices) < 3 || p.normal == (geom.Vector{}) {
+		return geom.Hit{}, false
+	}
+
+	tMin, tMax = ray.Clip(tMin, tMax)
+	t, ok := planeHit(p.tolerance, p.center, p.normal, ray)
+	if !ok || t < tMin || t > tMax {
+		return geom.Hit{}, false
+	}
+
+	hit := geom.Hit{T: t, Point: ray.At(t)}
+	q := p.project(hit.Point)
+	if !p.contains(q) {
+		return geom.Hit{}, false
+	}
+
+	hit.U, hit.V = q[0], q[1]
+	hit.SetFaceNormal(ray, p.normal)
+
+	return hit, true
+}
+
+// ClosestPoint implements the geom.Distancer interface. Points whose
+// projections on the plane of the polygon are inside it are closest to their
+// projections. Otherwise the closest point is on the nearest edge, like for
+// projections which are exactly on an edge. The U and V of the result are the
+// coordinates of the closest point in the plane, as for the hits of the
+// polygon.
+func (p *Polygon) ClosestPoint(v geom.Vector) geom.Closest {
+	if len(p.vertices) == 0 {
+		return geom.Closest{Distance: math.Inf(1)}
+	}
+
+	q := p.project(v)
+	closest := geom.Closest{Feature: geom.FeatureFace, U: q[0], V: q[1]}
+
+	// Find the nearest edge and the point on it.
+	edge, s, best := 0, 0.0, math.Inf(1)
+	for i, a := range p.points {
+		b := p.points[(i+1)%len(p.points)]
+		if d := segmentDistance(q, a, b); d < best {
+			edge, s, best = i, segmentParameter(q, a, b), d
+		}
+	}
+
+	if best == 0 || len(p.vertices) < 3 || p.normal == (geom.Vector{}) || !p.inside(q) {
+		a, b := p.points[edge], p.points[(edge+1)%len(p.points)]
+		closest.U = a[0] + s*(b[0]-a[0])
+		closest.V = a[1] + s*(b[1]-a[1])
+		switch s {
+		case 0:
+			closest.Feature, closest.Index = geom.FeatureVertex, edge
+		case 1:
+			closest.Feature, closest.Index = geom.FeatureVertex, (edge+1)%len(p.points)
+		default:
+			closest.Feature, closest.Index = geom.FeatureEdge, edge
+		}
+	}
+
+	closest.Point = geom.Add(p.vertices[0], geom.Add(
+		geom.Mul(p.tangent, closest.U),
+		geom.Mul(p.bitangent, closest.V),
+	))
+	closest.Distance = geom.Len(geom.Sub(closest.Point, v))
+
+	return closest
+}
+
+// Distance implements the geom.Distancer interface.
+func (p *Polygon) Distance(v geom.Vector) float64 {
+	return p.ClosestPoint(v).Distance
+}
+
+// contains tells whether the point `q` in the 2D coordinates of the plane is
+// in the polygon or within its tolerance from the edges.
+func (p *Polygon) contains(q [2]float64) bool {
+	if p.inside(q) {
+		return true
+	}
+
+	eps := p.tolerance.Epsilon(p.size)
+	for i, a := range p.points {
+		if segmentDistance(q, a, p.points[(i+1)%len(p.points)]) <= eps {
+			return true
+		}
+	}
+	return false
+}
+
+// inside tells whether the point `q` in the 2D coordinates of the plane is in
+// the polygon by the even-odd rule. It counts the edges crossed by the ray
+// from q in the positive X direction.
+func (p *Polygon) inside(q [2]float64) bool {
+	inside := false
+	for i, a := range p.points {
+		b := p.points[(i+1)%len(p.points)]
+		if (a[1] > q[1]) != (b[1] > q[1]) {
+			x := a[0] + (q[1]-a[1])*(b[0]-a[0])/(b[1]-a[1])
+			if q[0] < x {
+				inside = !inside
+			}
+		}
+	}
+	return inside
+}
+
+// Bounds implements the geom.Bounded interface.
+func (p *Polygon) Bounds() geom.AABB {
+	return geom.NewAABB(p.vertices...)
+}
+
+// orientation returns a positive number when `a`, `b` and `c` are ordered
+// counter clockwise, a negative one when they are ordered clockwise and zero
+// when they are on a line.
+func orientation(a, b, c [2]float64) float64 {
+	return (b[0]-a[0])*(c[1]-a[1]) - (b[1]-a[1])*(c[0]-a[0])
+}
+
+// segmentsIntersect tells whether the segments `a`-`b` and `c`-`d` have a
+// common point.
+func segmentsIntersect(a, b, c, d [2]float64) bool {
+	o1, o2 := orientation(a, b, c), orientation(a, b, d)
+	o3, o4 := orientation(c, d, a), orientation(c, d, b)
+	if ((o1 > 0 && o2 < 0) || (o1 < 0 && o2 > 0)) && ((o3 > 0 && o4 < 0) || (o3 < 0 && o4 > 0)) {
+		return true
+	}
+
+	// The segments touch when an end of one of them is on the other.
+	return (o1 == 0 && onSegment(c, a, b)) || (o2 == 0 && onSegment(d, a, b)) ||
+		(o3 == 0 && onSegment(a, c, d)) || (o4 == 0 && onSegment(b, c, d))
+}
+
+// onSegment tells whether `q`, which is on the line through `a` and `b`, is
+// between them.
+func onSegment(q, a, b [2]float64) bool {
+	return math.Min(a[0], b[0]) <= q[0] && q[0] <= math.Max(a[0], b[0]) &&
+		math.Min(a[1], b[1]) <= q[1] && q[1] <= math.Max(a[1], b[1])
+}
+
+// segmentDistance returns the distance from `q` to the segment `a`-`b`.
+func segmentDistance(q, a, b [2]float64) float64 {
+	s := segmentParameter(q, a, b)
+	return math.Hypot(q[0]-a[0]-s*(b[0]-a[0]), q[1]-a[1]-s*(b[1]-a[1]))
+}
+
+// segmentParameter returns the parameter of the point of the segment `a`-`b`
+// closest to `q`, from 0 at `a` to 1 at `b`.
+func segmentParameter(q, a, b [2]float64) float64 {
+	abx, aby := b[0]-a[0], b[1]-a[1]
+	l := abx*abx + aby*aby
+	if l == 0 {
+		return 0
+	}
+	return math.Max(0, math.Min(1, ((q[0]-a[0])*abx+(q[1]-a[1])*aby)/l))
+}
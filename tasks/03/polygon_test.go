@@ -0,0 +1,211 @@
+package main
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/fmi/go-homework/geom"
+)
+
+func TestPolygonAsQuad(t *testing.T) {
+	for _, test := range quadTests {
+		q := test.quad.(*Quad)
+		polygon := NewPolygon(
+			vectorToGeom(q.vertices[0]),
+			vectorToGeom(q.vertices[1]),
+			vectorToGeom(q.vertices[2]),
+			vectorToGeom(q.vertices[3]),
+		)
+
+		// Some of the quads are not planar, which polygons do not
+		// support.
+		if polygon.Validate() != nil {
+			continue
+		}
+
+		hit, ok := polygon.IntersectHit(test.ray)
+		if ok != test.intersected {
+			t.Errorf("%s: expected intersection to be %t but it was %t", test.description, test.intersected, ok)
+			continue
+		}
+		if !ok {
+			continue
+		}
+
+		expected, _ := q.IntersectHit(test.ray)
+		if math.Abs(hit.T-expected.T) > 1e-9 || hit.FrontFace != expected.FrontFace {
+			t.Errorf("%s: expected hit %+v but got %+v", test.description, expected, hit)
+		}
+		checkVector(t, "normal", hit.Normal, expected.Normal)
+	}
+}
+
+func TestPolygon(t *testing.T) {
+	// An L shape with a reflex vertex at (1, 1).
+	l := NewPolygon(
+		geom.NewVector(0, 0, 0),
+		geom.NewVector(2, 0, 0),
+		geom.NewVector(2, 1, 0),
+		geom.NewVector(1, 1, 0),
+		geom.NewVector(1, 2, 0),
+		geom.NewVector(0, 2, 0),
+	)
+	// A five-pointed star, whose points are spikes between concave
+	// vertices.
+	var starVertices []geom.Vector
+	for i := 0; i < 10; i++ {
+		r := 1.0
+		if i%2 == 1 {
+			r = 0.4
+		}
+		angle := math.Pi/2 + float64(i)*math.Pi/5
+		starVertices = append(starVertices, geom.NewVector(r*math.Cos(angle), 5, r*math.Sin(angle)))
+	}
+	star := NewPolygon(starVertices...)
+
+	tests := []struct {
+		description string
+		polygon     *Polygon
+		ray         geom.Ray
+		intersected bool
+	}{
+		{"L arm", l, down(1.5, 0.5), true},
+		{"L other arm", l, down(0.5, 1.5), true},
+		{"L corner", l, down(0.5, 0.5), true},
+		{"L notch", l, down(1.5, 1.5), false},
+		{"L reflex vertex", l, down(1, 1), true},
+		{"L edge of the notch", l, down(1.5, 1), true},
+		{"L outer vertex", l, down(2, 0), true},
+		{"L within tolerance", l, down(1.5, 1+1e-10), true},
+		{"L outside tolerance", l, down(1.5, 1+1e-6), false},
+		{"L in its plane", l, geom.NewRay(geom.NewVector(-1, 0.5, 0), geom.NewVector(1, 0, 0)), false},
+		{"star center", star, geom.NewRay(geom.NewVector(0, 0, 0), geom.NewVector(0, 1, 0)), true},
+		{"star point", star, geom.NewRay(geom.NewVector(0, 0, 0.9), geom.NewVector(0, 1, 0)), true},
+		{"star tip", star, geom.NewRay(geom.NewVector(0, 0, 1), geom.NewVector(0, 1, 0)), true},
+		{"star between points", star, geom.NewRay(geom.NewVector(0, 0, -0.9), geom.NewVector(0, 1, 0)), false},
+		{"star from behind", star, geom.NewRay(geom.NewVector(0, 10, 0.1), geom.NewVector(0, -1, 0)), true},
+	}
+
+	for _, test := range tests {
+		if err := test.polygon.Validate(); err != nil {
+			t.Fatalf("%s: unexpected validation error: %s", test.description, err)
+		}
+		if ok := test.polygon.Intersect(test.ray); ok != test.intersected {
+			t.Errorf("%s: expected intersection to be %t but it was %t", test.description, test.intersected, ok)
+		}
+	}
+}
+
+func TestPolygonHit(t *testing.T) {
+	polygon := NewPolygon(
+		geom.NewVector(1, 1, 0),
+		geom.NewVector(3, 1, 0),
+		geom.NewVector(3, 3, 0),
+		geom.NewVector(2, 2, 0),
+		geom.NewVector(1, 3, 0),
+	)
+
+	hit, ok := polygon.IntersectHit(geom.NewRay(geom.NewVector(2.5, 1.5, 2), geom.NewVector(0, 0, -1)))
+	if !ok {
+		t.Fatalf("Expected the ray to hit the polygon")
+	}
+	checkHit(t, hit, 2, geom.NewVector(2.5, 1.5, 0), geom.NewVector(0, 0, 1), true)
+	checkFloat(t, "u", hit.U, 1.5)
+	checkFloat(t, "v", hit.V, 0.5)
+
+	hit, ok = polygon.IntersectHit(geom.NewRay(geom.NewVector(1.5, 1.5, -1), geom.NewVector(0, 0, 1)))
+	if !ok {
+		t.Fatalf("Expected the ray to hit the back of the polygon")
+	}
+	checkHit(t, hit, 1, geom.NewVector(1.5, 1.5, 0), geom.NewVector(0, 0, -1), false)
+
+	expected := geom.AABB{Min: geom.NewVector(1, 1, 0), Max: geom.NewVector(3, 3, 0)}
+	if b := polygon.Bounds(); b != expected {
+		t.Errorf("Expected bounds %v but got %v", expected, b)
+	}
+}
+
+func TestPolygonValidate(t *testing.T) {
+	tests := []struct {
+		description string
+		vertices    []geom.Vector
+		err         string
+	}{
+		{
+			"triangle",
+			[]geom.Vector{geom.NewVector(0, 0, 0), geom.NewVector(1, 0, 0), geom.NewVector(0, 1, 0)},
+			"",
+		},
+		{
+			"tilted concave",
+			[]geom.Vector{
+				geom.NewVector(0, 0, 0), geom.NewVector(2, 0, 2), geom.NewVector(1, 1, 1),
+				geom.NewVector(2, 2, 2), geom.NewVector(0, 2, 0),
+			},
+			"",
+		},
+		{
+			"two vertices",
+			[]geom.Vector{geom.NewVector(0, 0, 0), geom.NewVector(1, 0, 0)},
+			"at least 3",
+		},
+		{
+			"collinear",
+			[]geom.Vector{geom.NewVector(0, 0, 0), geom.NewVector(1, 0, 0), geom.NewVector(2, 0, 0)},
+			"no area",
+		},
+		{
+			"not planar",
+			[]geom.Vector{
+				geom.NewVector(0, 0, 0), geom.NewVector(1, 0, 0),
+				geom.NewVector(1, 1, 0.1), geom.NewVector(0, 1, 0),
+			},
+			"not planar",
+		},
+		{
+			"bow tie",
+			[]geom.Vector{
+				geom.NewVector(0, 0, 0), geom.NewVector(2, 2, 0),
+				geom.NewVector(2, 0, 0), geom.NewVector(0, 1, 0),
+			},
+			"self-intersecting",
+		},
+		{
+			"touching itself",
+			[]geom.Vector{
+				geom.NewVector(0, 0, 0), geom.NewVector(2, 0, 0), geom.NewVector(1, 1, 0),
+				geom.NewVector(2, 2, 0), geom.NewVector(0, 2, 0), geom.NewVector(1, 1, 0),
+			},
+			"self-intersecting",
+		},
+		{
+			"spike",
+			[]geom.Vector{
+				geom.NewVector(0, 0, 0), geom.NewVector(2, 0, 0), geom.NewVector(1, 0, 0),
+				geom.NewVector(1, 1, 0),
+			},
+			"self-intersecting",
+		},
+		{
+			"repeated vertex",
+			[]geom.Vector{
+				geom.NewVector(0, 0, 0), geom.NewVector(1, 0, 0), geom.NewVector(1, 0, 0),
+				geom.NewVector(0, 1, 0),
+			},
+			"no length",
+		},
+	}
+
+	for _, test := range tests {
+		err := NewPolygon(test.vertices...).Validate()
+		switch {
+		case test.err == "" && err != nil:
+			t.Errorf("%s: unexpected error: %s", test.description, err)
+		case test.err != "" && err == nil:
+			t.Errorf("%s: expected an error containing %q", test.description, test.err)
+		case test.err != "" && !strings.Contains(err.Error(), test.err):
+			t.Errorf("%s: expected an error containing %q but got %q", test.description, test.err, err)
+		}
+	}
+}
@@ -0,0 +1,488 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/fmi/go-homework/geom"
+)
+
+// Quadric is an Intersectable which represents a quadric surface, such as a
+// cylinder, cone, ellipsoid or paraboloid. The surface is defined by an
+// implicit equation in its own local coordinate system, optionally clipped
+// between two planes perpendicular to the local Z axis. The clipped ends may
+// be closed with flat caps.
+//
+// All quadrics share the same intersection code. They differ only by the
+// coefficients of their equations and their local coordinate systems.
+type Quadric struct {
+	q quadricCoefficients
+
+	// toWorld places the local coordinate system in the world. It is
+	// always a rigid transformation, so it does not change distances and
+	// normals can be transformed as directions.
+	toWorld, toLocal geom.Matrix
+
+	// zMin and zMax clip the surface along the local Z axis. They are
+	// infinite when the surface is not clipped.
+	zMin, zMax float64
+	capped     bool
+
+	// localBounds contains the unclipped surface in local coordinates.
+	localBounds geom.AABB
+
+	// size is the size of the quadric for the tolerance. It is zero for
+	// infinite cones, which have no size.
+	size      float64
+	tolerance geom.Tolerance
+
+	// err tells why the quadric is invalid. It is nil for valid ones.
+	err error
+}
+
+// quadricCoefficients are the coefficients of the implicit quadric equation
+//
+//	a*x² + b*y² + c*z² + d*xy + e*xz + f*yz + g*x + h*y + i*z + j = 0
+//
+// The points for which the left side is negative are inside the surface.
+type quadricCoefficients struct {
+	a, b, c, d, e, f, g, h, i, j float64
+}
+
+// eval returns the value of the left side of the quadric equation at `p`.
+func (q quadricCoefficients) eval(p geom.Vector) float64 {
+	return q.a*p.X*p.X + q.b*p.Y*p.Y + q.c*p.Z*p.Z +
+		q.d*p.X*p.Y + q.e*p.X*p.Z + q.f*p.Y*p.Z +
+		q.g*p.X + q.h*p.Y + q.i*p.Z + q.j
+}
+
+// gradient returns the gradient of the quadric equation at `p`. It points
+// outside of the surface.
+func (q quadricCoefficients) gradient(p geom.Vector) geom.Vector {
+	return geom.NewVector(
+		2*q.a*p.X+q.d*p.Y+q.e*p.Z+q.g,
+		2*q.b*p.Y+q.d*p.X+q.f*p.Z+q.h,
+		2*q.c*p.Z+q.e*p.X+q.f*p.Y+q.i,
+	)
+}
+
+// NewCylinder returns a finite cylinder with radius `r` whose axis goes from
+// `a` to `b`. When `capped` is true both of its ends are closed with disks.
+// `a` and `b` must differ and `r` must be positive, see Validate.
+func NewCylinder(a, b geom.Vector, r float64, capped bool) *Quadric {
+	axis := geom.Sub(b, a)
+	return NewInfiniteCylinder(a, axis, r).Clipped(0, geom.Len(axis), capped)
+}
+
+// NewInfiniteCylinder returns a cylinder with radius `r` whose axis passes
+// through `p` and has direction `axis`. It extends to infinity in both
+// directions. `axis` must not be zero and `r` must be positive.
+func NewInfiniteCylinder(p, axis geom.Vector, r float64) *Quadric {
+	return newQuadric(
+		quadricCoefficients{a: 1, b: 1, j: -r * r},
+		frameAlong(p, axis),
+		geom.AABB{
+			Min: geom.NewVector(-r, -r, math.Inf(-1)),
+			Max: geom.NewVector(r, r, math.Inf(1)),
+		},
+		r,
+		r,
+	)
+}
+
+// NewCone returns a finite cone with a base of radius `r` centered at `base`
+// and a tip at `apex`. When `capped` is true the base is closed with a disk.
+// `base` and `apex` must differ and `r` must be positive.
+func NewCone(base, apex geom.Vector, r float64, capped bool) *Quadric {
+	axis := geom.Sub(apex, base)
+	h := geom.Len(axis)
+	k := 0.0
+	if h > 0 {
+		k = r / h
+	}
+
+	// x² + y² = k²(z - h)²
+	cone := newQuadric(
+		quadricCoefficients{a: 1, b: 1, c: -k * k, i: 2 * k * k * h, j: -k * k * h * h},
+		frameAlong(base, axis),
+		geom.AABB{
+			Min: geom.NewVector(-r, -r, 0),
+			Max: geom.NewVector(r, r, h),
+		},
+		math.Max(r, h),
+		r, h,
+	)
+	return cone.Clipped(0, h, capped)
+}
+
+// NewInfiniteCone returns a double cone with its tip at `apex`, whose axis
+// has direction `axis`. `angle` is the angle in radians between the axis and
+// the surface of the cone. The cone extends to infinity in both directions.
+// `axis` must not be zero and `angle` must be between 0 and π/2.
+func NewInfiniteCone(apex, axis geom.Vector, angle float64) *Quadric {
+	k := math.Tan(angle)
+	return newQuadric(
+		quadricCoefficients{a: 1, b: 1, c: -k * k},
+		frameAlong(apex, axis),
+		geom.InfiniteAABB(),
+		0,
+		angle, math.Pi/2-angle,
+	)
+}
+
+// NewEllipsoid returns an ellipsoid centered at `center` whose semi-axes are
+// aligned with the coordinate axes and have lengths `radii.X`, `radii.Y`
+// and `radii.Z`, which must be positive. It can be clipped along the Z axis,
+// relatively to its center.
+func NewEllipsoid(center, radii geom.Vector) *Quadric {
+	return newQuadric(
+		quadricCoefficients{
+			a: 1 / (radii.X * radii.X),
+			b: 1 / (radii.Y * radii.Y),
+			c: 1 / (radii.Z * radii.Z),
+			j: -1,
+		},
+		geom.Translate(center),
+		geom.AABB{Min: geom.Neg(radii), Max: radii},
+		math.Max(radii.X, math.Max(radii.Y, radii.Z)),
+		radii.X, radii.Y, radii.Z,
+	)
+}
+
+// NewParaboloid returns a finite paraboloid of revolution with its vertex at
+// `vertex` which opens in the direction of `axis`. It is clipped at height `h`
+// along the axis, where its radius is `r`. When `capped` is true this end is
+// closed with a disk.
+func NewParaboloid(vertex, axis geom.Vector, r, h float64, capped bool) *Quadric {
+	p := NewInfiniteParaboloid(vertex, axis, r, h)
+	p = p.Clipped(math.Inf(-1), h, capped)
+	p.localBounds = geom.AABB{
+		Min: geom.NewVector(-r, -r, 0),
+		Max: geom.NewVector(r, r, h),
+	}
+	return p
+}
+
+// NewInfiniteParaboloid returns a paraboloid of revolution with its vertex at
+// `vertex` which opens in the direction of `axis`. Its radius is `r` at height
+// `h` along the axis and it grows to infinity. `axis` must not be zero and
+// `r` and `h` must be positive.
+func NewInfiniteParaboloid(vertex, axis geom.Vector, r, h float64) *Quadric {
+	// x² + y² = (r²/h)z
+	i := 0.0
+	if h > 0 {
+		i = -r * r / h
+	}
+	return newQuadric(
+		quadricCoefficients{a: 1, b: 1, i: i},
+		frameAlong(vertex, axis),
+		geom.InfiniteAABB(),
+		math.Max(r, h),
+		r, h,
+	)
+}
+
+// newQuadric returns an unclipped Quadric with equation `q` in the local
+// coordinate system placed in the world by the rigid transformation `frame`.
+// `size` is its size for the tolerance. The quadric is invalid when `frame`
+// is singular, which happens for axes of zero length, or when some of its
+// `dimensions` are not positive and finite.
+func newQuadric(q quadricCoefficients, frame geom.Matrix, localBounds geom.AABB, size float64, dimensions ...float64) *Quadric {
+	quadric := &Quadric{
+		q:           q,
+		toWorld:     frame,
+		zMin:        math.Inf(-1),
+		zMax:        math.Inf(1),
+		localBounds: localBounds,
+		size:        size,
+		tolerance:   geom.DefaultTolerance,
+	}
+
+	for _, d := range dimensions {
+		if !(d > 0) || math.IsInf(d, 1) {
+			quadric.err = fmt.Errorf("quadric dimension %g is not positive", d)
+			return quadric
+		}
+	}
+	toLocal, ok := frame.Inverse()
+	if !ok {
+		quadric.err = errors.New("quadric axis has no length")
+		return quadric
+	}
+	quadric.toLocal = toLocal
+	return quadric
+}
+
+// Validate returns an error when the quadric was made with an axis of zero
+// length or with dimensions which are not positive. Invalid quadrics are
+// never hit and contain no points.
+func (q *Quadric) Validate() error {
+	return q.err
+}
+
+// frameAlong returns a rigid transformation which places the origin at `p`
+// and the Z axis along `axis`.
+func frameAlong(p, axis geom.Vector) geom.Matrix {
+	up := geom.NewVector(0, 1, 0)
+	if n := geom.Normalize(axis); math.Abs(n.Y) > 0.9 {
+		up = geom.NewVector(1, 0, 0)
+	}
+	return geom.LookAt(p, geom.Add(p, axis), up)
+}
+
+// Clipped returns a copy of `q` which is clipped between the planes z = `zMin`
+// and z = `zMax` of its local coordinate system. For cylinders, cones and
+// paraboloids the local Z axis is their axis, starting from the base, the
+// apex or the vertex. For ellipsoids it is the world Z axis through their
+// center. When `capped` is true, the finite clipped ends are closed with the
+// parts of the clipping planes which are inside the surface. Ends at which the
+// surface shrinks to a point, like the apex of a cone, stay open.
+func (q *Quadric) Clipped(zMin, zMax float64, capped bool) *Quadric {
+	clipped := *q
+	clipped.zMin = math.Max(q.zMin, zMin)
+	clipped.zMax = math.Min(q.zMax, zMax)
+	clipped.capped = capped
+	return &clipped
+}
+
+// WithTolerance returns a copy of `q` which is intersected with `tolerance`.
+// The constructors of quadrics use geom.DefaultTolerance.
+func (q *Quadric) WithTolerance(tolerance geom.Tolerance) *Quadric {
+	quadric := *q
+	quadric.tolerance = tolerance
+	return &quadric
+}
+
+// Intersect implements the geom.Intersecatble interface.
+func (q *Quadric) Intersect(ray geom.Ray) bool {
+	return q.Occluded(ray, math.Inf(1))
+}
+
+// IntersectHit implements the geom.Intersector interface.
+func (q *Quadric) IntersectHit(ray geom.Ray) (geom.Hit, bool) {
+	return q.ClosestHit(ray, 0, math.Inf(1))
+}
+
+// Occluded implements the geom.Occluder interface.
+func (q *Quadric) Occluded(ray geom.Ray, tMax float64) bool {
+	_, ok := q.ClosestHit(ray, 0, tMax)
+	return ok
+}
+
+// ClosestHit implements the geom.ClosestHitter interface. The ray is
+// transformed in the local coordinate system of the quadric, where the
+// equation of the surface along the ray becomes a quadratic equation for the
+// ray parameter.
+//
+// Points within the tolerance of the quadric from the surface, the clipping
+// planes and the edges of the caps count as hits. The size of a quadric is its radius or
+// its height, whichever is bigger, and the longest semi-axis for ellipsoids.
+// Infinite cones have no size, so for them it is the distance from the apex
+// of the point of the query.
+//
+// The U of the returned hit is the angle around the local Z axis, scaled to
+// [0, 1]. For hits with the surface V is the local Z coordinate, scaled to
+// [0, 1] when the surface is clipped at both ends. For hits with the caps V is
+// 0 at the lower and 1 at the upper cap.
+func (q *Quadric) ClosestHit(ray geom.Ray, tMin, tMax float64) (geom.Hit, bool) {
+	if q.err != nil {
+		return geom.Hit{}, false
+	}
+	tMin, tMax = ray.Clip(tMin, tMax)
+	local := q.toLocal.Ray(ray)
+
+	tHit := math.Inf(1)
+	var p geom.Vector
+
+	// side is -1 or 1 when the lower or the upper cap is hit and 0 when
+	// the surface is hit.
+	side := 0
+
+	for _, t := range q.surfaceRoots(local) {
+		if t < tMin || t > tMax {
+			continue
+		}
+		lp := local.At(t)
+		eps := q.tolerance.Epsilon(q.sizeAt(lp))
+		if lp.Z < q.zMin-eps || lp.Z > q.zMax+eps {
+			continue
+		}
+		tHit, p = t, lp
+		break
+	}
+
+	if q.capped {
+		for i, z := range [2]float64{q.zMin, q.zMax} {
+			t, lp, ok := q.capCrossing(local, z)
+			if !ok || t < tMin || t > tMax || t >= tHit {
+				continue
+			}
+			tHit, p, side = t, lp, i*2-1
+		}
+	}
+
+	if math.IsInf(tHit, 1) {
+		return geom.Hit{}, false
+	}
+
+	return q.hit(ray, tHit, p, side), true
+}
+
+// Intervals implements the geom.Solid interface. Quadrics which are clipped
+// without caps do not enclose a volume, so for them the clipped ends are
+// treated as if they were capped.
+func (q *Quadric) Intervals(ray geom.Ray) []geom.Interval {
+	if q.err != nil {
+		return nil
+	}
+	local := q.toLocal.Ray(ray)
+
+	var hits []geom.Hit
+	for _, t := range q.surfaceRoots(local) {
+		lp := local.At(t)
+		eps := q.tolerance.Epsilon(q.sizeAt(lp))
+		if lp.Z < q.zMin-eps || lp.Z > q.zMax+eps {
+			continue
+		}
+		hits = append(hits, q.hit(ray, t, lp, 0))
+	}
+	for i, z := range [2]float64{q.zMin, q.zMax} {
+		if t, lp, ok := q.capCrossing(local, z); ok {
+			hits = append(hits, q.hit(ray, t, lp, i*2-1))
+		}
+	}
+	sort.Slice(hits, func(i, j int) bool { return hits[i].T < hits[j].T })
+
+	return solidIntervals(hits, func(t float64) bool {
+		lp := local.At(t)
+		return q.q.eval(lp) < 0 && lp.Z >= q.zMin && lp.Z <= q.zMax
+	})
+}
+
+// Contains implements the geom.Container interface. Points within the
+// tolerance of the quadric from the surface or the clipping planes are inside. Like
+// Intervals, it treats the clipped ends of quadrics without caps as if they
+// were capped.
+func (q *Quadric) Contains(p geom.Vector) bool {
+	if q.err != nil {
+		return false
+	}
+	lp := q.toLocal.Point(p)
+	eps := q.tolerance.Epsilon(q.sizeAt(lp))
+	if lp.Z < q.zMin-eps || lp.Z > q.zMax+eps {
+		return false
+	}
+	return q.q.eval(lp) <= eps*geom.Len(q.q.gradient(lp))
+}
+
+// sizeAt returns the size of the quadric for the tolerance at the local point
+// `p`.
+func (q *Quadric) sizeAt(p geom.Vector) float64 {
+	if q.size == 0 {
+		return geom.Len(p)
+	}
+	return q.size
+}
+
+// capCrossing returns the ray parameter and the local point at which the
+// `local` ray crosses the cap at z = `z`. Its last return value is false when
+// the ray misses the cap or there is no cap at z = `z`, because it is at
+// infinity or the surface shrinks to a point there, like at the apex of a
+// cone.
+func (q *Quadric) capCrossing(local geom.Ray, z float64) (float64, geom.Vector, bool) {
+	if math.IsInf(z, 0) || q.q.eval(geom.NewVector(0, 0, z)) >= 0 {
+		return 0, geom.Vector{}, false
+	}
+	if q.tolerance.Parallel(local.Direction, geom.NewVector(0, 0, 1)) {
+		return 0, geom.Vector{}, false
+	}
+	t := (z - local.Origin.Z) / local.Direction.Z
+	lp := local.At(t)
+	lp.Z = z
+	eps := q.tolerance.Epsilon(q.sizeAt(lp))
+	if q.q.eval(lp) > eps*geom.Len(q.q.gradient(lp)) {
+		return 0, geom.Vector{}, false
+	}
+	return t, lp, true
+}
+
+// hit returns the hit of `ray` with the quadric at ray parameter `t` and local
+// point `p`. `side` is -1 or 1 for hits with the lower or the upper cap and 0
+// for hits with the surface.
+func (q *Quadric) hit(ray geom.Ray, t float64, p geom.Vector, side int) geom.Hit {
+	normal := q.q.gradient(p)
+	switch {
+	case side != 0:
+		normal = geom.NewVector(0, 0, float64(side))
+	case normal == geom.Vector{}:
+		// The gradient vanishes only at the apex of a cone, where the
+		// local Z axis points outside of the cone.
+		normal = geom.NewVector(0, 0, 1)
+	}
+
+	hit := geom.Hit{
+		T:     t,
+		Point: ray.At(t),
+		U:     (math.Atan2(p.Y, p.X) + math.Pi) / (2 * math.Pi),
+		V:     p.Z,
+	}
+	switch {
+	case side != 0:
+		hit.V = float64(side+1) / 2
+	case !math.IsInf(q.zMin, 0) && !math.IsInf(q.zMax, 0) && q.zMax > q.zMin:
+		hit.V = (p.Z - q.zMin) / (q.zMax - q.zMin)
+	}
+	hit.SetFaceNormal(ray, q.toWorld.Direction(normal))
+
+	return hit
+}
+
+// surfaceRoots returns the ray parameters, in increasing order, at which the
+// `local` ray crosses the unclipped surface.
+func (q *Quadric) surfaceRoots(local geom.Ray) []float64 {
+	c := q.q
+	o, d := local.Origin, local.Direction
+
+	qa := c.a*d.X*d.X + c.b*d.Y*d.Y + c.c*d.Z*d.Z +
+		c.d*d.X*d.Y + c.e*d.X*d.Z + c.f*d.Y*d.Z
+	qb := 2*(c.a*o.X*d.X+c.b*o.Y*d.Y+c.c*o.Z*d.Z) +
+		c.d*(o.X*d.Y+o.Y*d.X) + c.e*(o.X*d.Z+o.Z*d.X) + c.f*(o.Y*d.Z+o.Z*d.Y) +
+		c.g*d.X + c.h*d.Y + c.i*d.Z
+	qc := c.eval(o)
+
+	// The quadratic term vanishes for rays parallel to the axis of a
+	// cylinder or a paraboloid and for rays parallel to the surface of a
+	// cone. The equation is linear then.
+	if qa == 0 {
+		if qb == 0 {
+			return nil
+		}
+		return []float64{-qc / qb}
+	}
+
+	// At the extremum of the equation along the ray its value divided by
+	// the length of the gradient approximates the distance to the surface.
+	// The extremum is -discriminant / 4qa, so rays missing the surface by
+	// less than eps have discriminants down to -4|qa|*eps*|gradient|.
+	p := local.At(-qb / (2 * qa))
+	eps := q.tolerance.Epsilon(q.sizeAt(p))
+	t0, t1, ok := quadratic(qa, qb, qc, 4*math.Abs(qa)*eps*geom.Len(c.gradient(p)))
+	if !ok {
+		return nil
+	}
+	return []float64{t0, t1}
+}
+
+// Bounds implements the geom.Bounded interface. Quadrics which extend to
+// infinity have infinite bounds and invalid ones have empty bounds.
+func (q *Quadric) Bounds() geom.AABB {
+	if q.err != nil {
+		return geom.EmptyAABB()
+	}
+	b := q.localBounds
+	b.Min.Z = math.Max(b.Min.Z, q.zMin)
+	b.Max.Z = math.Min(b.Max.Z, q.zMax)
+	return b.Transform(q.toWorld)
+}
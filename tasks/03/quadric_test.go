@@ -0,0 +1,335 @@
+package main
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/fmi/go-homework/geom"
+)
+
+func TestQuadrics(t *testing.T) {
+	cylinder := NewCylinder(geom.NewVector(0, 0, 0), geom.NewVector(0, 0, 4), 1, false)
+	cappedCylinder := NewCylinder(geom.NewVector(0, 0, 0), geom.NewVector(0, 0, 4), 1, true)
+	tiltedCylinder := NewCylinder(geom.NewVector(0, 0, 0), geom.NewVector(2, 2, 2), 0.5, true)
+	infiniteCylinder := NewInfiniteCylinder(geom.NewVector(0, 0, 0), geom.NewVector(0, 1, 0), 1)
+	cone := NewCone(geom.NewVector(0, 0, 0), geom.NewVector(0, 0, 2), 1, false)
+	cappedCone := NewCone(geom.NewVector(0, 0, 0), geom.NewVector(0, 0, 2), 1, true)
+	infiniteCone := NewInfiniteCone(geom.NewVector(0, 0, 0), geom.NewVector(0, 0, 1), math.Pi/4)
+	ellipsoid := NewEllipsoid(geom.NewVector(0, 0, 0), geom.NewVector(3, 2, 1))
+	dome := NewEllipsoid(geom.NewVector(0, 0, 0), geom.NewVector(1, 1, 1)).Clipped(0, 1, true)
+	paraboloid := NewParaboloid(geom.NewVector(0, 0, 0), geom.NewVector(0, 0, 1), 2, 4, false)
+	cappedParaboloid := NewParaboloid(geom.NewVector(0, 0, 0), geom.NewVector(0, 0, 1), 2, 4, true)
+	infiniteParaboloid := NewInfiniteParaboloid(geom.NewVector(0, 0, 0), geom.NewVector(0, 0, 1), 2, 4)
+
+	tests := []struct {
+		description string
+		figure      geom.ClosestHitter
+		ray         geom.Ray
+		intersected bool
+		t           float64
+		normal      geom.Vector
+	}{
+		{
+			description: "cylinder side",
+			figure:      cylinder,
+			ray:         geom.NewRay(geom.NewVector(-5, 0, 2), geom.NewVector(1, 0, 0)),
+			intersected: true, t: 4, normal: geom.NewVector(-1, 0, 0),
+		},
+		{
+			description: "cylinder from inside",
+			figure:      cylinder,
+			ray:         geom.NewRay(geom.NewVector(0, 0, 2), geom.NewVector(0, 1, 0)),
+			intersected: true, t: 1, normal: geom.NewVector(0, -1, 0),
+		},
+		{
+			description: "cylinder above its end",
+			figure:      cylinder,
+			ray:         geom.NewRay(geom.NewVector(-5, 0, 5), geom.NewVector(1, 0, 0)),
+			intersected: false,
+		},
+		{
+			description: "open cylinder along its axis",
+			figure:      cylinder,
+			ray:         geom.NewRay(geom.NewVector(0, 0, -5), geom.NewVector(0, 0, 1)),
+			intersected: false,
+		},
+		{
+			description: "capped cylinder along its axis",
+			figure:      cappedCylinder,
+			ray:         geom.NewRay(geom.NewVector(0, 0, -5), geom.NewVector(0, 0, 1)),
+			intersected: true, t: 5, normal: geom.NewVector(0, 0, -1),
+		},
+		{
+			description: "capped cylinder through the top cap",
+			figure:      cappedCylinder,
+			ray:         geom.NewRay(geom.NewVector(0.5, 0, 10), geom.NewVector(0, 0, -2)),
+			intersected: true, t: 3, normal: geom.NewVector(0, 0, 1),
+		},
+		{
+			description: "capped cylinder cap edge",
+			figure:      cappedCylinder,
+			ray:         geom.NewRay(geom.NewVector(1, 0, 10), geom.NewVector(0, 0, -1)),
+			intersected: true, t: 6, normal: geom.NewVector(0, 0, 1),
+		},
+		{
+			description: "tilted cylinder",
+			figure:      tiltedCylinder,
+			ray:         geom.NewRay(geom.NewVector(1, 1, -5), geom.NewVector(0, 0, 1)),
+			intersected: true, t: 6 - math.Sqrt(0.375),
+			normal: geom.Normalize(geom.NewVector(1, 1, -2)),
+		},
+		{
+			description: "tilted cylinder near miss",
+			figure:      tiltedCylinder,
+			ray:         geom.NewRay(geom.NewVector(1, -0.5, -5), geom.NewVector(0, 0, 1)),
+			intersected: false,
+		},
+		{
+			description: "infinite cylinder far away",
+			figure:      infiniteCylinder,
+			ray:         geom.NewRay(geom.NewVector(-5, 1e6, 0), geom.NewVector(1, 0, 0)),
+			intersected: true, t: 4, normal: geom.NewVector(-1, 0, 0),
+		},
+		{
+			description: "cone side",
+			figure:      cone,
+			ray:         geom.NewRay(geom.NewVector(-5, 0, 1), geom.NewVector(1, 0, 0)),
+			intersected: true, t: 4.5, normal: geom.Normalize(geom.NewVector(-1, 0, 0.5)),
+		},
+		{
+			description: "cone above its apex",
+			figure:      cone,
+			ray:         geom.NewRay(geom.NewVector(-5, 0, 3), geom.NewVector(1, 0, 0)),
+			intersected: false,
+		},
+		{
+			description: "open cone from below",
+			figure:      cone,
+			ray:         geom.NewRay(geom.NewVector(0.5, 0, -1), geom.NewVector(0, 0, 1)),
+			intersected: true, t: 2, normal: geom.Normalize(geom.NewVector(-1, 0, -0.5)),
+		},
+		{
+			description: "capped cone from below",
+			figure:      cappedCone,
+			ray:         geom.NewRay(geom.NewVector(0.5, 0, -1), geom.NewVector(0, 0, 1)),
+			intersected: true, t: 1, normal: geom.NewVector(0, 0, -1),
+		},
+		{
+			description: "capped cone at its apex",
+			figure:      cappedCone,
+			ray:         geom.NewRay(geom.NewVector(0, 0, 3), geom.NewVector(0, 0, -1)),
+			intersected: true, t: 1, normal: geom.NewVector(0, 0, 1),
+		},
+		{
+			description: "capped cone apex from inside",
+			figure:      cappedCone,
+			ray:         geom.NewRay(geom.NewVector(0, 0, 1), geom.NewVector(0, 0, 1)),
+			intersected: true, t: 1, normal: geom.NewVector(0, 0, -1),
+		},
+		{
+			description: "infinite cone parallel to its surface",
+			figure:      infiniteCone,
+			ray:         geom.NewRay(geom.NewVector(-1, 0, 0), geom.NewVector(1, 0, 1)),
+			intersected: true, t: 0.5, normal: geom.Normalize(geom.NewVector(-1, 0, -1)),
+		},
+		{
+			description: "infinite cone lower nappe",
+			figure:      infiniteCone,
+			ray:         geom.NewRay(geom.NewVector(-5, 0, -2), geom.NewVector(1, 0, 0)),
+			intersected: true, t: 3, normal: geom.Normalize(geom.NewVector(-1, 0, 1)),
+		},
+		{
+			description: "ellipsoid along its long axis",
+			figure:      ellipsoid,
+			ray:         geom.NewRay(geom.NewVector(-5, 0, 0), geom.NewVector(1, 0, 0)),
+			intersected: true, t: 2, normal: geom.NewVector(-1, 0, 0),
+		},
+		{
+			description: "ellipsoid near miss",
+			figure:      ellipsoid,
+			ray:         geom.NewRay(geom.NewVector(0, 0, 1.01), geom.NewVector(1, 0, 0)),
+			intersected: false,
+		},
+		{
+			description: "dome from below",
+			figure:      dome,
+			ray:         geom.NewRay(geom.NewVector(0.5, 0, -5), geom.NewVector(0, 0, 1)),
+			intersected: true, t: 5, normal: geom.NewVector(0, 0, -1),
+		},
+		{
+			description: "dome below its cap",
+			figure:      dome,
+			ray:         geom.NewRay(geom.NewVector(-5, 0, -0.5), geom.NewVector(1, 0, 0)),
+			intersected: false,
+		},
+		{
+			description: "paraboloid along its axis",
+			figure:      paraboloid,
+			ray:         geom.NewRay(geom.NewVector(1, 0, -5), geom.NewVector(0, 0, 1)),
+			intersected: true, t: 6, normal: geom.Normalize(geom.NewVector(2, 0, -1)),
+		},
+		{
+			description: "paraboloid from inside",
+			figure:      paraboloid,
+			ray:         geom.NewRay(geom.NewVector(1, 0, 10), geom.NewVector(0, 0, -1)),
+			intersected: true, t: 9, normal: geom.Normalize(geom.NewVector(-2, 0, 1)),
+		},
+		{
+			description: "capped paraboloid from above",
+			figure:      cappedParaboloid,
+			ray:         geom.NewRay(geom.NewVector(1, 0, 10), geom.NewVector(0, 0, -1)),
+			intersected: true, t: 6, normal: geom.NewVector(0, 0, 1),
+		},
+		{
+			description: "paraboloid above its end",
+			figure:      paraboloid,
+			ray:         geom.NewRay(geom.NewVector(-10, 0, 5), geom.NewVector(1, 0, 0)),
+			intersected: false,
+		},
+		{
+			description: "infinite paraboloid above its end",
+			figure:      infiniteParaboloid,
+			ray:         geom.NewRay(geom.NewVector(-10, 0, 9), geom.NewVector(1, 0, 0)),
+			intersected: true, t: 7, normal: geom.Normalize(geom.NewVector(-6, 0, -1)),
+		},
+	}
+
+	for _, test := range tests {
+		hit, ok := test.figure.ClosestHit(test.ray, 0, math.Inf(1))
+		if ok != test.intersected {
+			t.Errorf("%s: expected intersection to be %t but it was %t", test.description, test.intersected, ok)
+			continue
+		}
+		if !ok {
+			continue
+		}
+		if math.Abs(hit.T-test.t) > 1e-9 {
+			t.Errorf("%s: expected hit at %g but it was at %g", test.description, test.t, hit.T)
+		}
+		if geom.Len(geom.Sub(hit.Normal, test.normal)) > 1e-9 {
+			t.Errorf("%s: expected normal %v but it was %v", test.description, test.normal, hit.Normal)
+		}
+		if test.figure.Intersect(test.ray) != ok {
+			t.Errorf("%s: Intersect does not agree with ClosestHit", test.description)
+		}
+	}
+}
+
+func TestQuadricBounds(t *testing.T) {
+	tests := []struct {
+		description string
+		figure      geom.Bounded
+		min, max    geom.Vector
+		finite      bool
+	}{
+		{
+			description: "cylinder",
+			figure:      NewCylinder(geom.NewVector(0, 0, 0), geom.NewVector(0, 0, 4), 1, false),
+			min:         geom.NewVector(-1, -1, 0),
+			max:         geom.NewVector(1, 1, 4),
+			finite:      true,
+		},
+		{
+			description: "cone",
+			figure:      NewCone(geom.NewVector(1, 1, 1), geom.NewVector(1, 3, 1), 0.5, true),
+			min:         geom.NewVector(0.5, 1, 0.5),
+			max:         geom.NewVector(1.5, 3, 1.5),
+			finite:      true,
+		},
+		{
+			description: "ellipsoid",
+			figure:      NewEllipsoid(geom.NewVector(1, 1, 1), geom.NewVector(3, 2, 1)),
+			min:         geom.NewVector(-2, -1, 0),
+			max:         geom.NewVector(4, 3, 2),
+			finite:      true,
+		},
+		{
+			description: "dome",
+			figure:      NewEllipsoid(geom.NewVector(0, 0, 0), geom.NewVector(1, 1, 1)).Clipped(0, 1, true),
+			min:         geom.NewVector(-1, -1, 0),
+			max:         geom.NewVector(1, 1, 1),
+			finite:      true,
+		},
+		{
+			description: "paraboloid",
+			figure:      NewParaboloid(geom.NewVector(0, 0, 0), geom.NewVector(0, 0, 1), 2, 4, true),
+			min:         geom.NewVector(-2, -2, 0),
+			max:         geom.NewVector(2, 2, 4),
+			finite:      true,
+		},
+		{
+			description: "infinite cylinder",
+			figure:      NewInfiniteCylinder(geom.NewVector(0, 0, 0), geom.NewVector(0, 0, 1), 1),
+		},
+		{
+			description: "infinite cone",
+			figure:      NewInfiniteCone(geom.NewVector(0, 0, 0), geom.NewVector(0, 0, 1), 0.5),
+		},
+	}
+
+	for _, test := range tests {
+		b := test.figure.Bounds()
+		if b.IsFinite() != test.finite {
+			t.Errorf("%s: expected finite bounds to be %t but got %v", test.description, test.finite, b)
+			continue
+		}
+		if !test.finite {
+			continue
+		}
+		if geom.Len(geom.Sub(b.Min, test.min)) > 1e-9 || geom.Len(geom.Sub(b.Max, test.max)) > 1e-9 {
+			t.Errorf("%s: expected bounds [%v, %v] but got [%v, %v]",
+				test.description, test.min, test.max, b.Min, b.Max)
+		}
+	}
+}
+
+func TestQuadricValidate(t *testing.T) {
+	o := geom.NewVector(0, 0, 0)
+	up := geom.NewVector(0, 0, 1)
+
+	tests := []struct {
+		description string
+		quadric     *Quadric
+		err         string
+	}{
+		{"cylinder", NewCylinder(o, up, 1, true), ""},
+		{"cylinder with no axis", NewCylinder(o, o, 1, true), "no length"},
+		{"cylinder with no radius", NewCylinder(o, up, 0, true), "not positive"},
+		{"infinite cylinder with no axis", NewInfiniteCylinder(o, geom.Vector{}, 1), "no length"},
+		{"cone", NewCone(o, up, 1, true), ""},
+		{"cone with no axis", NewCone(o, o, 1, true), "not positive"},
+		{"infinite cone", NewInfiniteCone(o, up, math.Pi/4), ""},
+		{"flat infinite cone", NewInfiniteCone(o, up, math.Pi/2), "not positive"},
+		{"infinite cone with no axis", NewInfiniteCone(o, geom.Vector{}, math.Pi/4), "no length"},
+		{"ellipsoid", NewEllipsoid(o, geom.NewVector(1, 2, 3)), ""},
+		{"flat ellipsoid", NewEllipsoid(o, geom.NewVector(1, 0, 3)), "not positive"},
+		{"paraboloid", NewParaboloid(o, up, 1, 2, true), ""},
+		{"paraboloid with no height", NewParaboloid(o, up, 1, 0, true), "not positive"},
+		{"paraboloid with no axis", NewParaboloid(o, geom.Vector{}, 1, 2, true), "no length"},
+	}
+
+	// The ray passes through the origin, where all of the quadrics are.
+	ray := geom.NewRay(geom.NewVector(-5, 0.1, 0.2), geom.NewVector(1, 0, 0))
+	for _, test := range tests {
+		err := test.quadric.Validate()
+		switch {
+		case test.err == "" && err != nil:
+			t.Errorf("%s: unexpected error: %s", test.description, err)
+		case test.err != "" && err == nil:
+			t.Errorf("%s: expected an error containing %q", test.description, test.err)
+		case test.err != "" && !strings.Contains(err.Error(), test.err):
+			t.Errorf("%s: expected an error containing %q but got %q", test.description, test.err, err)
+		}
+
+		if test.err == "" {
+			continue
+		}
+		if test.quadric.Intersect(ray) || test.quadric.Contains(o) || len(test.quadric.Intervals(ray)) > 0 {
+			t.Errorf("%s: expected an invalid quadric to be empty", test.description)
+		}
+		if !test.quadric.Bounds().IsEmpty() {
+			t.Errorf("%s: expected an invalid quadric to have empty bounds", test.description)
+		}
+	}
+}
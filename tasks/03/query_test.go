@@ -0,0 +1,112 @@
+package main
+
+import (
+	"math"
+	"testing"
+
+	"github.com/fmi/go-homework/geom"
+)
+
+func TestQueryImplementations(t *testing.T) {
+	var _ geom.ClosestHitter = NewTriangle(geom.Vector{}, geom.Vector{}, geom.Vector{})
+	var _ geom.ClosestHitter = NewQuad(geom.Vector{}, geom.Vector{}, geom.Vector{}, geom.Vector{})
+	var _ geom.ClosestHitter = NewSphere(geom.Vector{}, 1)
+	var _ geom.Occluder = NewTriangle(geom.Vector{}, geom.Vector{}, geom.Vector{})
+	var _ geom.Occluder = NewQuad(geom.Vector{}, geom.Vector{}, geom.Vector{}, geom.Vector{})
+	var _ geom.Occluder = NewSphere(geom.Vector{}, 1)
+}
+
+func TestClosestHitInterval(t *testing.T) {
+	sphere := NewSphere(geom.NewVector(0, 0, 5), 1)
+	triangle := NewTriangle(
+		geom.NewVector(-1, -1, 2),
+		geom.NewVector(1, -1, 2),
+		geom.NewVector(0, 1, 2),
+	)
+	quad := NewQuad(
+		geom.NewVector(-1, -1, 8),
+		geom.NewVector(1, -1, 8),
+		geom.NewVector(1, 1, 8),
+		geom.NewVector(-1, 1, 8),
+	)
+	ray := geom.NewRay(geom.NewVector(0, 0, 0), geom.NewVector(0, 0, 1))
+	inf := math.Inf(1)
+
+	tests := []struct {
+		description string
+		figure      geom.ClosestHitter
+		tMin, tMax  float64
+		intersected bool
+		t           float64
+	}{
+		{"sphere whole ray", sphere, 0, inf, true, 4},
+		{"sphere from inside", sphere, 5, inf, true, 6},
+		{"sphere before it", sphere, 0, 3.5, false, 0},
+		{"sphere after it", sphere, 6.5, inf, false, 0},
+		{"sphere interval between hits", sphere, 4.5, 5.5, false, 0},
+		{"sphere interval ends on a hit", sphere, 0, 4, true, 4},
+		{"triangle whole ray", triangle, 0, inf, true, 2},
+		{"triangle before it", triangle, 0, 1.5, false, 0},
+		{"triangle after it", triangle, 2.5, inf, false, 0},
+		{"quad whole ray", quad, 0, inf, true, 8},
+		{"quad before it", quad, 0, 7, false, 0},
+		{"quad after it", quad, 9, inf, false, 0},
+	}
+
+	for _, test := range tests {
+		hit, ok := test.figure.ClosestHit(ray, test.tMin, test.tMax)
+		if ok != test.intersected {
+			t.Errorf("%s: expected intersection to be %t but it was %t", test.description, test.intersected, ok)
+			continue
+		}
+		if ok && math.Abs(hit.T-test.t) > 1e-9 {
+			t.Errorf("%s: expected hit at %g but it was at %g", test.description, test.t, hit.T)
+		}
+
+		occluded := test.figure.(geom.Occluder).Occluded(ray, test.tMax)
+		if test.tMin == 0 && occluded != test.intersected {
+			t.Errorf("%s: expected occlusion to be %t but it was %t", test.description, test.intersected, occluded)
+		}
+	}
+}
+
+func TestContainerQueries(t *testing.T) {
+	scene := []geom.Intersectable{
+		NewSphere(geom.NewVector(0, 0, 5), 1),
+		NewSphere(geom.NewVector(0, 0, 10), 1),
+		transformed(NewSphere(geom.NewVector(0, 0, 0), 1), geom.Translate(geom.NewVector(0, 0, 15))),
+	}
+	bvh := geom.NewBVH(scene)
+	ray := geom.NewRay(geom.NewVector(0, 0, 0), geom.NewVector(0, 0, 1))
+
+	if bvh.Occluded(ray, 3) {
+		t.Errorf("Expected nothing to occlude the ray before t=3")
+	}
+	if !bvh.Occluded(ray, 4.5) {
+		t.Errorf("Expected the first sphere to occlude the ray before t=4.5")
+	}
+
+	hit, ok := bvh.ClosestHit(ray, 7, math.Inf(1))
+	if !ok {
+		t.Fatalf("Expected a hit after t=7")
+	}
+	checkFloat(t, "t", hit.T, 9)
+
+	hit, ok = bvh.ClosestHit(ray, 11.5, 20)
+	if !ok {
+		t.Fatalf("Expected a hit after t=11.5")
+	}
+	checkFloat(t, "t", hit.T, 14)
+
+	if _, ok := bvh.ClosestHit(ray, 16.5, 20); ok {
+		t.Errorf("Expected no hits after t=16.5")
+	}
+
+	// Objects which only implement Intersect are tested as a whole.
+	if !geom.Occluded(unboundedFigure{}, ray, 1) {
+		t.Errorf("Expected plain Intersectables to be considered occluding")
+	}
+	if _, ok := geom.ClosestHit(unboundedFigure{}, ray, 0, 1); ok {
+		t.Errorf("Expected plain Intersectables to report no hits")
+	}
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/fmi/go-homework/geom"
+	"github.com/fmi/go-homework/render"
+)
+
+func TestRenderPrimitives(t *testing.T) {
+	light := geom.NewVector(0, 0, 5)
+	scene := &render.Scene{
+		Objects: []render.Object{
+			{
+				Shape:    NewPlane(geom.NewVector(0, 0, 0), geom.NewVector(0, 0, 1)),
+				Material: render.Lambert{Albedo: render.Gray(0.8)},
+			},
+			{
+				Shape:    NewSphere(geom.NewVector(0, 0, 1), 1),
+				Material: render.Phong{Diffuse: render.NewColor(0.9, 0.1, 0.1), Specular: render.Gray(0.1), Shininess: 30},
+			},
+			{
+				Shape:    NewBox(geom.NewVector(2, -1, 0), geom.NewVector(2.5, 1, 2)),
+				Material: render.Mirror{Color: render.Gray(0.9)},
+			},
+		},
+		Lights: []render.Light{render.PointLight{Position: light, Intensity: render.Gray(40)}},
+	}
+	whitted := render.Whitted{MaxDepth: 3}
+
+	// The shadow of the sphere reaches about 1.29 from the axis.
+	down := geom.NewVector(0, 0, -1)
+	if c := whitted.Radiance(scene, geom.NewRay(geom.NewVector(1.2, 0, 10), down), nil); !c.IsBlack() {
+		t.Errorf("Expected the floor to be in the shadow of the sphere but it was %v", c)
+	}
+
+	floor := geom.NewVector(-3, 0, 0)
+	d := geom.Len(geom.Sub(light, floor))
+	expected := 0.8 / math.Pi * 40 / (d * d) * 5 / d
+	if c := whitted.Radiance(scene, geom.NewRay(geom.NewVector(-3, 0, 10), down), nil); math.Abs(c.R-expected) > 1e-9 {
+		t.Errorf("Expected the lit floor to have radiance %g but it was %v", expected, c)
+	}
+
+	// The mirror shows the lit side of the red sphere.
+	reflection := whitted.Radiance(scene, geom.NewRay(geom.NewVector(1.5, 0, 1.7), geom.NewVector(1, 0, 0)), nil)
+	if reflection.R <= 0 || reflection.R < 2*reflection.G {
+		t.Errorf("Expected the sphere to be reflected in the mirror but the reflection was %v", reflection)
+	}
+	if c := (render.Whitted{}).Radiance(scene, geom.NewRay(geom.NewVector(1.5, 0, 1.7), geom.NewVector(1, 0, 0)), nil); !c.IsBlack() {
+		t.Errorf("Expected no reflection without reflection depth but it was %v", c)
+	}
+
+	camera := render.NewPerspective(geom.NewVector(0, -8, 3), geom.NewVector(0, 0, 1), geom.NewVector(0, 0, 1), 0.8, 1)
+	img, err := render.RenderScene(context.Background(), camera, scene, whitted, 32, 32, render.Options{})
+	if err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+	if c := img.RGBAAt(16, 16); c.R < 2*c.G || c.R < 2*c.B {
+		t.Errorf("Expected the sphere in the center of the image to be red but it was %v", c)
+	}
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"math"
+	"testing"
+
+	"github.com/fmi/go-homework/geom"
+)
+
+func TestSegments(t *testing.T) {
+	figures := []struct {
+		description string
+		figure      geom.Intersectable
+	}{
+		{
+			description: "triangle",
+			figure: NewTriangle(
+				geom.NewVector(-1, -1, 0),
+				geom.NewVector(1, -1, 0),
+				geom.NewVector(0, 1, 0),
+			),
+		},
+		{
+			description: "quad",
+			figure: NewQuad(
+				geom.NewVector(-1, -1, 0),
+				geom.NewVector(1, -1, 0),
+				geom.NewVector(1, 1, 0),
+				geom.NewVector(-1, 1, 0),
+			),
+		},
+		{
+			description: "sphere",
+			figure:      NewSphere(geom.NewVector(0, 0, 0.5), 0.5),
+		},
+		{
+			description: "transformed sphere",
+			figure: transformed(
+				NewSphere(geom.NewVector(0, 0, 0), 1),
+				geom.Translate(geom.NewVector(0, 0, 0.5)).Mul(geom.Scale(geom.NewVector(1, 1, 0.5))),
+			),
+		},
+		{
+			description: "bvh",
+			figure: geom.NewBVH([]geom.Intersectable{
+				NewSphere(geom.NewVector(0, 0, 0.5), 0.5),
+				NewSphere(geom.NewVector(5, 0, 0), 0.5),
+			}),
+		},
+	}
+
+	segments := []struct {
+		description string
+		segment     geom.Ray
+		intersected bool
+	}{
+		{"through the figure", geom.Between(geom.NewVector(0, 0, -3), geom.NewVector(0, 0, 3)), true},
+		{"ends before the figure", geom.Between(geom.NewVector(0, 0, -3), geom.NewVector(0, 0, -1)), false},
+		{"starts after the figure", geom.Between(geom.NewVector(0, 0, 1.5), geom.NewVector(0, 0, 3)), false},
+		{"ends on the figure", geom.Between(geom.NewVector(0, 0, -3), geom.NewVector(0, 0, 0)), true},
+		{
+			"ray starting after the figure",
+			geom.NewRay(geom.NewVector(0, 0, -3), geom.NewVector(0, 0, 1)).Limit(4.5, math.Inf(1)),
+			false,
+		},
+		{
+			"single point on the figure",
+			geom.NewRay(geom.NewVector(0, 0, -3), geom.NewVector(0, 0, 1)).Limit(3, 3),
+			true,
+		},
+		{
+			"single point before the figure",
+			geom.NewRay(geom.NewVector(0, 0, -3), geom.NewVector(0, 0, 1)).Limit(2, 2),
+			false,
+		},
+		{
+			"ray limited before the figure",
+			geom.Ray{Origin: geom.NewVector(0, 0, -3), Direction: geom.NewVector(0, 0, 1), TMax: 2, Bounded: true},
+			false,
+		},
+		{
+			"ray literal without limits",
+			geom.Ray{Origin: geom.NewVector(0, 0, -3), Direction: geom.NewVector(0, 0, 1)},
+			true,
+		},
+	}
+
+	for _, f := range figures {
+		for _, s := range segments {
+			if actual := f.figure.Intersect(s.segment); actual != s.intersected {
+				t.Errorf("%s %s: expected intersection to be %t but it was %t",
+					f.description, s.description, s.intersected, actual)
+			}
+
+			_, hit := f.figure.(geom.Intersector).IntersectHit(s.segment)
+			if hit != s.intersected {
+				t.Errorf("%s %s: expected hit to be %t but it was %t",
+					f.description, s.description, s.intersected, hit)
+			}
+		}
+	}
+}
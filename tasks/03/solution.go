@@ -8,86 +8,171 @@ import (
 
 // Triangle is an Intersectable which represents a triangle in the 3D space.
 type Triangle struct {
-	a, b, c vector
+	a, b, c   vector
+	algorithm geom.TriangleAlgorithm
+	tolerance geom.Tolerance
 }
 
-// Intersect implements the geom.Intersecatble interface. It uses the
-// Möller–Trumbore ray-triangle intersection algorithm from 1997. Wiki link:
-// https://en.wikipedia.org/wiki/M%C3%B6ller%E2%80%93Trumbore_intersection_algorithm
+// Intersect implements the geom.Intersecatble interface.
 func (t *Triangle) Intersect(r geom.Ray) bool {
-	ray := rayFromGeom(r)
-	edge1 := t.b.Minus(t.a)
-	edge2 := t.c.Minus(t.a)
-
-	s1 := ray.Direction.Cross(edge2)
-	divisor := edge1.Product(s1)
+	return t.Occluded(r, math.Inf(1))
+}
 
-	// Not culling:
-	if divisor > -epsilon && divisor < epsilon {
-		return false
-	}
+// IntersectHit implements the geom.Intersector interface.
+func (t *Triangle) IntersectHit(r geom.Ray) (geom.Hit, bool) {
+	return t.ClosestHit(r, 0, math.Inf(1))
+}
 
-	invDivisor := 1.0 / divisor
+// Occluded implements the geom.Occluder interface.
+func (t *Triangle) Occluded(r geom.Ray, tMax float64) bool {
+	_, ok := t.ClosestHit(r, 0, tMax)
+	return ok
+}
 
-	s := ray.Origin.Minus(t.a)
-	b1 := s.Product(s1) * invDivisor
+// ClosestHit implements the geom.ClosestHitter interface. By default it uses
+// the Möller–Trumbore ray-triangle intersection algorithm from 1997. Wiki link:
+// https://en.wikipedia.org/wiki/M%C3%B6ller%E2%80%93Trumbore_intersection_algorithm
+// Use WithAlgorithm for selecting another one. Points closer to the edges
+// than the tolerance of the triangle are part of it.
+//
+// The U and V of the returned hit are the barycentric coordinates of the hit
+// point in respect to the second and the third vertex of the triangle.
+func (t *Triangle) ClosestHit(r geom.Ray, tMin, tMax float64) (geom.Hit, bool) {
+	tMin, tMax = r.Clip(tMin, tMax)
+
+	tt, b1, b2, ok := geom.IntersectTriangle(t.algorithm, t.tolerance, r,
+		vectorToGeom(t.a), vectorToGeom(t.b), vectorToGeom(t.c), tMin, tMax)
+	if !ok {
+		return geom.Hit{}, false
+	}
 
-	if b1 < 0.0 || b1 > 1.0 {
-		return false
+	hit := geom.Hit{
+		T:     tt,
+		Point: r.At(tt),
+		U:     b1,
+		V:     b2,
 	}
+	normal := t.b.Minus(t.a).Cross(t.c.Minus(t.a))
+	hit.SetFaceNormal(r, vectorToGeom(normal))
 
-	s2 := s.Cross(edge1)
-	b2 := ray.Direction.Product(s2) * invDivisor
+	return hit, true
+}
 
-	if b2 < 0.0 || b1+b2 > 1.0 {
-		return false
-	}
+// ClosestPoint implements the geom.Distancer interface. The U and V of the
+// result are the barycentric coordinates of the closest point in respect to
+// the second and the third vertex, as for the hits of the triangle.
+func (t *Triangle) ClosestPoint(p geom.Vector) geom.Closest {
+	return geom.ClosestPointOnTriangle(p, vectorToGeom(t.a), vectorToGeom(t.b), vectorToGeom(t.c))
+}
 
-	tt := edge2.Product(s2) * invDivisor
+// Distance implements the geom.Distancer interface.
+func (t *Triangle) Distance(p geom.Vector) float64 {
+	return t.ClosestPoint(p).Distance
+}
 
-	if tt < 0 {
-		return false
-	}
+// WithAlgorithm returns a copy of the triangle which is intersected using
+// `algorithm`. geom.Watertight guarantees that rays never pass between
+// triangles sharing an edge, which matters for closed meshes.
+func (t *Triangle) WithAlgorithm(algorithm geom.TriangleAlgorithm) *Triangle {
+	triangle := *t
+	triangle.algorithm = algorithm
+	return &triangle
+}
+
+// WithTolerance returns a copy of the triangle which is intersected with
+// `tolerance`. NewTriangle uses geom.DefaultTolerance.
+func (t *Triangle) WithTolerance(tolerance geom.Tolerance) *Triangle {
+	triangle := *t
+	triangle.tolerance = tolerance
+	return &triangle
+}
 
-	return true
+// Bounds implements the geom.Bounded interface.
+func (t *Triangle) Bounds() geom.AABB {
+	return geom.NewAABB(vectorToGeom(t.a), vectorToGeom(t.b), vectorToGeom(t.c))
 }
 
 // NewTriangle returns a new Triangle, defined with the points `a`, `b` and `c`.
 func NewTriangle(a, b, c geom.Vector) *Triangle {
 	return &Triangle{
-		a: vectorFromGeom(a),
-		b: vectorFromGeom(b),
-		c: vectorFromGeom(c),
+		a:         vectorFromGeom(a),
+		b:         vectorFromGeom(b),
+		c:         vectorFromGeom(c),
+		tolerance: geom.DefaultTolerance,
 	}
 }
 
 // Quad is an Intersectable which represents a quadrilateral in the 3D space.
 type Quad struct {
-	vertices [4]vector
+	vertices  [4]vector
+	tolerance geom.Tolerance
+
+	// polygon is the quad as a Polygon, used for finding closest points.
+	polygon *Polygon
+
+	// patch is used instead of the quad when it is set by
+	// WithBilinearFallback.
+	patch *BilinearPatch
 }
 
-// Intersect implements the geom.Intersecatble interface. It is based on the
-// Ares Lagae and Philip Dutre (2005) algorithm.
+// Intersect implements the geom.Intersecatble interface.
 func (q *Quad) Intersect(r geom.Ray) bool {
+	return q.Occluded(r, math.Inf(1))
+}
+
+// IntersectHit implements the geom.Intersector interface.
+func (q *Quad) IntersectHit(r geom.Ray) (geom.Hit, bool) {
+	return q.ClosestHit(r, 0, math.Inf(1))
+}
+
+// Occluded implements the geom.Occluder interface.
+func (q *Quad) Occluded(r geom.Ray, tMax float64) bool {
+	_, ok := q.ClosestHit(r, 0, tMax)
+	return ok
+}
+
+// ClosestHit implements the geom.ClosestHitter interface. It is based on the
+// Ares Lagae and Philip Dutre (2005) algorithm, which expects the quad to be
+// convex and within the parallelogram of its first, second and fourth
+// vertices. Points closer to the edges than the tolerance of the quad are
+// part of it. The size of the quad is its longest edge.
+//
+// The U and V of the returned hit are the bilinear coordinates of the hit
+// point. U goes along the edge from the first to the second vertex and V
+// along the edge from the first to the fourth vertex.
+func (q *Quad) ClosestHit(r geom.Ray, tMin, tMax float64) (geom.Hit, bool) {
+	if q.patch != nil {
+		return q.patch.ClosestHit(r, tMin, tMax)
+	}
+
+	tMin, tMax = r.Clip(tMin, tMax)
 	ray := rayFromGeom(r)
 	e01 := q.vertices[1].Minus(q.vertices[0])
 	e03 := q.vertices[3].Minus(q.vertices[0])
+	normal := e01.Cross(e03)
 
+	if q.tolerance.Parallel(r.Direction, vectorToGeom(normal)) {
+		return geom.Hit{}, false
+	}
 	p := ray.Direction.Cross(e03)
 	det := e01.Product(p)
-	if det == 0 {
-		return false
-	}
 	invDet := 1 / det
 	t := ray.Origin.Minus(q.vertices[0])
 	alfa := t.Product(p) * invDet
-	if alfa < 0 || alfa > 1 {
-		return false
-	}
 	w := t.Cross(e01)
 	beta := ray.Direction.Product(w) * invDet
-	if beta < 0 || beta > 1 {
-		return false
+
+	// alfa is 0 on the edge from the first to the fourth vertex and beta
+	// on the edge from the first to the second one. The algorithm expects
+	// the quad to be within the parallelogram of these edges, so alfa and
+	// beta above 1 are outside of it. For parallelograms they are on the
+	// other two edges, which get the same tolerance.
+	if alfa < 0 || beta < 0 || alfa > 1 || beta > 1 {
+		eps := q.epsilon()
+		alfaSlack, betaSlack := edgeSlack(eps, e03, normal), edgeSlack(eps, e01, normal)
+		if alfa < -alfaSlack || alfa > 1+alfaSlack || beta < -betaSlack || beta > 1+betaSlack {
+			return geom.Hit{}, false
+		}
 	}
 
 	if alfa+beta > 1 {
@@ -97,28 +182,172 @@ func (q *Quad) Intersect(r geom.Ray) bool {
 		pp := ray.Direction.Cross(e21)
 		detp := e23.Product(pp)
 		if detp == 0 {
-			return false
+			return geom.Hit{}, false
 		}
 		invDetp := 1 / detp
 		tp := ray.Origin.Minus(q.vertices[2])
 		alfap := tp.Product(pp) * invDetp
-		if alfap < 0 {
-			return false
-		}
 		qp := tp.Cross(e23)
 		betap := ray.Direction.Product(qp) * invDetp
-		if betap < 0 {
-			return false
+
+		// alfap is 0 on the edge from the third to the second vertex
+		// and betap on the edge from the third to the fourth one.
+		if alfap < 0 || betap < 0 {
+			eps := q.epsilon()
+			normalp := e23.Cross(e21)
+			if alfap < -edgeSlack(eps, e21, normalp) || betap < -edgeSlack(eps, e23, normalp) {
+				return geom.Hit{}, false
+			}
 		}
 	}
 
 	tDist := e03.Product(w) * invDet
 
-	if tDist < 0 {
-		return false
+	if tDist < tMin || tDist > tMax {
+		return geom.Hit{}, false
+	}
+
+	u, v := q.bilinear(normal, math.Max(alfa, 0), math.Max(beta, 0))
+
+	hit := geom.Hit{
+		T:     tDist,
+		Point: r.At(tDist),
+		U:     u,
+		V:     v,
+	}
+	hit.SetFaceNormal(r, vectorToGeom(normal))
+
+	return hit, true
+}
+
+// ClosestPoint implements the geom.Distancer interface. The U and V of the
+// result are the bilinear coordinates of the closest point, as for the hits
+// of the quad. The closest point of a twisted quad is searched in the plane
+// which fits it best, even when it falls back to a BilinearPatch.
+func (q *Quad) ClosestPoint(p geom.Vector) geom.Closest {
+	closest := q.polygon.ClosestPoint(p)
+
+	// The barycentric coordinates of the closest point in respect to the
+	// triangle of the first, second and fourth vertices.
+	e01 := q.vertices[1].Minus(q.vertices[0])
+	e03 := q.vertices[3].Minus(q.vertices[0])
+	normal := e01.Cross(e03)
+	n2 := normal.Product(normal)
+	if n2 == 0 {
+		closest.U, closest.V = 0, 0
+		return closest
+	}
+	local := vectorFromGeom(closest.Point).Minus(q.vertices[0])
+	alfa := local.Cross(e03).Product(normal) / n2
+	beta := e01.Cross(local).Product(normal) / n2
+	closest.U, closest.V = q.bilinear(normal, math.Max(alfa, 0), math.Max(beta, 0))
+
+	return closest
+}
+
+// Distance implements the geom.Distancer interface.
+func (q *Quad) Distance(p geom.Vector) float64 {
+	return q.polygon.ClosestPoint(p).Distance
+}
+
+// epsilon returns the distance within which points are considered on the
+// edges of the quad.
+func (q *Quad) epsilon() float64 {
+	var size float64
+	for i := range q.vertices {
+		edge := vectorToGeom(q.vertices[(i+1)%4].Minus(q.vertices[i]))
+		size = math.Max(size, geom.Len(edge))
+	}
+	return q.tolerance.Epsilon(size)
+}
+
+// bilinear returns the bilinear coordinates of a point in the quad from its
+// barycentric coordinates `alfa` and `beta` in respect to the triangle formed by
+// the first, second and fourth vertices. `normal` is the (not normalized) normal
+// of the same triangle. This is the second part of the Lagae and Dutre algorithm.
+func (q *Quad) bilinear(normal vector, alfa, beta float64) (float64, float64) {
+	e01 := q.vertices[1].Minus(q.vertices[0])
+	e02 := q.vertices[2].Minus(q.vertices[0])
+	e03 := q.vertices[3].Minus(q.vertices[0])
+
+	// Barycentric coordinates of the third vertex.
+	var alfa11, beta11 float64
+	nx, ny, nz := math.Abs(normal.X), math.Abs(normal.Y), math.Abs(normal.Z)
+	switch {
+	case nx >= ny && nx >= nz:
+		alfa11 = (e02.Y*e03.Z - e02.Z*e03.Y) / normal.X
+		beta11 = (e01.Y*e02.Z - e01.Z*e02.Y) / normal.X
+	case ny >= nz:
+		alfa11 = (e02.Z*e03.X - e02.X*e03.Z) / normal.Y
+		beta11 = (e01.Z*e02.X - e01.X*e02.Z) / normal.Y
+	default:
+		alfa11 = (e02.X*e03.Y - e02.Y*e03.X) / normal.Z
+		beta11 = (e01.X*e02.Y - e01.Y*e02.X) / normal.Z
 	}
 
-	return true
+	var u, v float64
+	switch {
+	case math.Abs(alfa11-1) < epsilon:
+		u = alfa
+		if math.Abs(beta11-1) < epsilon {
+			v = beta
+		} else {
+			v = beta / (u*(beta11-1) + 1)
+		}
+	case math.Abs(beta11-1) < epsilon:
+		v = beta
+		u = alfa / (v*(alfa11-1) + 1)
+	default:
+		a := -(beta11 - 1)
+		b := alfa*(beta11-1) - beta*(alfa11-1) - 1
+		c := alfa
+		discrim := math.Max(b*b-4*a*c, 0)
+		qq := -0.5 * (b + math.Copysign(math.Sqrt(discrim), b))
+		u = qq / a
+		if u < 0 || u > 1 {
+			u = c / qq
+		}
+		v = beta / (u*(beta11-1) + 1)
+	}
+
+	return u, v
+}
+
+// WithBilinearFallback returns a copy of the quad which is intersected as a
+// BilinearPatch when its vertices are not in one plane, as decided by the
+// tolerance of the quad. The algorithm of the quad assumes that they are and
+// silently gives wrong results for twisted quads.
+func (q *Quad) WithBilinearFallback() *Quad {
+	quad := *q
+	quad.patch = nil
+
+	if !q.polygon.planar() {
+		v := q.polygon.Vertices()
+		quad.patch = NewBilinearPatch(v[0], v[1], v[2], v[3]).WithTolerance(q.tolerance)
+	}
+
+	return &quad
+}
+
+// WithTolerance returns a copy of the quad which is intersected with
+// `tolerance`. NewQuad uses geom.DefaultTolerance.
+func (q *Quad) WithTolerance(tolerance geom.Tolerance) *Quad {
+	quad := *q
+	quad.tolerance = tolerance
+	quad.polygon = quad.polygon.WithTolerance(tolerance)
+	if quad.patch != nil {
+		quad.patch = quad.patch.WithTolerance(tolerance)
+	}
+	return &quad
+}
+
+// Bounds implements the geom.Bounded interface.
+func (q *Quad) Bounds() geom.AABB {
+	b := geom.EmptyAABB()
+	for _, v := range q.vertices {
+		b = b.Expand(vectorToGeom(v))
+	}
+	return b
 }
 
 // NewQuad returns a new Quad which is definied byt he four points `a`, `b`, `c`
@@ -131,6 +360,8 @@ func NewQuad(a, b, c, d geom.Vector) *Quad {
 			vectorFromGeom(c),
 			vectorFromGeom(d),
 		},
+		tolerance: geom.DefaultTolerance,
+		polygon:   NewPolygon(a, b, c, d),
 	}
 }
 
@@ -138,52 +369,160 @@ func NewQuad(a, b, c, d geom.Vector) *Quad {
 type Sphere struct {
 	o geom.Vector
 	r float64
+
+	tolerance geom.Tolerance
 }
 
 // Intersect implements the geom.Intersecatble interface.
 func (s *Sphere) Intersect(ray geom.Ray) bool {
+	return s.Occluded(ray, math.Inf(1))
+}
+
+// IntersectHit implements the geom.Intersector interface.
+func (s *Sphere) IntersectHit(ray geom.Ray) (geom.Hit, bool) {
+	return s.ClosestHit(ray, 0, math.Inf(1))
+}
+
+// Occluded implements the geom.Occluder interface.
+func (s *Sphere) Occluded(ray geom.Ray, tMax float64) bool {
+	_, ok := s.ClosestHit(ray, 0, tMax)
+	return ok
+}
+
+// ClosestHit implements the geom.ClosestHitter interface. Rays which pass
+// within the tolerance of the sphere, relative to its radius, touch it.
+//
+// The U and V of the returned hit are the spherical coordinates of the hit
+// point, scaled to [0, 1]. U is the azimuth around the Y axis and V is the
+// polar angle measured from the positive Y direction.
+func (s *Sphere) ClosestHit(ray geom.Ray, tMin, tMax float64) (geom.Hit, bool) {
+	tMin, tMax = ray.Clip(tMin, tMax)
+	tNear, tFar, ok := s.roots(ray)
+
+	if !ok || tNear > tMax || tFar < tMin {
+		return geom.Hit{}, false
+	}
+
+	var retdist = tNear
+
+	if tNear < tMin {
+		retdist = tFar
+	}
+
+	if retdist > tMax {
+		return geom.Hit{}, false
+	}
+
+	return s.hit(ray, retdist), true
+}
+
+// Intervals implements the geom.Solid interface.
+func (s *Sphere) Intervals(ray geom.Ray) []geom.Interval {
+	tNear, tFar, ok := s.roots(ray)
+	if !ok {
+		return nil
+	}
+	return []geom.Interval{newInterval(s.hit(ray, tNear), s.hit(ray, tFar))}
+}
+
+// Contains implements the geom.Container interface. Points within the
+// tolerance of the sphere, relative to its radius, are inside.
+func (s *Sphere) Contains(p geom.Vector) bool {
+	return geom.Len(geom.Sub(p, s.o)) <= s.r+s.tolerance.Epsilon(s.r)
+}
+
+// ClosestPoint implements the geom.Distancer interface. It returns the closest
+// point on the surface of the sphere, also for points inside it. For the
+// center of the sphere this is its topmost point. The U and V of the result
+// are the spherical coordinates of the closest point, as for the hits of the
+// sphere.
+func (s *Sphere) ClosestPoint(p geom.Vector) geom.Closest {
+	dir := geom.Normalize(geom.Sub(p, s.o))
+	if dir == (geom.Vector{}) {
+		dir = geom.NewVector(0, 1, 0)
+	}
+	q := geom.Add(s.o, geom.Mul(dir, s.r))
+	u, v := sphereUV(dir)
+	return geom.Closest{Point: q, Distance: geom.Len(geom.Sub(q, p)), Feature: geom.FeatureFace, U: u, V: v}
+}
+
+// Distance implements the geom.Distancer interface. It is the distance to the
+// surface of the sphere, also for points inside it.
+func (s *Sphere) Distance(p geom.Vector) float64 {
+	return math.Abs(geom.Len(geom.Sub(p, s.o)) - s.r)
+}
+
+// roots returns the ray parameters, in increasing order, at which the line of
+// `ray` crosses the sphere. Its last return value is false when it misses it.
+func (s *Sphere) roots(ray geom.Ray) (float64, float64, bool) {
 	var d = ray.Direction
 	var o = ray.Origin
 
-	// Normalize the direction so that we can later check whether the
-	// intersection is behind the origin or in front of it.
-	dl := 1.0 / math.Sqrt(d.X*d.X+d.Y*d.Y+d.Z*d.Z)
-	d.X *= dl
-	d.Y *= dl
-	d.Z *= dl
-
 	// To make calculations easier, change the coord system so that
 	// the sphere center goes in 0,0,0.
 	o.X -= s.o.X
 	o.Y -= s.o.Y
 	o.Z -= s.o.Z
 
+	// The direction is not normalized so that the solutions are values
+	// of the ray parameter. Their sign still tells whether the intersection
+	// is behind the origin or in front of it.
 	var a = d.X*d.X + d.Y*d.Y + d.Z*d.Z
 	var b = 2 * (d.X*o.X + d.Y*o.Y + d.Z*o.Z)
 	var c = o.X*o.X + o.Y*o.Y + o.Z*o.Z - s.r*s.r
 
-	tNear, tFar, ok := quadratic(a, b, c)
+	// The discriminant divided by 4a is r² - d², where d is the distance
+	// from the center to the line of the ray. Allow d up to r + eps.
+	eps := s.tolerance.Epsilon(s.r)
+	return quadratic(a, b, c, 4*a*(2*s.r*eps+eps*eps))
+}
 
-	if !ok || (tNear < 0 && tFar < 0) {
-		return false
+// hit returns the hit of `ray` with the sphere at ray parameter `t`.
+func (s *Sphere) hit(ray geom.Ray, t float64) geom.Hit {
+	hit := geom.Hit{
+		T:     t,
+		Point: ray.At(t),
 	}
+	outward := geom.Sub(hit.Point, s.o)
+	hit.SetFaceNormal(ray, outward)
+	hit.U, hit.V = sphereUV(geom.Normalize(outward))
 
-	var retdist = tNear
+	return hit
+}
 
-	if tNear < 0 {
-		retdist = tFar
-	}
+// sphereUV returns the spherical coordinates of the unit vector `n`, scaled
+// to [0, 1].
+func sphereUV(n geom.Vector) (float64, float64) {
+	phi := math.Atan2(n.Z, n.X)
+	theta := math.Acos(math.Max(-1, math.Min(1, n.Y)))
+	return (phi + math.Pi) / (2 * math.Pi), theta / math.Pi
+}
 
-	if retdist < 0 {
-		return false
-	}
+// Bounds implements the geom.Bounded interface.
+func (s *Sphere) Bounds() geom.AABB {
+	r := geom.NewVector(s.r, s.r, s.r)
+	return geom.NewAABB(geom.Sub(s.o, r), geom.Add(s.o, r))
+}
 
-	return true
+// WithTolerance returns a copy of the sphere which is intersected with
+// `tolerance`. NewSphere uses geom.DefaultTolerance.
+func (s *Sphere) WithTolerance(tolerance geom.Tolerance) *Sphere {
+	sphere := *s
+	sphere.tolerance = tolerance
+	return &sphere
 }
 
 // NewSphere returns a new Sphere with center `o` and radius `r`.
 func NewSphere(o geom.Vector, r float64) *Sphere {
-	return &Sphere{o: o, r: r}
+	return &Sphere{o: o, r: r, tolerance: geom.DefaultTolerance}
+}
+
+// edgeSlack returns how far below zero a barycentric coordinate may go for
+// points within distance `eps` of the edge `edge` of a triangle whose
+// normal, not normalized, is `normal`. The coordinate is the distance to the
+// edge divided by the height to it.
+func edgeSlack(eps float64, edge, normal vector) float64 {
+	return eps * geom.Len(vectorToGeom(edge)) / geom.Len(vectorToGeom(normal))
 }
 
 // vector is a algebraic vector which supports few algebraic operations with other
@@ -211,6 +550,11 @@ func vectorFromGeom(p geom.Vector) vector {
 	return vector{X: p.X, Y: p.Y, Z: p.Z}
 }
 
+// vectorToGeom returns the `geom.Vector` which corresponds to `vector`.
+func vectorToGeom(v vector) geom.Vector {
+	return geom.Vector{X: v.X, Y: v.Y, Z: v.Z}
+}
+
 // ray is a similar to geo.Ray but it is using vector instead of geom.Vector
 // values. This makes calculations in this package easier.
 type ray struct {
@@ -228,12 +572,18 @@ func rayFromGeom(r geom.Ray) ray {
 
 // quadratic solves a quadratic equation and returns the two solutions of there are any.
 // Its last return value is a boolean and true when there is a solution. The first two
-// values are the solutions.
-func quadratic(a, b, c float64) (float64, float64, bool) {
+// values are the solutions. A zero discriminant gives a double solution. So do negative
+// discriminants down to -`slack`, which allows rays to be tangent to curved surfaces
+// within the tolerance.
+func quadratic(a, b, c, slack float64) (float64, float64, bool) {
 	discrim := b*b - 4*a*c
-	if discrim <= 0 {
+	if discrim < -slack {
 		return 0, 0, false
 	}
+	if discrim <= 0 {
+		t := -b / (2 * a)
+		return t, t, true
+	}
 	rootDiscrim := math.Sqrt(discrim)
 	var q float64
 	if b < 0 {
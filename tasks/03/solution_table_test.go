@@ -98,126 +98,128 @@ func TestTriangle(t *testing.T) {
 	}
 }
 
-func TestQuad(t *testing.T) {
-	tests := []struct {
-		description string
-		quad        geom.Intersectable
-		ray         geom.Ray
-		intersected bool
-	}{
-		{
-			description: "simple intersection",
-			quad: NewQuad(
-				geom.NewVector(-1, -1, 0),
-				geom.NewVector(1, -1, 0),
-				geom.NewVector(1, 1, 0),
-				geom.NewVector(-1, 1, 0),
-			),
-			ray:         geom.NewRay(geom.NewVector(0, 0, -1), geom.NewVector(0, 0, 1)),
-			intersected: true,
-		},
-		{
-			description: "no back face culling",
-			quad: NewQuad(
-				geom.NewVector(-1, -1, 0),
-				geom.NewVector(1, -1, 0),
-				geom.NewVector(1, 1, 0),
-				geom.NewVector(-1, 1, 0),
-			),
-			ray:         geom.NewRay(geom.NewVector(0, 0, 1), geom.NewVector(0, 0, -1)),
-			intersected: true,
-		},
-		{
-			description: "ray opposite direction",
-			quad: NewQuad(
-				geom.NewVector(-1, -1, 0),
-				geom.NewVector(1, -1, 0),
-				geom.NewVector(1, 1, 0),
-				geom.NewVector(-1, 1, 0),
-			),
-			ray:         geom.NewRay(geom.NewVector(0, 0, 1), geom.NewVector(0, 0, 1)),
-			intersected: false,
-		},
-		{
-			description: "near miss",
-			quad: NewQuad(
-				geom.NewVector(-1, -1, 0),
-				geom.NewVector(1, -1, 0),
-				geom.NewVector(1, 1, 0),
-				geom.NewVector(-1, 1, 0),
-			),
-			ray:         geom.NewRay(geom.NewVector(0, 0, 5), geom.NewVector(10, 10, -1)),
-			intersected: false,
-		},
-		{
-			description: "non axis aligned quad",
-			quad: NewQuad(
-				geom.NewVector(1, 0, 0),
-				geom.NewVector(0.5946035575013605, 0.5946035575013605, 0),
-				geom.NewVector(0, 1, 0),
-				geom.NewVector(0, 0, 1),
-			),
-			ray:         geom.NewRay(geom.NewVector(0, 0, 0), geom.NewVector(1, 1, 1)),
-			intersected: true,
-		},
-		{
-			description: "ray on edge",
-			quad: NewQuad(
-				geom.NewVector(1, 0, 0),
-				geom.NewVector(0.5946035575013605, 0.5946035575013605, 0),
-				geom.NewVector(0, 1, 0),
-				geom.NewVector(0, 0, 1),
-			),
-			ray:         geom.NewRay(geom.NewVector(1, 0, 0), geom.NewVector(0, 1, 0)),
-			intersected: true,
-		},
-		{
-			description: "origin really close to object",
-			quad: NewQuad(
-				geom.NewVector(-1, -1, 0),
-				geom.NewVector(1, -1, 0),
-				geom.NewVector(1, 1, 0),
-				geom.NewVector(-1, 1, 0),
-			),
-			ray:         geom.NewRay(geom.NewVector(0, 0, 1e-6), geom.NewVector(0, 0, 1)),
-			intersected: false,
-		},
-		{
-			description: "irregular quad hit",
-			quad: NewQuad(
-				geom.NewVector(-1, -1, 0),
-				geom.NewVector(1, -1, 0),
-				geom.NewVector(10, 10, 0),
-				geom.NewVector(-1, 1, 0),
-			),
-			ray:         geom.NewRay(geom.NewVector(0, 0, -1), geom.NewVector(0, 0, 1)),
-			intersected: true,
-		},
-		{
-			description: "second irregular quad hit",
-			quad: NewQuad(
-				geom.NewVector(-1, -1, 0),
-				geom.NewVector(1, -1, 0),
-				geom.NewVector(0.2, 0.2, 0),
-				geom.NewVector(-1, 1, 0),
-			),
-			ray:         geom.NewRay(geom.NewVector(0, 0, -1), geom.NewVector(0, 0, 1)),
-			intersected: true,
-		},
-		{
-			description: "irregular quad miss",
-			quad: NewQuad(
-				geom.NewVector(-1, -1, 0),
-				geom.NewVector(1, -1, 0),
-				geom.NewVector(10, 10, 0),
-				geom.NewVector(-1, 1, 0),
-			),
-			ray:         geom.NewRay(geom.NewVector(0, 0, 5), geom.NewVector(-10, 10, -1)),
-			intersected: false,
-		},
-	}
+// quadTests are shared by the tests of quads and of polygons with four
+// vertices.
+var quadTests = []struct {
+	description string
+	quad        geom.Intersectable
+	ray         geom.Ray
+	intersected bool
+}{
+	{
+		description: "simple intersection",
+		quad: NewQuad(
+			geom.NewVector(-1, -1, 0),
+			geom.NewVector(1, -1, 0),
+			geom.NewVector(1, 1, 0),
+			geom.NewVector(-1, 1, 0),
+		),
+		ray:         geom.NewRay(geom.NewVector(0, 0, -1), geom.NewVector(0, 0, 1)),
+		intersected: true,
+	},
+	{
+		description: "no back face culling",
+		quad: NewQuad(
+			geom.NewVector(-1, -1, 0),
+			geom.NewVector(1, -1, 0),
+			geom.NewVector(1, 1, 0),
+			geom.NewVector(-1, 1, 0),
+		),
+		ray:         geom.NewRay(geom.NewVector(0, 0, 1), geom.NewVector(0, 0, -1)),
+		intersected: true,
+	},
+	{
+		description: "ray opposite direction",
+		quad: NewQuad(
+			geom.NewVector(-1, -1, 0),
+			geom.NewVector(1, -1, 0),
+			geom.NewVector(1, 1, 0),
+			geom.NewVector(-1, 1, 0),
+		),
+		ray:         geom.NewRay(geom.NewVector(0, 0, 1), geom.NewVector(0, 0, 1)),
+		intersected: false,
+	},
+	{
+		description: "near miss",
+		quad: NewQuad(
+			geom.NewVector(-1, -1, 0),
+			geom.NewVector(1, -1, 0),
+			geom.NewVector(1, 1, 0),
+			geom.NewVector(-1, 1, 0),
+		),
+		ray:         geom.NewRay(geom.NewVector(0, 0, 5), geom.NewVector(10, 10, -1)),
+		intersected: false,
+	},
+	{
+		description: "non axis aligned quad",
+		quad: NewQuad(
+			geom.NewVector(1, 0, 0),
+			geom.NewVector(0.5946035575013605, 0.5946035575013605, 0),
+			geom.NewVector(0, 1, 0),
+			geom.NewVector(0, 0, 1),
+		),
+		ray:         geom.NewRay(geom.NewVector(0, 0, 0), geom.NewVector(1, 1, 1)),
+		intersected: true,
+	},
+	{
+		description: "ray on edge",
+		quad: NewQuad(
+			geom.NewVector(1, 0, 0),
+			geom.NewVector(0.5946035575013605, 0.5946035575013605, 0),
+			geom.NewVector(0, 1, 0),
+			geom.NewVector(0, 0, 1),
+		),
+		ray:         geom.NewRay(geom.NewVector(1, 0, 0), geom.NewVector(0, 1, 0)),
+		intersected: true,
+	},
+	{
+		description: "origin really close to object",
+		quad: NewQuad(
+			geom.NewVector(-1, -1, 0),
+			geom.NewVector(1, -1, 0),
+			geom.NewVector(1, 1, 0),
+			geom.NewVector(-1, 1, 0),
+		),
+		ray:         geom.NewRay(geom.NewVector(0, 0, 1e-6), geom.NewVector(0, 0, 1)),
+		intersected: false,
+	},
+	{
+		description: "irregular quad hit",
+		quad: NewQuad(
+			geom.NewVector(-1, -1, 0),
+			geom.NewVector(1, -1, 0),
+			geom.NewVector(10, 10, 0),
+			geom.NewVector(-1, 1, 0),
+		),
+		ray:         geom.NewRay(geom.NewVector(0, 0, -1), geom.NewVector(0, 0, 1)),
+		intersected: true,
+	},
+	{
+		description: "second irregular quad hit",
+		quad: NewQuad(
+			geom.NewVector(-1, -1, 0),
+			geom.NewVector(1, -1, 0),
+			geom.NewVector(0.2, 0.2, 0),
+			geom.NewVector(-1, 1, 0),
+		),
+		ray:         geom.NewRay(geom.NewVector(0, 0, -1), geom.NewVector(0, 0, 1)),
+		intersected: true,
+	},
+	{
+		description: "irregular quad miss",
+		quad: NewQuad(
+			geom.NewVector(-1, -1, 0),
+			geom.NewVector(1, -1, 0),
+			geom.NewVector(10, 10, 0),
+			geom.NewVector(-1, 1, 0),
+		),
+		ray:         geom.NewRay(geom.NewVector(0, 0, 5), geom.NewVector(-10, 10, -1)),
+		intersected: false,
+	},
+}
 
-	for _, test := range tests {
+func TestQuad(t *testing.T) {
+	for _, test := range quadTests {
 		t.Run(test.description, func(t *testing.T) {
 			actual := test.quad.Intersect(test.ray)
 			if actual != test.intersected {
@@ -0,0 +1,222 @@
+package main
+
+import (
+	"math"
+	"testing"
+
+	"github.com/fmi/go-homework/geom"
+)
+
+// towards returns a ray which starts at `origin` and goes to `target`.
+func towards(origin, target geom.Vector) geom.Ray {
+	return geom.NewRay(origin, geom.Sub(target, origin))
+}
+
+// down returns a ray which goes down the Z axis to the point (x, y, 0).
+func down(x, y float64) geom.Ray {
+	return geom.NewRay(geom.NewVector(x, y, 1), geom.NewVector(0, 0, -1))
+}
+
+func TestBoundaryHits(t *testing.T) {
+	triangle := NewTriangle(geom.NewVector(0, 0, 0), geom.NewVector(1, 0, 0), geom.NewVector(0, 1, 0))
+	turned := transformed(
+		NewTriangle(geom.NewVector(0, 0, 0), geom.NewVector(1, 0, 0), geom.NewVector(0, 1, 0)),
+		geom.RotateZ(math.Pi/3),
+	)
+	quad := NewQuad(
+		geom.NewVector(0, 0, 0), geom.NewVector(2, 0, 0),
+		geom.NewVector(2, 1, 0), geom.NewVector(0, 1, 0),
+	)
+	skewed := NewQuad(
+		geom.NewVector(0, 0, 0), geom.NewVector(2, 0, 0),
+		geom.NewVector(1.8, 0.9, 0), geom.NewVector(0, 1, 0),
+	)
+	sphere := NewSphere(geom.NewVector(0, 0, 0), 1)
+	disk := NewDisk(geom.NewVector(0, 0, 0), geom.NewVector(0, 0, 1), 1)
+	box := NewBox(geom.NewVector(0, 0, 0), geom.NewVector(1, 1, 1))
+	obb := NewOBB(
+		geom.NewVector(0, 0, 0),
+		geom.NewVector(1, 1, 0), geom.NewVector(-1, 1, 0), geom.NewVector(0, 0, 1),
+	)
+	cylinder := NewCylinder(geom.NewVector(0, 0, 0), geom.NewVector(0, 0, 2), 1, true)
+	cone := NewCone(geom.NewVector(0, 0, 0), geom.NewVector(0, 0, 1), 1, true)
+	ellipsoid := NewEllipsoid(geom.NewVector(0, 0, 0), geom.NewVector(2, 1, 1))
+	paraboloid := NewParaboloid(geom.NewVector(0, 0, 0), geom.NewVector(0, 0, 1), 1, 1, true)
+	torus := NewTorus(geom.NewVector(0, 0, 0), geom.NewVector(0, 0, 1), 2, 0.5)
+
+	// The vertices of the rotated triangle and box are rounded, so they
+	// are only hit thanks to the tolerance.
+	c, s := math.Cos(math.Pi/3), math.Sin(math.Pi/3)
+
+	tests := []struct {
+		description string
+		figure      geom.Intersectable
+		ray         geom.Ray
+		intersected bool
+	}{
+		{"triangle vertex", triangle, down(0, 0), true},
+		{"triangle other vertex", triangle, down(1, 0), true},
+		{"triangle edge", triangle, down(0.3, 0), true},
+		{"triangle diagonal edge", triangle, down(0.5, 0.5), true},
+		{"triangle within tolerance", triangle, down(0.3, -1e-10), true},
+		{"triangle outside tolerance", triangle, down(0.3, -1e-6), false},
+		{"triangle diagonal within tolerance", triangle, down(0.5+1e-10, 0.5), true},
+		{"triangle in its plane", triangle, geom.NewRay(geom.NewVector(-1, 0.2, 0), geom.NewVector(1, 0, 0)), false},
+		{"rotated triangle vertex", turned, down(c, s), true},
+		{"rotated triangle edge", turned, down(c/2, s/2), true},
+		{"watertight triangle edge", triangle.WithAlgorithm(geom.Watertight), down(0.5, 0.5), true},
+		{"watertight triangle within tolerance", triangle.WithAlgorithm(geom.Watertight), down(0.3, -1e-10), true},
+		{"watertight triangle outside tolerance", triangle.WithAlgorithm(geom.Watertight), down(0.3, -1e-6), false},
+
+		{"quad vertex", quad, down(0, 0), true},
+		{"quad opposite vertex", quad, down(2, 1), true},
+		{"quad edge", quad, down(2, 0.5), true},
+		{"quad top edge", quad, down(1.5, 1), true},
+		{"quad within tolerance", quad, down(2+1e-10, 0.5), true},
+		{"quad outside tolerance", quad, down(2+1e-6, 0.5), false},
+		{"skewed quad third vertex", skewed, down(1.8, 0.9), true},
+		{"skewed quad edge", skewed, down(1.9, 0.45), true},
+		{"skewed quad outside tolerance", skewed, down(1.9+1e-6, 0.45), false},
+		{"skewed quad beyond the third vertex", skewed, down(1.9, 0.95), false},
+		{"quad in its plane", quad, geom.NewRay(geom.NewVector(-1, 0.5, 0), geom.NewVector(1, 0, 0)), false},
+
+		{"sphere tangent", sphere, geom.NewRay(geom.NewVector(-5, 1, 0), geom.NewVector(1, 0, 0)), true},
+		{"sphere oblique tangent", sphere, geom.NewRay(geom.NewVector(-3.4, 3.8, 0), geom.NewVector(0.8, -0.6, 0)), true},
+		{"sphere within tolerance", sphere, geom.NewRay(geom.NewVector(-5, 1+1e-10, 0), geom.NewVector(1, 0, 0)), true},
+		{"sphere outside tolerance", sphere, geom.NewRay(geom.NewVector(-5, 1+1e-6, 0), geom.NewVector(1, 0, 0)), false},
+
+		{"disk edge", disk, down(1, 0), true},
+		{"disk oblique edge", disk, down(0.6, 0.8), true},
+		{"disk within tolerance", disk, down(1+1e-10, 0), true},
+		{"disk outside tolerance", disk, down(1+1e-6, 0), false},
+
+		{"box corner", box, towards(geom.NewVector(-1, 0, 0), geom.NewVector(0, 1, 1)), true},
+		{"box edge", box, geom.NewRay(geom.NewVector(-1, 0, 0.5), geom.NewVector(1, 1, 0)), true},
+		{"box face plane", box, geom.NewRay(geom.NewVector(-1, 1, 0.5), geom.NewVector(1, 0, 0)), true},
+		{"box within tolerance", box, geom.NewRay(geom.NewVector(-1-1e-10, 1e-10, 0.5), geom.NewVector(1, 1, 0)), true},
+		{"box outside tolerance", box, geom.NewRay(geom.NewVector(-1-1e-6, 1e-6, 0.5), geom.NewVector(1, 1, 0)), false},
+		{"oriented box corner", obb, geom.NewRay(geom.NewVector(-1, 2, 2), geom.NewVector(1, 0, -1)), true},
+		{"oriented box edge", obb, geom.NewRay(geom.NewVector(-5, 2, 0), geom.NewVector(1, 0, 0)), true},
+		{"oriented box outside tolerance", obb, geom.NewRay(geom.NewVector(-5, 2+1e-6, 0), geom.NewVector(1, 0, 0)), false},
+
+		{"cylinder tangent", cylinder, geom.NewRay(geom.NewVector(-5, 1, 1), geom.NewVector(1, 0, 0)), true},
+		{"cylinder line on the surface", cylinder, geom.NewRay(geom.NewVector(1, 0, 5), geom.NewVector(0, 0, -1)), true},
+		{"cylinder rim", cylinder, towards(geom.NewVector(3, 0, 4), geom.NewVector(1, 0, 2)), true},
+		{"cylinder rim within tolerance", cylinder, towards(geom.NewVector(3, 0, 4), geom.NewVector(1+1e-10, 0, 2)), true},
+		{"cylinder outside tolerance", cylinder, geom.NewRay(geom.NewVector(-5, 1+1e-6, 1), geom.NewVector(1, 0, 0)), false},
+		{"cone apex", cone, geom.NewRay(geom.NewVector(-1, 0, 1), geom.NewVector(1, 0, 0)), true},
+		{"cone base rim", cone, geom.NewRay(geom.NewVector(-5, 1, 0), geom.NewVector(1, 0, 0)), true},
+		{"cone above the apex", cone, geom.NewRay(geom.NewVector(-1, 0, 1+1e-6), geom.NewVector(1, 0, 0)), false},
+		{"ellipsoid tangent", ellipsoid, geom.NewRay(geom.NewVector(-5, 1, 0), geom.NewVector(1, 0, 0)), true},
+		{"ellipsoid long tangent", ellipsoid, geom.NewRay(geom.NewVector(2, -5, 0), geom.NewVector(0, 1, 0)), true},
+		{"ellipsoid outside tolerance", ellipsoid, geom.NewRay(geom.NewVector(2+1e-6, -5, 0), geom.NewVector(0, 1, 0)), false},
+		{"paraboloid vertex", paraboloid, geom.NewRay(geom.NewVector(-1, 0, 0), geom.NewVector(1, 0, 0)), true},
+		{"ellipsoid pole within tolerance", ellipsoid, geom.NewRay(geom.NewVector(-5, 0, 1+1e-10), geom.NewVector(1, 0, 0)), true},
+		{"paraboloid vertex within tolerance", paraboloid, geom.NewRay(geom.NewVector(-1, 0, -1e-10), geom.NewVector(1, 0, 0)), true},
+		{"paraboloid below the vertex", paraboloid, geom.NewRay(geom.NewVector(-1, 0, -1e-6), geom.NewVector(1, 0, 0)), false},
+		{"torus top tangent", torus, geom.NewRay(geom.NewVector(-5, 0, 0.5), geom.NewVector(1, 0, 0)), true},
+		{"torus oblique top tangent", torus, geom.NewRay(geom.NewVector(-5, 1, 0.5), geom.NewVector(1, 0, 0)), true},
+		{"torus outer tangent", torus, geom.NewRay(geom.NewVector(2.5, -5, 0), geom.NewVector(0, 1, 0)), true},
+		{"torus outside tolerance", torus, geom.NewRay(geom.NewVector(-5, 0, 0.5+1e-6), geom.NewVector(1, 0, 0)), false},
+	}
+
+	for _, test := range tests {
+		if ok := test.figure.Intersect(test.ray); ok != test.intersected {
+			t.Errorf("%s: expected intersection to be %t but it was %t", test.description, test.intersected, ok)
+		}
+	}
+}
+
+func TestConfigurableTolerance(t *testing.T) {
+	triangle := NewTriangle(geom.NewVector(0, 0, 0), geom.NewVector(1, 0, 0), geom.NewVector(0, 1, 0))
+	quad := NewQuad(geom.NewVector(0, 0, 0), geom.NewVector(2, 0, 0), geom.NewVector(2, 1, 0), geom.NewVector(0, 1, 0))
+	sphere := NewSphere(geom.NewVector(0, 0, 0), 1)
+	cylinder := NewCylinder(geom.NewVector(0, 0, 0), geom.NewVector(0, 0, 2), 1, false)
+	torus := NewTorus(geom.NewVector(0, 0, 0), geom.NewVector(0, 0, 1), 2, 0.5)
+
+	figures := []struct {
+		description string
+		figure      func(tol geom.Tolerance) geom.Intersectable
+		ray         geom.Ray
+	}{
+		{
+			"triangle",
+			func(tol geom.Tolerance) geom.Intersectable { return triangle.WithTolerance(tol) },
+			down(0.3, -1e-4),
+		},
+		{
+			"quad",
+			func(tol geom.Tolerance) geom.Intersectable { return quad.WithTolerance(tol) },
+			down(2+1e-4, 0.5),
+		},
+		{
+			"sphere",
+			func(tol geom.Tolerance) geom.Intersectable { return sphere.WithTolerance(tol) },
+			geom.NewRay(geom.NewVector(-5, 1+1e-4, 0), geom.NewVector(1, 0, 0)),
+		},
+		{
+			"cylinder",
+			func(tol geom.Tolerance) geom.Intersectable { return cylinder.WithTolerance(tol) },
+			geom.NewRay(geom.NewVector(-5, 1+1e-4, 1), geom.NewVector(1, 0, 0)),
+		},
+		{
+			"torus",
+			func(tol geom.Tolerance) geom.Intersectable { return torus.WithTolerance(tol) },
+			geom.NewRay(geom.NewVector(-5, 0, 0.5+1e-4), geom.NewVector(1, 0, 0)),
+		},
+	}
+
+	tolerances := []struct {
+		description string
+		tolerance   geom.Tolerance
+		intersected bool
+	}{
+		{"default", geom.DefaultTolerance, false},
+		{"absolute", geom.Tolerance{Absolute: 1e-3}, true},
+		{"relative", geom.Tolerance{Relative: 1e-3}, true},
+	}
+
+	for _, tolerance := range tolerances {
+		for _, f := range figures {
+			if ok := f.figure(tolerance.tolerance).Intersect(f.ray); ok != tolerance.intersected {
+				t.Errorf("%s tolerance, %s: expected intersection to be %t but it was %t",
+					tolerance.description, f.description, tolerance.intersected, ok)
+			}
+		}
+	}
+
+	// The coarse tolerances of the copies do not leak into the originals.
+	originals := []geom.Intersectable{triangle, quad, sphere, cylinder, torus}
+	for i, original := range originals {
+		if original.Intersect(figures[i].ray) {
+			t.Errorf("%s: the tolerance of a copy changed the original", figures[i].description)
+		}
+	}
+}
+
+func TestParallelTolerance(t *testing.T) {
+	triangle := NewTriangle(geom.NewVector(0, 0, 0), geom.NewVector(4, 0, 0), geom.NewVector(0, 4, 0))
+	disk := NewDisk(geom.NewVector(0, 0, 0), geom.NewVector(0, 0, 1), 4)
+
+	// The ray crosses the plane of the shapes at an angle of about 1e-4
+	// radians.
+	ray := towards(geom.NewVector(-1, 1, 1e-4), geom.NewVector(1, 1, -1e-4))
+
+	tests := []struct {
+		description string
+		figure      geom.Intersectable
+		intersected bool
+	}{
+		{"triangle", triangle, true},
+		{"triangle with a coarse angle", triangle.WithTolerance(geom.Tolerance{Angle: 1e-3}), false},
+		{"triangle with a coarse distance", triangle.WithTolerance(geom.Tolerance{Absolute: 1e-3, Relative: 1e-3}), true},
+		{"disk", disk, true},
+		{"disk with a coarse angle", disk.WithTolerance(geom.Tolerance{Angle: 1e-3}), false},
+	}
+
+	for _, test := range tests {
+		if ok := test.figure.Intersect(ray); ok != test.intersected {
+			t.Errorf("%s: expected intersection to be %t but it was %t", test.description, test.intersected, ok)
+		}
+	}
+}
@@ -0,0 +1,233 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/fmi/go-homework/geom"
+	"github.com/fmi/go-homework/geom/poly"
+)
+
+// Torus is an Intersectable which represents a ring torus, the surface swept
+// by a circle rotated around an axis in its plane.
+type Torus struct {
+	// R is the distance from the axis to the center of the tube and r is
+	// the radius of the tube.
+	R, r float64
+
+	// toWorld is a rigid transformation which places the center of the
+	// torus in the world, with its axis along the local Z axis. toLocal is
+	// its inverse.
+	toWorld, toLocal geom.Matrix
+
+	tolerance geom.Tolerance
+
+	// err tells why the torus is invalid. It is nil for valid ones.
+	err error
+}
+
+// NewTorus returns a new Torus with center `center` which is symmetric around
+// `axis`. `R` is the distance from the axis to the center of its tube and `r`
+// is the radius of the tube. `axis` must not be zero and `R` must be bigger
+// than `r`, which must be positive, see Validate.
+func NewTorus(center, axis geom.Vector, R, r float64) *Torus {
+	frame := frameAlong(center, axis)
+	torus := &Torus{
+		R:         R,
+		r:         r,
+		toWorld:   frame,
+		tolerance: geom.DefaultTolerance,
+	}
+
+	if !(r > 0) || !(R > r) || math.IsInf(R, 1) {
+		torus.err = fmt.Errorf("torus radii %g and %g do not form a ring", R, r)
+		return torus
+	}
+	toLocal, ok := frame.Inverse()
+	if !ok {
+		torus.err = errors.New("torus axis has no length")
+		return torus
+	}
+	torus.toLocal = toLocal
+	return torus
+}
+
+// Validate returns an error when the torus was made with an axis of zero
+// length or with radii which do not form a ring torus. Invalid tori are never
+// hit and contain no points.
+func (to *Torus) Validate() error {
+	return to.err
+}
+
+// WithTolerance returns a copy of the torus which is intersected with
+// `tolerance`. NewTorus uses geom.DefaultTolerance.
+func (to *Torus) WithTolerance(tolerance geom.Tolerance) *Torus {
+	torus := *to
+	torus.tolerance = tolerance
+	return &torus
+}
+
+// Intersect implements the geom.Intersecatble interface.
+func (to *Torus) Intersect(ray geom.Ray) bool {
+	return to.Occluded(ray, math.Inf(1))
+}
+
+// IntersectHit implements the geom.Intersector interface.
+func (to *Torus) IntersectHit(ray geom.Ray) (geom.Hit, bool) {
+	return to.ClosestHit(ray, 0, math.Inf(1))
+}
+
+// Occluded implements the geom.Occluder interface.
+func (to *Torus) Occluded(ray geom.Ray, tMax float64) bool {
+	_, ok := to.ClosestHit(ray, 0, tMax)
+	return ok
+}
+
+// ClosestHit implements the geom.ClosestHitter interface. The torus equation
+//
+//	(x² + y² + z² - R² - r²)² + 4R²(z² - r²) = 0
+//
+// becomes a quartic equation for the ray parameter, which is solved with the
+// geom/poly package. For numerical stability the ray is first moved to the
+// bounding sphere of the torus and its direction is normalized.
+//
+// Rays passing within the tolerance of the torus, relative to the
+// radius of its tube, touch it. Such tangent rays give double roots, which
+// rounding errors may turn into complex ones, so when the quartic has less
+// than four real roots its extrema are checked too.
+//
+// The U of the returned hit is the angle around the axis of the torus and V
+// is the angle around its tube, both scaled to [0, 1].
+func (to *Torus) ClosestHit(ray geom.Ray, tMin, tMax float64) (geom.Hit, bool) {
+	if to.err != nil {
+		return geom.Hit{}, false
+	}
+	tMin, tMax = ray.Clip(tMin, tMax)
+	local := to.toLocal.Ray(ray)
+
+	for _, t := range to.roots(local, tMin, tMax) {
+		if t < tMin || t > tMax {
+			continue
+		}
+		return to.hit(ray, local, t), true
+	}
+
+	return geom.Hit{}, false
+}
+
+// Intervals implements the geom.Solid interface.
+func (to *Torus) Intervals(ray geom.Ray) []geom.Interval {
+	if to.err != nil {
+		return nil
+	}
+	local := to.toLocal.Ray(ray)
+	roots := to.roots(local, math.Inf(-1), math.Inf(1))
+	hits := make([]geom.Hit, len(roots))
+	for i, t := range roots {
+		hits[i] = to.hit(ray, local, t)
+	}
+
+	return solidIntervals(hits, func(t float64) bool {
+		p := local.At(t)
+		return math.Hypot(math.Hypot(p.X, p.Y)-to.R, p.Z) < to.r
+	})
+}
+
+// Contains implements the geom.Container interface. Points within the
+// tolerance of the torus, relative to the radius of its tube, are inside.
+func (to *Torus) Contains(p geom.Vector) bool {
+	if to.err != nil {
+		return false
+	}
+	lp := to.toLocal.Point(p)
+	return math.Hypot(math.Hypot(lp.X, lp.Y)-to.R, lp.Z) <= to.r+to.tolerance.Epsilon(to.r)
+}
+
+// roots returns the ray parameters, in increasing order, at which the `local`
+// ray crosses the torus. Only the part of the ray from `tMin` to `tMax` which
+// is in the bounding sphere of the torus is searched.
+func (to *Torus) roots(local geom.Ray, tMin, tMax float64) []float64 {
+	dl := geom.Len(local.Direction)
+	if dl == 0 {
+		return nil
+	}
+	dir := geom.Mul(local.Direction, 1/dl)
+
+	// Start from where the ray enters the bounding sphere. The distances
+	// along the normalized direction are dl times the ray parameters.
+	eps := to.tolerance.Epsilon(to.r)
+	outer := to.R + to.r + eps
+	b := geom.Dot(local.Origin, dir)
+	c := geom.Dot(local.Origin, local.Origin) - outer*outer
+	if b*b-c < 0 {
+		return nil
+	}
+	start := math.Max(tMin*dl, -b-math.Sqrt(b*b-c))
+	if start > tMax*dl {
+		return nil
+	}
+	o := geom.Add(local.Origin, geom.Mul(dir, start))
+
+	R2, r2 := to.R*to.R, to.r*to.r
+	od := geom.Dot(o, dir)
+	e := geom.Dot(o, o) - R2 - r2
+
+	c3 := 4 * od
+	c2 := 4*od*od + 2*e + 4*R2*dir.Z*dir.Z
+	c1 := 4*od*e + 8*R2*o.Z*dir.Z
+	c0 := e*e + 4*R2*(o.Z*o.Z-r2)
+	roots := poly.Quartic(1, c3, c2, c1, c0)
+
+	if len(roots) < 4 {
+		for _, s := range poly.Cubic(4, 3*c3, 2*c2, c1) {
+			p := geom.Add(o, geom.Mul(dir, s))
+			if math.Abs(math.Hypot(math.Hypot(p.X, p.Y)-to.R, p.Z)-to.r) <= eps {
+				roots = append(roots, s)
+			}
+		}
+		sort.Float64s(roots)
+	}
+
+	ts := make([]float64, 0, len(roots))
+	for _, s := range roots {
+		if s >= 0 {
+			ts = append(ts, (start+s)/dl)
+		}
+	}
+	return ts
+}
+
+// hit returns the hit of `ray` with the torus at ray parameter `t`. `local` is
+// the ray in the local coordinate system of the torus.
+func (to *Torus) hit(ray, local geom.Ray, t float64) geom.Hit {
+	R2, r2 := to.R*to.R, to.r*to.r
+	p := local.At(t)
+	g := geom.Dot(p, p) - R2 - r2
+	normal := geom.NewVector(4*g*p.X, 4*g*p.Y, 4*g*p.Z+8*R2*p.Z)
+
+	hit := geom.Hit{
+		T:     t,
+		Point: ray.At(t),
+		U:     (math.Atan2(p.Y, p.X) + math.Pi) / (2 * math.Pi),
+		V:     (math.Atan2(p.Z, math.Hypot(p.X, p.Y)-to.R) + math.Pi) / (2 * math.Pi),
+	}
+	hit.SetFaceNormal(ray, to.toWorld.Direction(normal))
+
+	return hit
+}
+
+// Bounds implements the geom.Bounded interface. Invalid tori have empty
+// bounds.
+func (to *Torus) Bounds() geom.AABB {
+	if to.err != nil {
+		return geom.EmptyAABB()
+	}
+	outer := to.R + to.r
+	local := geom.NewAABB(
+		geom.NewVector(-outer, -outer, -to.r),
+		geom.NewVector(outer, outer, to.r),
+	)
+	return local.Transform(to.toWorld)
+}
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/fmi/go-homework/geom"
+)
+
+func TestTorus(t *testing.T) {
+	torus := NewTorus(geom.NewVector(0, 0, 0), geom.NewVector(0, 0, 1), 2, 0.5)
+	tilted := NewTorus(geom.NewVector(5, 5, 5), geom.NewVector(1, 0, 0), 2, 0.5)
+	big := NewTorus(geom.NewVector(0, 0, 0), geom.NewVector(0, 0, 1), 20, 5)
+	huge := NewTorus(geom.NewVector(0, 0, 0), geom.NewVector(0, 0, 1), 2000, 500)
+
+	// The ray towards the big torus hits it at distance rho from its axis.
+	rho := 20 + math.Sqrt(24)
+	x := math.Sqrt(rho*rho - 9)
+
+	tests := []struct {
+		description string
+		figure      geom.ClosestHitter
+		ray         geom.Ray
+		intersected bool
+		t           float64
+		normal      geom.Vector
+	}{
+		{
+			description: "through the tube",
+			figure:      torus,
+			ray:         geom.NewRay(geom.NewVector(-10, 0, 0), geom.NewVector(1, 0, 0)),
+			intersected: true, t: 7.5, normal: geom.NewVector(-1, 0, 0),
+		},
+		{
+			description: "from above the tube",
+			figure:      torus,
+			ray:         geom.NewRay(geom.NewVector(2, 0, 10), geom.NewVector(0, 0, -2)),
+			intersected: true, t: 4.75, normal: geom.NewVector(0, 0, 1),
+		},
+		{
+			description: "big torus off its plane",
+			figure:      big,
+			ray:         geom.NewRay(geom.NewVector(-100, 3, 1), geom.NewVector(1, 0, 0)),
+			intersected: true, t: 100 - x,
+			normal: geom.NewVector(-x/rho*math.Sqrt(24)/5, 3/rho*math.Sqrt(24)/5, 0.2),
+		},
+		{
+			description: "huge torus through the tube",
+			figure:      huge,
+			ray:         geom.NewRay(geom.NewVector(-10000, 0, 0), geom.NewVector(1, 0, 0)),
+			intersected: true, t: 7500, normal: geom.NewVector(-1, 0, 0),
+		},
+		{
+			description: "huge torus from inside the tube",
+			figure:      huge,
+			ray:         geom.NewRay(geom.NewVector(-2000, 0, 0), geom.NewVector(0, 0, 1)),
+			intersected: true, t: 500, normal: geom.NewVector(0, 0, -1),
+		},
+		{
+			description: "through the hole along the axis",
+			figure:      torus,
+			ray:         geom.NewRay(geom.NewVector(0, 0, -10), geom.NewVector(0, 0, 1)),
+			intersected: false,
+		},
+		{
+			description: "through the hole diagonally",
+			figure:      torus,
+			ray:         geom.NewRay(geom.NewVector(-1, 0, -10), geom.NewVector(0.1, 0, 1)),
+			intersected: false,
+		},
+		{
+			description: "from the hole to the inner side",
+			figure:      torus,
+			ray:         geom.NewRay(geom.NewVector(0, 0, 0), geom.NewVector(0, 1, 0)),
+			intersected: true, t: 1.5, normal: geom.NewVector(0, -1, 0),
+		},
+		{
+			description: "from inside the tube",
+			figure:      torus,
+			ray:         geom.NewRay(geom.NewVector(2, 0, 0), geom.NewVector(0, 0, 1)),
+			intersected: true, t: 0.5, normal: geom.NewVector(0, 0, -1),
+		},
+		{
+			description: "grazing the top of the tube",
+			figure:      torus,
+			ray:         geom.NewRay(geom.NewVector(-10, 0, 0.5-1e-6), geom.NewVector(1, 0, 0)),
+			intersected: true, t: 8 - math.Sqrt(1e-6), normal: geom.NewVector(-0.002, 0, 1),
+		},
+		{
+			description: "just above the tube",
+			figure:      torus,
+			ray:         geom.NewRay(geom.NewVector(-10, 0, 0.5+1e-6), geom.NewVector(1, 0, 0)),
+			intersected: false,
+		},
+		{
+			description: "grazing the outer side",
+			figure:      torus,
+			ray:         geom.NewRay(geom.NewVector(-10, 2.5-1e-6, 0), geom.NewVector(1, 0, 0)),
+			intersected: true, t: 10 - math.Sqrt(5e-6), normal: geom.NewVector(-math.Sqrt(5e-6)/2.5, 1, 0),
+		},
+		{
+			description: "just beside the outer side",
+			figure:      torus,
+			ray:         geom.NewRay(geom.NewVector(-10, 2.5+1e-6, 0), geom.NewVector(1, 0, 0)),
+			intersected: false,
+		},
+		{
+			description: "past the inner side",
+			figure:      torus,
+			ray:         geom.NewRay(geom.NewVector(-10, 1.5+1e-6, 0), geom.NewVector(1, 0, 0)),
+			intersected: true, t: 10 - math.Sqrt(6.25-(1.5+1e-6)*(1.5+1e-6)),
+			normal: geom.NewVector(-math.Sqrt(6.25-(1.5+1e-6)*(1.5+1e-6)), 1.5+1e-6, 0),
+		},
+		{
+			description: "far away",
+			figure:      torus,
+			ray:         geom.NewRay(geom.NewVector(-1e4, 0, 0), geom.NewVector(1, 0, 0)),
+			intersected: true, t: 1e4 - 2.5, normal: geom.NewVector(-1, 0, 0),
+		},
+		{
+			description: "tilted torus",
+			figure:      tilted,
+			ray:         geom.NewRay(geom.NewVector(5, 7, -5), geom.NewVector(0, 0, 1)),
+			intersected: true, t: 8.5, normal: geom.NewVector(0, 0.8, -0.6),
+		},
+		{
+			description: "tilted torus hole",
+			figure:      tilted,
+			ray:         geom.NewRay(geom.NewVector(-5, 5, 5), geom.NewVector(1, 0, 0)),
+			intersected: false,
+		},
+	}
+
+	for _, test := range tests {
+		hit, ok := test.figure.ClosestHit(test.ray, 0, math.Inf(1))
+		if ok != test.intersected {
+			t.Errorf("%s: expected intersection to be %t but it was %t", test.description, test.intersected, ok)
+			continue
+		}
+		if !ok {
+			continue
+		}
+		if math.Abs(hit.T-test.t) > 1e-6 {
+			t.Errorf("%s: expected hit at %g but it was at %g", test.description, test.t, hit.T)
+		}
+		if n := geom.Normalize(test.normal); geom.Len(geom.Sub(hit.Normal, n)) > 1e-6 {
+			t.Errorf("%s: expected normal %v but it was %v", test.description, n, hit.Normal)
+		}
+	}
+}
+
+func TestTorusBounds(t *testing.T) {
+	b := NewTorus(geom.NewVector(1, 1, 1), geom.NewVector(0, 1, 0), 2, 0.5).Bounds()
+	if geom.Len(geom.Sub(b.Min, geom.NewVector(-1.5, 0.5, -1.5))) > 1e-9 ||
+		geom.Len(geom.Sub(b.Max, geom.NewVector(3.5, 1.5, 3.5))) > 1e-9 {
+		t.Errorf("Unexpected torus bounds %v", b)
+	}
+}
+
+func TestTorusValidate(t *testing.T) {
+	o := geom.NewVector(0, 0, 0)
+	up := geom.NewVector(0, 0, 1)
+
+	tests := []struct {
+		description string
+		torus       *Torus
+		err         string
+	}{
+		{"ring", NewTorus(o, up, 2, 0.5), ""},
+		{"no axis", NewTorus(o, geom.Vector{}, 2, 0.5), "no length"},
+		{"no tube", NewTorus(o, up, 2, 0), "ring"},
+		{"horn", NewTorus(o, up, 1, 1), "ring"},
+		{"spindle", NewTorus(o, up, 0.5, 1), "ring"},
+	}
+
+	ray := geom.NewRay(geom.NewVector(-5, 0, 0), geom.NewVector(1, 0, 0))
+	for _, test := range tests {
+		err := test.torus.Validate()
+		switch {
+		case test.err == "" && err != nil:
+			t.Errorf("%s: unexpected error: %s", test.description, err)
+		case test.err != "" && err == nil:
+			t.Errorf("%s: expected an error containing %q", test.description, test.err)
+		case test.err != "" && !strings.Contains(err.Error(), test.err):
+			t.Errorf("%s: expected an error containing %q but got %q", test.description, test.err, err)
+		}
+
+		if test.err == "" {
+			continue
+		}
+		if test.torus.Intersect(ray) || test.torus.Contains(geom.NewVector(2, 0, 0)) || len(test.torus.Intervals(ray)) > 0 {
+			t.Errorf("%s: expected an invalid torus to be empty", test.description)
+		}
+		if !test.torus.Bounds().IsEmpty() {
+			t.Errorf("%s: expected an invalid torus to have empty bounds", test.description)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"math"
+	"testing"
+
+	"github.com/fmi/go-homework/geom"
+)
+
+// transformed returns `obj` transformed by the invertible matrix `m`.
+func transformed(obj geom.Intersectable, m geom.Matrix) *geom.Instance {
+	instance, err := geom.Transformed(obj, m)
+	if err != nil {
+		panic(err)
+	}
+	return instance
+}
+
+func TestTransformedSingular(t *testing.T) {
+	sphere := NewSphere(geom.NewVector(0, 0, 0), 1)
+	instance, err := geom.Transformed(sphere, geom.Scale(geom.NewVector(1, 0, 1)))
+	if instance != nil || err != geom.ErrSingularMatrix {
+		t.Errorf("Expected a flattening transformation to be rejected, but got %v and error %v", instance, err)
+	}
+}
+
+func TestTransformedSphereIsEllipsoid(t *testing.T) {
+	ellipsoid := transformed(
+		NewSphere(geom.NewVector(0, 0, 0), 1),
+		geom.Scale(geom.NewVector(3, 1, 1)),
+	)
+
+	checkFigure(t, ellipsoid, geom.NewRay(geom.NewVector(2.9, 0, -5), geom.NewVector(0, 0, 1)), true)
+	checkFigure(t, ellipsoid, geom.NewRay(geom.NewVector(0, 1.1, -5), geom.NewVector(0, 0, 1)), false)
+
+	ray := geom.NewRay(geom.NewVector(-10, 0, 0), geom.NewVector(2, 0, 0))
+	hit, ok := ellipsoid.IntersectHit(ray)
+	if !ok {
+		t.Fatalf("Expected ray %#v to hit the ellipsoid", ray)
+	}
+	checkHit(t, hit, 3.5, geom.NewVector(-3, 0, 0), geom.NewVector(-1, 0, 0), true)
+
+	ray = geom.NewRay(geom.NewVector(0, 0, 0), geom.NewVector(1, 1, 0))
+	hit, ok = ellipsoid.IntersectHit(ray)
+	if !ok {
+		t.Fatalf("Expected ray %#v to hit the ellipsoid", ray)
+	}
+	tt := 3 / math.Sqrt(10)
+	checkHit(t, hit, tt, geom.NewVector(tt, tt, 0), geom.Normalize(geom.NewVector(-1.0/9, -1, 0)), false)
+}
+
+func TestTransformedInstances(t *testing.T) {
+	triangle := NewTriangle(
+		geom.NewVector(-1, -1, 0),
+		geom.NewVector(1, -1, 0),
+		geom.NewVector(0, 1, 0),
+	)
+	moved := transformed(triangle, geom.Translate(geom.NewVector(10, 0, 0)))
+	turned := transformed(triangle, geom.RotateY(math.Pi/2))
+
+	ray := geom.NewRay(geom.NewVector(0, 0, -1), geom.NewVector(0, 0, 1))
+	checkFigure(t, triangle, ray, true)
+	checkFigure(t, moved, ray, false)
+	checkFigure(t, turned, ray, false)
+
+	ray = geom.NewRay(geom.NewVector(10, 0, -1), geom.NewVector(0, 0, 1))
+	checkFigure(t, moved, ray, true)
+
+	ray = geom.NewRay(geom.NewVector(-5, 0, 0.25), geom.NewVector(1, 0, 0))
+	hit, ok := turned.IntersectHit(ray)
+	if !ok {
+		t.Fatalf("Expected ray %#v to hit the rotated triangle", ray)
+	}
+	checkHit(t, hit, 5, geom.NewVector(0, 0, 0.25), geom.NewVector(-1, 0, 0), hit.FrontFace)
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/fmi/go-homework/geom"
+)
+
+// closedMesh returns the triangles of a closed surface around the origin. It
+// is an octahedron with each face subdivided `n` times in each direction,
+// projected on a sphere with randomly perturbed radius. Neighbouring
+// triangles share their edges exactly.
+func closedMesh(rnd *rand.Rand, n int, algorithm geom.TriangleAlgorithm) []*Triangle {
+	corners := []geom.Vector{
+		geom.NewVector(1, 0, 0), geom.NewVector(0, 1, 0), geom.NewVector(-1, 0, 0),
+		geom.NewVector(0, -1, 0), geom.NewVector(0, 0, 1), geom.NewVector(0, 0, -1),
+	}
+	faces := [][3]int{
+		{0, 1, 4}, {1, 2, 4}, {2, 3, 4}, {3, 0, 4},
+		{1, 0, 5}, {2, 1, 5}, {3, 2, 5}, {0, 3, 5},
+	}
+
+	// Points on the shared edges must be computed from the same values in
+	// every face, so they are cached by their direction.
+	radii := make(map[geom.Vector]float64)
+	point := func(d geom.Vector) geom.Vector {
+		d = geom.Normalize(d)
+		d = geom.NewVector(round(d.X), round(d.Y), round(d.Z))
+		r, ok := radii[d]
+		if !ok {
+			r = 0.8 + 0.4*rnd.Float64()
+			radii[d] = r
+		}
+		return geom.Mul(d, r)
+	}
+
+	var triangles []*Triangle
+	for _, f := range faces {
+		a, b, c := corners[f[0]], corners[f[1]], corners[f[2]]
+		vertex := func(i, j int) geom.Vector {
+			// Integer weights keep the directions of shared points equal.
+			return point(geom.Add(geom.Add(
+				geom.Mul(a, float64(n-i-j)),
+				geom.Mul(b, float64(i))),
+				geom.Mul(c, float64(j))))
+		}
+
+		for i := 0; i < n; i++ {
+			for j := 0; i+j < n; j++ {
+				triangles = append(triangles,
+					NewTriangle(vertex(i, j), vertex(i+1, j), vertex(i, j+1)).WithAlgorithm(algorithm))
+				if i+j+1 < n {
+					triangles = append(triangles,
+						NewTriangle(vertex(i+1, j), vertex(i+1, j+1), vertex(i, j+1)).WithAlgorithm(algorithm))
+				}
+			}
+		}
+	}
+
+	return triangles
+}
+
+// round rounds `f` to 12 decimal places, so directions computed in a
+// different order become equal.
+func round(f float64) float64 {
+	return math.Round(f*1e12) / 1e12
+}
+
+// edgeRays returns `count` rays starting near the center of the mesh and
+// aimed at vertices and edges of random triangles, which are the places
+// where rays slip through.
+func edgeRays(rnd *rand.Rand, triangles []*Triangle, count int) []geom.Ray {
+	rays := make([]geom.Ray, count)
+	for i := range rays {
+		t := triangles[rnd.Intn(len(triangles))]
+		vertices := [3]vector{t.a, t.b, t.c}
+		k := rnd.Intn(3)
+		p, q := vectorToGeom(vertices[k]), vectorToGeom(vertices[(k+1)%3])
+
+		target := p
+		if i%4 != 0 {
+			s := rnd.Float64()
+			target = geom.Add(geom.Mul(p, 1-s), geom.Mul(q, s))
+		}
+
+		origin := randomVector(rnd, 0.1)
+		rays[i] = geom.NewRay(origin, geom.Sub(target, origin))
+	}
+	return rays
+}
+
+func TestWatertightClosedMesh(t *testing.T) {
+	count := 1 << 20
+	if testing.Short() {
+		count = 1 << 14
+	}
+
+	rnd := rand.New(rand.NewSource(42))
+	triangles := closedMesh(rnd, 16, geom.Watertight)
+	objects := make([]geom.Intersectable, len(triangles))
+	for i, triangle := range triangles {
+		objects[i] = triangle
+	}
+	bvh := geom.NewBVH(objects)
+
+	leaks := 0
+	for _, ray := range edgeRays(rnd, triangles, count) {
+		if !bvh.Intersect(ray) {
+			leaks++
+			if leaks <= 5 {
+				t.Errorf("Ray %+v leaked through the closed mesh", ray)
+			}
+		}
+	}
+	if leaks > 0 {
+		t.Errorf("%d of %d rays leaked through the closed mesh", leaks, count)
+	}
+}
+
+func TestTriangleAlgorithmsAgree(t *testing.T) {
+	rnd := rand.New(rand.NewSource(7))
+
+	for i := 0; i < 1000; i++ {
+		a, b, c := randomVector(rnd, 1), randomVector(rnd, 1), randomVector(rnd, 1)
+		moller := NewTriangle(a, b, c)
+		watertight := moller.WithAlgorithm(geom.Watertight)
+
+		// Rays aimed at points safely inside or outside the triangle.
+		w1, w2 := rnd.Float64()*1.2-0.1, rnd.Float64()*1.2-0.1
+		if math.Abs(w1) < 1e-3 || math.Abs(w2) < 1e-3 || math.Abs(w1+w2-1) < 1e-3 {
+			continue
+		}
+		target := geom.Add(a, geom.Add(geom.Mul(geom.Sub(b, a), w1), geom.Mul(geom.Sub(c, a), w2)))
+		origin := randomVector(rnd, 3)
+		ray := geom.NewRay(origin, geom.Sub(target, origin))
+
+		hit1, ok1 := moller.IntersectHit(ray)
+		hit2, ok2 := watertight.IntersectHit(ray)
+		if ok1 != ok2 {
+			t.Errorf("Algorithms disagree for triangle %v %v %v and ray %+v", a, b, c, ray)
+			continue
+		}
+		if !ok1 {
+			continue
+		}
+
+		checkHit(t, hit2, hit1.T, hit1.Point, hit1.Normal, hit1.FrontFace)
+		checkFloat(t, "u", hit2.U, hit1.U)
+		checkFloat(t, "v", hit2.V, hit1.V)
+	}
+}